@@ -17,9 +17,12 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/spf13/afero"
 	"github.com/spf13/viper"
 	"github.com/yandex/pandora/core/config"
 	"github.com/yandex/pandora/core/engine"
+	"github.com/yandex/pandora/core/register"
+	"github.com/yandex/pandora/lib/confutil"
 	"github.com/yandex/pandora/lib/zaputil"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -75,6 +78,10 @@ func DefaultConfig() *CliConfig {
 				Enabled: false,
 				File:    "memprofile.log",
 			},
+			Control: &controlConfig{
+				Enabled: false,
+				Port:    1235,
+			},
 		},
 	}
 }
@@ -82,20 +89,54 @@ func DefaultConfig() *CliConfig {
 // TODO(skipor): make nice spf13/cobra CLI and integrate it with viper
 // TODO(skipor): on special command (help or smth else) print list of available plugins
 
+// commands are run by pandora <command> [flags] [args] instead of test.
+var commands = map[string]func(args []string) error{
+	convertCommand: runConvert,
+	reportCommand:  runReport,
+	schemaCommand:  runSchema,
+	encryptCommand: runEncrypt,
+}
+
 func Run() {
+	for _, c := range register.Commands() {
+		if commands[c.Name] == nil {
+			commands[c.Name] = c.Run
+		}
+	}
+	if len(os.Args) > 1 && commands[os.Args[1]] != nil {
+		err := commands[os.Args[1]](os.Args[2:])
+		if err == flag.ErrHelp {
+			return
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Pandora %s failed: %s\n", os.Args[1], err)
+			os.Exit(1)
+		}
+		return
+	}
 	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage of Pandora: pandora [<config_filename>]\n"+"<config_filename> is './%s.(yaml|json|...)' by default\n", defaultConfigFile)
+		fmt.Fprintf(os.Stderr, "Usage of Pandora: pandora [flags] [<config_filename> [<overlay_filename>...]]\n"+"<config_filename> is './%s.(yaml|json|...)' by default\n", defaultConfigFile)
+		fmt.Fprintf(os.Stderr, "Overlay files are deep merged into config in order, and then --set values are applied.\n")
+		fmt.Fprintf(os.Stderr, "       pandora %s [flags] <binary_results_file>\n", convertCommand)
+		fmt.Fprintf(os.Stderr, "       pandora %s [flags] <results_file>\n", reportCommand)
+		fmt.Fprintf(os.Stderr, "       pandora %s [flags]\n", schemaCommand)
+		fmt.Fprintf(os.Stderr, "       pandora %s [flags] < secret_file\n", encryptCommand)
+		for _, c := range register.Commands() {
+			fmt.Fprintf(os.Stderr, "       pandora %s %s\n", c.Name, c.Usage)
+		}
 		flag.PrintDefaults()
 	}
 	var (
 		example bool
 		expvar  bool
 		version bool
+		sets    stringsFlag
 	)
 	flag.BoolVar(&example, "example", false, "print example config to STDOUT and exit")
 	flag.BoolVar(&version, "version", false, "print pandora core version")
 	flag.BoolVar(&expvar, "expvar", false, "enable expvar service (DEPRECATED, use monitoring config section instead)")
-	flag.Parse()
+	flag.Var(&sets, "set", "set config value, e.g. --set pools.0.gun.target=host:80. Could be repeated")
+	args := parseInterspersed(flag.CommandLine, os.Args[1:])
 
 	if expvar {
 		fmt.Fprintf(os.Stderr, "-expvar flag is DEPRECATED. Use monitoring config section instead\n")
@@ -111,11 +152,15 @@ func Run() {
 		return
 	}
 
-	ReadConfigAndRunEngine()
+	readConfigAndRunEngine(args, sets)
 }
 
 func ReadConfigAndRunEngine() {
-	conf := readConfig(flag.Args())
+	readConfigAndRunEngine(flag.Args(), nil)
+}
+
+func readConfigAndRunEngine(args []string, sets []string) {
+	conf := readConfig(args, sets)
 	log := newLogger(conf.Log)
 	zap.ReplaceGlobals(log)
 	zap.RedirectStdLog(log)
@@ -126,6 +171,7 @@ func ReadConfigAndRunEngine() {
 	startReport(m)
 
 	pandora := engine.New(log, m, conf.Engine)
+	startControl(conf.Monitoring.Control, pandora)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -191,7 +237,39 @@ func runEngine(ctx context.Context, engine *engine.Engine, errs chan error) {
 	errs <- engine.Run(ctx)
 }
 
-func readConfig(args []string) *CliConfig {
+// parseInterspersed parses flags, that could be placed after positional arguments,
+// e.g. pandora load.yaml --set pools.0.gun.target=host:80, and returns positional arguments.
+// Arguments after "--" terminator are positional.
+func parseInterspersed(flags *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		// Errors are handled by flags error handling.
+		_ = flags.Parse(args)
+		rest := flags.Args()
+		if parsed := len(args) - len(rest); parsed > 0 && args[parsed-1] == "--" {
+			return append(positional, rest...)
+		}
+		args = rest
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+type stringsFlag []string
+
+func (f *stringsFlag) String() string { return strings.Join(*f, ",") }
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// readConfig reads config file of the first argument or default config file, resolves its
+// includes, merges overlay files of the rest arguments, and applies sets.
+func readConfig(args []string, sets []string) *CliConfig {
 	log, err := zap.NewDevelopment(zap.AddCaller())
 	if err != nil {
 		panic(err)
@@ -205,8 +283,6 @@ func readConfig(args []string) *CliConfig {
 	var useStdinConfig = false
 	if len(args) > 0 {
 		switch {
-		case len(args) > 1:
-			zap.L().Fatal("Too many command line arguments", zap.Strings("args", args))
 		case args[0] == stdinConfigSelector:
 			log.Info("Reading config from standard input")
 			useStdinConfig = true
@@ -236,18 +312,42 @@ func readConfig(args []string) *CliConfig {
 			log.Fatal("Config read failed", zap.Error(err))
 		}
 	}
-	pools := v.Get("pools").([]any)
-	for i, pool := range pools {
-		poolMap := pool.(map[string]any)
+	fs := afero.NewOsFs()
+	configDir := "."
+	if !useStdinConfig {
+		configDir = filepath.Dir(v.ConfigFileUsed())
+	}
+	settings, err := confutil.ResolveIncludes(fs, v.AllSettings(), configDir)
+	if err != nil {
+		log.Fatal("Config includes resolve failed", zap.Error(err))
+	}
+	for _, overlayFile := range args[min(1, len(args)):] {
+		log.Info("Reading config overlay", zap.String("file", overlayFile))
+		overlay, err := confutil.ReadConfigFile(fs, overlayFile)
+		if err != nil {
+			log.Fatal("Config overlay read failed", zap.Error(err))
+		}
+		settings = confutil.MergeConfig(settings, overlay)
+	}
+	for _, set := range sets {
+		if err := confutil.SetConfigValue(settings, set); err != nil {
+			log.Fatal("Config value set failed", zap.Error(err))
+		}
+	}
+
+	pools, _ := settings["pools"].([]any)
+	for _, pool := range pools {
+		poolMap, ok := pool.(map[string]any)
+		if !ok {
+			continue
+		}
 		if _, ok := poolMap["discard_overflow"]; !ok {
 			poolMap["discard_overflow"] = true
 		}
-		pools[i] = poolMap
 	}
-	v.Set("pools", pools)
 
 	conf := DefaultConfig()
-	err = config.DecodeAndValidate(v.AllSettings(), conf)
+	err = config.DecodeAndValidate(settings, conf)
 	if err != nil {
 		log.Fatal("Config decode failed", zap.Error(err))
 	}
@@ -267,6 +367,7 @@ type monitoringConfig struct {
 	Expvar     *expvarConfig
 	CPUProfile *cpuprofileConfig
 	MemProfile *memprofileConfig
+	Control    *controlConfig
 }
 
 type expvarConfig struct {
@@ -284,6 +385,25 @@ type memprofileConfig struct {
 	File    string `config:"file"`
 }
 
+// controlConfig enables local HTTP API to change load of running test.
+type controlConfig struct {
+	Enabled bool `config:"enabled"`
+	Port    int  `config:"port" validate:"required"`
+}
+
+func startControl(conf *controlConfig, pandora *engine.Engine) {
+	if conf == nil || !conf.Enabled {
+		return
+	}
+	addr := "localhost:" + strconv.Itoa(conf.Port)
+	handler := engine.NewControlHandler(pandora.EnableControl())
+	zap.L().Info("Starting control API", zap.String("addr", addr))
+	go func() {
+		err := http.ListenAndServe(addr, handler)
+		zap.L().Fatal("Control server failed", zap.Error(err))
+	}()
+}
+
 func startMonitoring(conf monitoringConfig) (stop func()) {
 	zap.L().Debug("Start monitoring", zap.Reflect("conf", conf))
 	if conf.Expvar != nil {
@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseInterspersed(t *testing.T) {
+	tests := []struct {
+		name           string
+		args           []string
+		wantPositional []string
+		wantSets       []string
+	}{
+		{
+			name:           "flags after positional",
+			args:           []string{"load.yaml", "--set", "a=1", "overlay.yaml", "--set=b=2"},
+			wantPositional: []string{"load.yaml", "overlay.yaml"},
+			wantSets:       []string{"a=1", "b=2"},
+		},
+		{
+			name:           "terminator",
+			args:           []string{"--set", "a=1", "load.yaml", "--", "--set", "-"},
+			wantPositional: []string{"load.yaml", "--set", "-"},
+			wantSets:       []string{"a=1"},
+		},
+		{
+			name:           "terminator first",
+			args:           []string{"--", "--set=a=1"},
+			wantPositional: []string{"--set=a=1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := flag.NewFlagSet("pandora", flag.ContinueOnError)
+			var sets stringsFlag
+			flags.Var(&sets, "set", "")
+			positional := parseInterspersed(flags, tt.args)
+			assert.Equal(t, tt.wantPositional, positional)
+			assert.Equal(t, tt.wantSets, []string(sets))
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+	"github.com/yandex/pandora/core/aggregator/netsample"
+)
+
+const convertCommand = "convert"
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// runConvert converts results written by binary aggregator to phout or jsonlines.
+func runConvert(args []string) error {
+	flags := flag.NewFlagSet(convertCommand, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage of Pandora: pandora %s [flags] <binary_results_file>\n"+
+			"Converts results of binary aggregator. Gzip and zstd compressed files are detected automatically.\n"+
+			"<binary_results_file> is STDIN, if it is '-' or not set\n", convertCommand)
+		flags.PrintDefaults()
+	}
+	var (
+		conf   netsample.ConvertConfig
+		output string
+	)
+	flags.StringVar(&conf.Format, "format", netsample.FormatPhout, "output format: phout or jsonlines")
+	flags.BoolVar(&conf.ID, "id", false, "print ammo ids in phout")
+	flags.StringVar(&output, "o", "", "output file. STDOUT by default")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return errors.New("too many arguments")
+	}
+
+	var in io.Reader = os.Stdin
+	if input := flags.Arg(0); input != "" && input != stdinConfigSelector {
+		file, err := os.Open(input)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		in = file
+	}
+	in, closeIn, err := decompress(in)
+	if err != nil {
+		return errors.WithMessage(err, "input decompression failed")
+	}
+	defer closeIn()
+
+	var out io.Writer = os.Stdout
+	if output != "" {
+		file, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+	return netsample.ConvertBinary(out, in, conf)
+}
+
+// decompress returns reader of decompressed input, if input is gzip or zstd compressed.
+func decompress(r io.Reader) (io.Reader, func(), error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(len(zstdMagic))
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, nil, err
+		}
+		return gr, func() { _ = gr.Close() }, nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, nil, err
+		}
+		return zr, zr.Close, nil
+	default:
+		return br, func() {}, nil
+	}
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/yandex/pandora/lib/confutil"
+)
+
+const encryptCommand = "encrypt"
+
+// runEncrypt encrypts secret value for ${aes:...} config tag.
+func runEncrypt(args []string) error {
+	flags := flag.NewFlagSet(encryptCommand, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage of Pandora: pandora %s [flags]\n"+
+			"Encrypts value from STDIN by key from %s env variable, and writes ${aes:...} config tag to STDOUT.\n",
+			encryptCommand, confutil.SecretKeyEnv)
+		flags.PrintDefaults()
+	}
+	var keygen bool
+	flags.BoolVar(&keygen, "keygen", false, fmt.Sprintf("write new random key for %s env variable and exit", confutil.SecretKeyEnv))
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return errors.New("too many arguments")
+	}
+	if keygen {
+		key, err := confutil.NewSecretKey()
+		if err != nil {
+			return err
+		}
+		fmt.Println(key)
+		return nil
+	}
+
+	encodedKey, ok := os.LookupEnv(confutil.SecretKeyEnv)
+	if !ok {
+		return confutil.ErrSecretKeyNotProvided
+	}
+	key, err := confutil.ParseSecretKey(encodedKey)
+	if err != nil {
+		return err
+	}
+	value, err := io.ReadAll(bufio.NewReader(os.Stdin))
+	if err != nil {
+		return err
+	}
+	encrypted, err := confutil.EncryptValue(key, strings.TrimRight(string(value), "\r\n"))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("${aes:%s}\n", encrypted)
+	return nil
+}
@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/yandex/pandora/lib/report"
+)
+
+const reportCommand = "report"
+
+// runReport writes HTML and Markdown report of phout, jsonlines or binary results.
+func runReport(args []string) error {
+	flags := flag.NewFlagSet(reportCommand, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage of Pandora: pandora %s [flags] <results_file>\n"+
+			"Writes report of phout, jsonlines or binary results. Gzip and zstd compressed files are detected automatically.\n"+
+			"Markdown report is written to STDOUT, if no output file is set.\n", reportCommand)
+		flags.PrintDefaults()
+	}
+	var (
+		htmlOutput       string
+		markdownOutput   string
+		title            string
+		baseline         string
+		failOnRegression bool
+		compareConf      = report.DefaultCompareConfig()
+	)
+	flags.StringVar(&htmlOutput, "html", "", "HTML report file")
+	flags.StringVar(&markdownOutput, "md", "", "Markdown report file")
+	flags.StringVar(&title, "title", "", "report title. Results file name by default")
+	flags.StringVar(&baseline, "baseline", "", "baseline results file to compare with")
+	flags.Float64Var(&compareConf.LatencyThreshold, "latency-threshold", compareConf.LatencyThreshold,
+		"relative latency increase, that is regression")
+	flags.Float64Var(&compareConf.ErrorThreshold, "error-threshold", compareConf.ErrorThreshold,
+		"absolute error rate increase, that is regression")
+	flags.BoolVar(&failOnRegression, "fail-on-regression", false, "exit with non zero code, if regression is found")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("one results file should be passed")
+	}
+
+	input := flags.Arg(0)
+	if title == "" {
+		title = filepath.Base(input)
+	}
+	r, err := readReport(input, title)
+	if err != nil {
+		return err
+	}
+	if baseline != "" {
+		base, err := readReport(baseline, filepath.Base(baseline))
+		if err != nil {
+			return errors.WithMessage(err, "baseline")
+		}
+		report.Compare(r, base, compareConf)
+	}
+
+	if htmlOutput == "" && markdownOutput == "" {
+		if err := report.WriteMarkdown(os.Stdout, r); err != nil {
+			return err
+		}
+	}
+	if err := writeReport(htmlOutput, r, report.WriteHTML); err != nil {
+		return err
+	}
+	if err := writeReport(markdownOutput, r, report.WriteMarkdown); err != nil {
+		return err
+	}
+	if failOnRegression && r.Comparison != nil && r.Comparison.Regressions > 0 {
+		return errors.Errorf("%v regressions found", r.Comparison.Regressions)
+	}
+	return nil
+}
+
+func readReport(filename string, title string) (*report.Report, error) {
+	var in io.Reader = os.Stdin
+	if filename != stdinConfigSelector {
+		file, err := os.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		in = file
+	}
+	in, closeIn, err := decompress(in)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "%s decompression failed", filename)
+	}
+	defer closeIn()
+	r, err := report.Read(in, title)
+	return r, errors.WithMessagef(err, "%s read failed", filename)
+}
+
+func writeReport(filename string, r *report.Report, write func(io.Writer, *report.Report) error) (err error) {
+	if filename == "" {
+		return nil
+	}
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	return write(file, r)
+}
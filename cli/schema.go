@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/yandex/pandora/core/plugin/pluginconfig"
+	"github.com/yandex/pandora/lib/confutil"
+)
+
+const schemaCommand = "schema"
+
+// runSchema writes JSON Schema of config with all registered plugins.
+func runSchema(args []string) error {
+	flags := flag.NewFlagSet(schemaCommand, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage of Pandora: pandora %s [flags]\n"+
+			"Writes JSON Schema of config with all registered plugins, for config validation and autocompletion in editors.\n", schemaCommand)
+		flags.PrintDefaults()
+	}
+	var output string
+	flags.StringVar(&output, "o", "", "output file. STDOUT by default")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return errors.New("too many arguments")
+	}
+
+	var out io.Writer = os.Stdout
+	if output != "" {
+		file, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+	return writeSchema(out)
+}
+
+func writeSchema(out io.Writer) error {
+	schema, err := configSchema()
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(schema)
+}
+
+func configSchema() (map[string]interface{}, error) {
+	schema, err := pluginconfig.Schema(DefaultConfig())
+	if err != nil {
+		return nil, err
+	}
+	schema["title"] = "Pandora config"
+	files := map[string]interface{}{"anyOf": []interface{}{
+		map[string]interface{}{"type": "string"},
+		map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+	}}
+	properties := schema["properties"].(map[string]interface{})
+	properties[confutil.ExtendsKey] = files
+	properties[confutil.IncludeKey] = files
+	return schema, nil
+}
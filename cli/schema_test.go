@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	grpc "github.com/yandex/pandora/components/grpc/import"
+	phttp "github.com/yandex/pandora/components/phttp/import"
+	coreimport "github.com/yandex/pandora/core/import"
+	"github.com/yandex/pandora/lib/confutil"
+)
+
+func TestSchema_Examples(t *testing.T) {
+	fs := afero.NewOsFs()
+	coreimport.Import(fs)
+	phttp.Import(fs)
+	grpc.Import(fs)
+
+	out := &bytes.Buffer{}
+	require.NoError(t, writeSchema(out))
+	compiler := jsonschema.NewCompiler()
+	require.NoError(t, compiler.AddResource("schema.json", out))
+	schema, err := compiler.Compile("schema.json")
+	require.NoError(t, err)
+
+	examples, err := filepath.Glob("../examples/*.yaml")
+	require.NoError(t, err)
+	require.NotEmpty(t, examples)
+	for _, example := range examples {
+		conf, err := confutil.ReadConfigFile(fs, example)
+		require.NoError(t, err)
+		// Validate JSON values, as editors do.
+		data, err := json.Marshal(conf)
+		require.NoError(t, err)
+		assert.NoError(t, schema.Validate(decodeJSON(t, string(data))), example)
+	}
+
+	pool := `{"pools": [{
+		"gun": {"type": "http", "target": "localhost:80"%s},
+		"ammo": {"type": "uri", "file": "ammo.uri"},
+		"result": {"type": "discard"},
+		"rps": {"type": "const", "ops": 10, "duration": "%s"}
+	}]}`
+	assert.NoError(t, schema.Validate(decodeJSON(t, fmt.Sprintf(pool, "", "10s"))))
+	assert.Error(t, schema.Validate(decodeJSON(t, fmt.Sprintf(pool, `, "unknown": true`, "10s"))))
+	assert.Error(t, schema.Validate(decodeJSON(t, fmt.Sprintf(pool, "", "10 seconds"))))
+}
+
+func decodeJSON(t *testing.T, data string) interface{} {
+	var value interface{}
+	require.NoError(t, json.Unmarshal([]byte(data), &value))
+	return value
+}
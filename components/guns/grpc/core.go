@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"strconv"
 	"strings"
 	"time"
@@ -15,6 +16,7 @@ import (
 	"github.com/jhump/protoreflect/dynamic"
 	"github.com/jhump/protoreflect/dynamic/grpcdynamic"
 	"github.com/jhump/protoreflect/grpcreflect"
+	"github.com/spf13/afero"
 	ammo "github.com/yandex/pandora/components/providers/grpc"
 	"github.com/yandex/pandora/core"
 	"github.com/yandex/pandora/core/aggregator/netsample"
@@ -56,11 +58,7 @@ type GunConfig struct {
 	} `config:"shared-client,omitempty"`
 }
 
-type AnswLogConfig struct {
-	Enabled bool   `config:"enabled"`
-	Path    string `config:"path"`
-	Filter  string `config:"filter" valid:"oneof=all warning error"`
-}
+type AnswLogConfig = answlog.Config
 
 type Gun struct {
 	DebugLog bool
@@ -71,7 +69,10 @@ type Gun struct {
 	Stub     grpcdynamic.Stub
 	Services map[string]desc.MethodDescriptor
 
-	AnswLog *zap.Logger
+	AnswLog   *zap.Logger
+	answRules *answlog.Rules
+	// answLogCloser releases AnswLog initialized by Bind.
+	answLogCloser io.Closer
 }
 
 func DefaultGunConfig() GunConfig {
@@ -158,8 +159,7 @@ func (g *Gun) prepareClientPool() (*clientpool.Pool[grpcdynamic.Stub], error) {
 }
 
 func NewGun(conf GunConfig) *Gun {
-	answLog := answlog.Init(conf.AnswLog.Path, conf.AnswLog.Enabled)
-	return &Gun{Conf: conf, AnswLog: answLog}
+	return &Gun{Conf: conf}
 }
 
 func (g *Gun) Bind(aggr core.Aggregator, deps core.GunDeps) error {
@@ -181,6 +181,14 @@ func (g *Gun) Bind(aggr core.Aggregator, deps core.GunDeps) error {
 	g.Aggr = aggr
 	g.GunDeps = deps
 
+	if g.AnswLog == nil {
+		answLog, answLogCloser, err := answlog.Init(afero.NewOsFs(), g.Conf.AnswLog)
+		if err != nil {
+			return err
+		}
+		g.AnswLog, g.answLogCloser = answLog, answLogCloser
+	}
+
 	if ent := deps.Log.Check(zap.DebugLevel, "Gun bind"); ent != nil {
 		deps.Log.Warn("Deprecation Warning: log level: debug doesn't produce request/response logs anymore. Please use AnswLog option instead:\nanswlog:\n  enabled: true\n  filter: all|warning|error\n  path: answ.log")
 		g.DebugLog = true
@@ -189,6 +197,13 @@ func (g *Gun) Bind(aggr core.Aggregator, deps core.GunDeps) error {
 	return nil
 }
 
+func (g *Gun) Close() error {
+	if g.answLogCloser != nil {
+		return g.answLogCloser.Close()
+	}
+	return nil
+}
+
 func (g *Gun) Shoot(am core.Ammo) {
 	customAmmo := am.(*ammo.Ammo)
 	g.shoot(customAmmo)
@@ -238,26 +253,56 @@ func (g *Gun) shoot(ammo *ammo.Ammo) {
 		g.GunDeps.Log.Error("response error", zap.Error(err))
 	}
 
-	g.Answ(&method, message, ammo.Metadata, out, grpcErr, code)
+	g.Answ(sample, "", &method, message, ammo.Metadata, out, grpcErr, code)
 }
 
-func (g *Gun) Answ(method *desc.MethodDescriptor, message *dynamic.Message, metadata map[string]string, out proto.Message, grpcErr error, code int) {
-	if g.Conf.AnswLog.Enabled {
-		switch g.Conf.AnswLog.Filter {
-		case "all":
-			g.AnswLogging(g.AnswLog, method, message, metadata, out, grpcErr)
-
-		case "warning":
-			if code >= 400 {
-				g.AnswLogging(g.AnswLog, method, message, metadata, out, grpcErr)
-			}
+// Answ writes request and response to answer log, if it is enabled and code matches its rules.
+// Step is a name of scenario step, or empty string.
+func (g *Gun) Answ(sample *netsample.Sample, step string, method *desc.MethodDescriptor, message *dynamic.Message, metadata map[string]string, out proto.Message, grpcErr error, code int) {
+	if !g.Conf.AnswLog.Enabled {
+		return
+	}
+	if g.answRules == nil {
+		g.answRules = answlog.NewRules(g.Conf.AnswLog)
+	}
+	if !g.answRules.Match(code) {
+		return
+	}
+	if !g.answRules.JSON() {
+		g.AnswLogging(g.AnswLog, method, message, metadata, out, grpcErr)
+		return
+	}
+	g.answRules.Write(g.AnswLog, &answlog.Entry{
+		Time:     sample.Timestamp(),
+		AmmoID:   sample.ID(),
+		Tag:      sample.Tags(),
+		Step:     step,
+		Duration: time.Since(sample.Timestamp()),
+		Code:     code,
+		Err:      grpcErr,
+		Request: answlog.Message{
+			Method:  method.GetFullyQualifiedName(),
+			Headers: answlog.MetadataHeaders(metadata),
+			Body:    messageJSON(message),
+		},
+		Response: answlog.Message{
+			Status: status.Code(grpcErr).String(),
+			Body:   messageJSON(out),
+		},
+	})
+}
 
-		case "error":
-			if code >= 500 {
-				g.AnswLogging(g.AnswLog, method, message, metadata, out, grpcErr)
-			}
+// messageJSON returns JSON of dynamic message, or its text representation.
+func messageJSON(m proto.Message) []byte {
+	if m == nil {
+		return nil
+	}
+	if dm, ok := m.(*dynamic.Message); ok {
+		if data, err := dm.MarshalJSON(); err == nil {
+			return data
 		}
 	}
+	return []byte(m.String())
 }
 
 func (g *Gun) AnswLogging(logger *zap.Logger, method *desc.MethodDescriptor, request proto.Message, metadata map[string]string, response proto.Message, grpcErr error) {
@@ -5,6 +5,10 @@ import (
 
 	"github.com/golang/protobuf/proto"
 	"github.com/yandex/pandora/components/providers/scenario"
+	"github.com/yandex/pandora/components/providers/scenario/flow"
+	"github.com/yandex/pandora/components/providers/scenario/templater"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 type SourceStorage interface {
@@ -17,6 +21,9 @@ type Scenario struct {
 	Name            string
 	MinWaitingTime  time.Duration
 	VariableStorage SourceStorage
+	FailurePolicy   *scenario.FailurePolicy
+	// Sequences are provider counters of seq template function.
+	Sequences *templater.Sequences
 }
 
 func (a *Scenario) SetID(id uint64) {
@@ -29,6 +36,8 @@ func (a *Scenario) Clone() scenario.ProvAmmo {
 		Name:            a.Name,
 		MinWaitingTime:  a.MinWaitingTime,
 		VariableStorage: a.VariableStorage,
+		FailurePolicy:   a.FailurePolicy,
+		Sequences:       a.Sequences,
 	}
 }
 
@@ -43,12 +52,39 @@ type Call struct {
 	Payload  []byte            `json:"payload"`
 
 	Sleep time.Duration `json:"sleep"`
+
+	// OnError overrides scenario failure policy for the call, if it is set.
+	OnError scenario.OnError `json:"-"`
+	Retry   scenario.Retry   `json:"-"`
+
+	// Control is set for condition, loop and choice steps. Such steps make no call
+	// by themselves, but run nested steps.
+	Control *flow.Control[Call] `json:"-"`
 }
 
 type Postprocessor interface {
 	Process(out proto.Message, code int) (map[string]any, error)
 }
 
+// Response is a result of call passed to ResponsePostprocessor.
+type Response struct {
+	// Out is nil, if call failed.
+	Out    proto.Message
+	Code   int
+	Status *status.Status
+	// Header and Trailer are response metadata.
+	Header       metadata.MD
+	Trailer      metadata.MD
+	ResponseTime time.Duration
+}
+
+// ResponsePostprocessor is a Postprocessor, that needs more than response message and code.
+// Gun calls ProcessResponse instead of Process for it.
+type ResponsePostprocessor interface {
+	Postprocessor
+	ProcessResponse(resp *Response) (map[string]any, error)
+}
+
 type Preprocessor interface {
 	Process(call *Call, templateVars map[string]any) (newVars map[string]any, err error)
 }
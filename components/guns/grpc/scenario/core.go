@@ -3,19 +3,24 @@ package scenario
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/rand"
 	"time"
 
 	"github.com/jhump/protoreflect/dynamic"
 	grpcgun "github.com/yandex/pandora/components/guns/grpc"
+	"github.com/yandex/pandora/components/providers/scenario"
+	"github.com/yandex/pandora/components/providers/scenario/templater"
 	"github.com/yandex/pandora/core"
 	"github.com/yandex/pandora/core/aggregator/netsample"
 	"github.com/yandex/pandora/core/warmup"
 	"github.com/yandex/pandora/lib/answlog"
 	"go.uber.org/zap"
 	"golang.org/x/exp/maps"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 const defaultTimeout = time.Second * 15
@@ -35,11 +40,7 @@ type GrpcDialOptions struct {
 	Timeout   time.Duration `config:"timeout"`
 }
 
-type AnswLogConfig struct {
-	Enabled bool   `config:"enabled"`
-	Path    string `config:"path"`
-	Filter  string `config:"filter" valid:"oneof=all warning error"`
-}
+type AnswLogConfig = answlog.Config
 
 func DefaultGunConfig() GunConfig {
 	return GunConfig{
@@ -53,7 +54,6 @@ func DefaultGunConfig() GunConfig {
 }
 
 func NewGun(conf GunConfig) *Gun {
-	answLog := answlog.Init(conf.AnswLog.Path, conf.AnswLog.Enabled)
 	r := rand.New(rand.NewSource(0)) //TODO: use real random
 	return &Gun{
 		templ: NewTextTemplater(),
@@ -67,13 +67,8 @@ func NewGun(conf GunConfig) *Gun {
 				Authority: conf.DialOptions.Authority,
 				Timeout:   conf.DialOptions.Timeout,
 			},
-			AnswLog: grpcgun.AnswLogConfig{
-				Enabled: conf.AnswLog.Enabled,
-				Path:    conf.AnswLog.Path,
-				Filter:  conf.AnswLog.Filter,
-			},
-		},
-			AnswLog: answLog},
+			AnswLog: conf.AnswLog,
+		}},
 		rand: r,
 	}
 }
@@ -82,6 +77,7 @@ type Gun struct {
 	gun   *grpcgun.Gun
 	rand  *rand.Rand
 	templ Templater
+	seqs  *templater.Sequences
 }
 
 func (g *Gun) WarmUp(opts *warmup.Options) (interface{}, error) {
@@ -92,8 +88,19 @@ func (g *Gun) Bind(aggr core.Aggregator, deps core.GunDeps) error {
 	return g.gun.Bind(aggr, deps)
 }
 
+func (g *Gun) Close() error {
+	return g.gun.Close()
+}
+
 func (g *Gun) Shoot(am core.Ammo) {
 	scen := am.(*Scenario)
+	if _, ok := g.templ.(*TextTemplater); ok && scen.Sequences != nil && scen.Sequences != g.seqs {
+		// Templates are parsed with seq function bound to provider counters, so templater is recreated for them.
+		g.seqs = scen.Sequences
+		templ := &TextTemplater{}
+		templ.InitSequences(g.seqs)
+		g.templ = templ
+	}
 
 	templateVars := map[string]any{}
 	if scen.VariableStorage != nil {
@@ -101,8 +108,20 @@ func (g *Gun) Shoot(am core.Ammo) {
 	} else {
 		templateVars["source"] = map[string]any{}
 	}
+	templateVars["instance"] = map[string]any{"id": g.gun.InstanceID}
 
 	err := g.shoot(scen, templateVars)
+	if errors.Is(err, scenario.ErrShootingStopped) {
+		return
+	}
+	var abortErr *scenario.AbortError
+	if errors.As(err, &abortErr) {
+		g.gun.Log.Error("Shooting aborted", zap.Uint64("request", scen.id), zap.Error(err))
+		if g.gun.Abort != nil {
+			g.gun.Abort(err)
+		}
+		return
+	}
 	if err != nil {
 		g.gun.Log.Warn("Invalid ammo", zap.Uint64("request", scen.id), zap.Error(err))
 		return
@@ -123,22 +142,91 @@ func (g *Gun) shoot(ammo *Scenario, templateVars map[string]any) error {
 	}
 
 	startAt := time.Now()
-	for _, call := range ammo.Calls {
-		tag := ammo.Name + "." + call.Tag
-		sample := netsample.Acquire(tag)
-
-		err := g.shootStep(&call, sample, ammo.Name, templateVars, requestVars)
-		if err != nil {
-			return err
-		}
+	// Successful transaction has code of successful call.
+	tx := scenario.StartTransaction(g.gun.Ctx, ammo.Name, grpcgun.ConvertGrpcStatus(nil))
+	err := g.shootSteps(tx, ammo, ammo.Calls, templateVars, requestVars)
+	if errors.Is(err, scenario.ErrShootingStopped) {
+		return err
+	}
+	g.gun.Aggr.Report(tx.Finish())
+	if err != nil {
+		return err
 	}
 	spent := time.Since(startAt)
 	if ammo.MinWaitingTime > spent {
 		time.Sleep(ammo.MinWaitingTime - spent)
 	}
+	// Errors of calls with continue policy are reported by transaction sample only.
+	return nil
+}
+
+func (g *Gun) shootSteps(tx *scenario.Transaction, ammo *Scenario, calls []Call, templateVars map[string]any, requestVars map[string]any) error {
+	for _, call := range calls {
+		if call.Control != nil {
+			var stepsFailed bool
+			err := call.Control.Exec(templateVars, func(steps []Call) error {
+				err := g.shootSteps(tx, ammo, steps, templateVars, requestVars)
+				stepsFailed = err != nil
+				return err
+			})
+			if err != nil {
+				tx.Fail(call.Name, err)
+				if !stepsFailed {
+					// Error of control itself, e.g. loop has reached max iterations. Errors of nested calls are already handled.
+					err = ammo.FailurePolicy.Handle(ammo.Name, call.OnError, err)
+				}
+				if err != nil {
+					return err
+				}
+			}
+			if err := tx.Sleep(call.Sleep); err != nil {
+				return err
+			}
+			continue
+		}
+		err := g.shootWithRetry(tx, ammo, &call, templateVars, requestVars)
+		if errors.Is(err, scenario.ErrShootingStopped) {
+			return err
+		}
+		if err != nil {
+			tx.Fail(call.Name, err)
+			err = ammo.FailurePolicy.Handle(ammo.Name, call.OnError, err)
+			if err != nil {
+				return err
+			}
+		}
+		if err := tx.Sleep(call.Sleep); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// shootWithRetry shoots call and retries it on failure, if it is configured.
+// Every attempt is reported as a separate sample, retry attempts have additional retry tag.
+// Retry delays are not included into transaction duration.
+func (g *Gun) shootWithRetry(tx *scenario.Transaction, ammo *Scenario, call *Call, templateVars map[string]any, requestVars map[string]any) error {
+	tag := ammo.Name + "." + call.Tag
+	var err error
+	for attempt := 0; attempt <= call.Retry.Attempts; attempt++ {
+		if attempt > 0 {
+			if sleepErr := tx.Sleep(call.Retry.Delay(attempt)); sleepErr != nil {
+				return sleepErr
+			}
+		}
+		sample := netsample.Acquire(tag)
+		if attempt > 0 {
+			sample.AddTag(scenario.RetryTag(attempt))
+		}
+
+		err = g.shootStep(call, sample, ammo.Name, templateVars, requestVars)
+		if err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
 func (g *Gun) shootStep(step *Call, sample *netsample.Sample, ammoName string, templateVars map[string]any, requestVars map[string]any) error {
 	const op = "base_gun.shootStep"
 	code := 0
@@ -195,26 +283,54 @@ func (g *Gun) shootStep(step *Call, sample *netsample.Sample, ammoName string, t
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 	ctx = metadata.NewOutgoingContext(ctx, metadata.New(step.Metadata))
-	out, grpcErr := g.gun.Stub.InvokeRpc(ctx, &method, message)
+	var header, trailer metadata.MD
+	callStart := time.Now()
+	out, grpcErr := g.gun.Stub.InvokeRpc(ctx, &method, message, grpc.Header(&header), grpc.Trailer(&trailer))
+	responseTime := time.Since(callStart)
 	code = grpcgun.ConvertGrpcStatus(grpcErr)
 	sample.SetProtoCode(code) // for setRTT inside
+	stepVars["status"] = code
 
 	if grpcErr != nil {
-		g.gun.GunDeps.Log.Error("response error", zap.Error(err))
+		g.gun.GunDeps.Log.Debug("response error", zap.Error(grpcErr))
 	}
 
-	g.gun.Answ(&method, message, step.Metadata, out, grpcErr, code)
+	g.gun.Answ(sample, step.Name, &method, message, step.Metadata, out, grpcErr, code)
 
+	resp := &Response{
+		Out:          out,
+		Code:         code,
+		Status:       status.Convert(grpcErr),
+		Header:       header,
+		Trailer:      trailer,
+		ResponseTime: responseTime,
+	}
+	postprocessorVars := map[string]any{}
 	for _, postProcessor := range step.Postprocessors {
-		pp, err := postProcessor.Process(out, code)
+		var pp map[string]any
+		if rp, ok := postProcessor.(ResponsePostprocessor); ok {
+			pp, err = rp.ProcessResponse(resp)
+		} else {
+			pp, err = postProcessor.Process(out, code)
+		}
 		if err != nil {
+			if scenario.IsAssertError(err) {
+				sample.AddTag(scenario.AssertFailedTag)
+			}
 			return fmt.Errorf("%s postProcessor %w", op, err)
 		}
-		stepVars = mergeMaps(stepVars, pp)
+		for k, v := range pp {
+			postprocessorVars[k] = v
+		}
 		if g.gun.DebugLog {
 			g.gun.GunDeps.Log.Debug("Postprocessor variables", zap.Any(fmt.Sprintf(".request.%s.postprocessor", step.Name), pp))
 		}
 	}
+	stepVars["postprocessor"] = postprocessorVars
+	if grpcErr != nil {
+		// Call with non-OK status fails after postprocessors, so its variables are still available.
+		return fmt.Errorf("%s call %s: %w", op, step.Call, grpcErr)
+	}
 	if out != nil {
 		// Postprocessor
 		// if it is nessesary
@@ -237,17 +353,14 @@ func (g *Gun) shootStep(step *Call, sample *netsample.Sample, ammoName string, t
 			// unexpected result
 			return fmt.Errorf("%s json.Unmarshal %w", op, err)
 		}
-		stepVars["postprocessor"] = outMap
+		// Extracted variables take precedence over response fields with the same name.
+		stepVars["postprocessor"] = mergeMaps(postprocessorVars, outMap)
 
 		if g.gun.DebugLog {
 			g.gun.GunDeps.Log.Debug("Postprocessor variables", zap.String(fmt.Sprintf(".resuest.%s.postprocessor", step.Name), out.String()))
 		}
 	}
 
-	if step.Sleep > 0 {
-		time.Sleep(step.Sleep)
-	}
-
 	return nil
 }
 
@@ -11,12 +11,14 @@ import (
 	"net/http/httptrace"
 	"net/http/httputil"
 	"net/url"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/yandex/pandora/core"
 	"github.com/yandex/pandora/core/aggregator/netsample"
 	"github.com/yandex/pandora/core/clientpool"
 	"github.com/yandex/pandora/core/warmup"
+	"github.com/yandex/pandora/lib/answlog"
 	"github.com/yandex/pandora/lib/netutil"
 	"go.uber.org/zap"
 )
@@ -33,11 +35,7 @@ type AutoTagConfig struct {
 	NoTagOnly   bool `config:"no-tag-only"`                   // When true, autotagged only ammo that has no tag before.
 }
 
-type AnswLogConfig struct {
-	Enabled bool   `config:"enabled"`
-	Path    string `config:"path"`
-	Filter  string `config:"filter" valid:"oneof=all warning error"`
-}
+type AnswLogConfig = answlog.Config
 
 type HTTPTraceConfig struct {
 	DumpEnabled  bool `config:"dump"`
@@ -67,8 +65,10 @@ type BaseGun struct {
 	OnClose           func() error                    // Optional. Called on Close().
 	Aggregator        netsample.Aggregator            // Lazy set via BindResultTo.
 	AnswLog           *zap.Logger
+	AnswLogCloser     io.Closer // Optional. Releases AnswLog on Close().
 	Client            Client
 	ClientConstructor func() Client
+	answRules         *answlog.Rules // Lazy set via AnswRules.
 
 	core.GunDeps
 }
@@ -228,20 +228,11 @@ func (b *BaseGun) Shoot(ammo Ammo) {
 	if b.DebugLog {
 		b.verboseLogging(res)
 	}
-	if b.Config.AnswLog.Enabled {
-		switch b.Config.AnswLog.Filter {
-		case "all":
+	if b.Config.AnswLog.Enabled && b.AnswRules().Match(res.StatusCode) {
+		if b.AnswRules().JSON() {
+			b.answJSONLogging(sample, req, bodyBytes, res)
+		} else {
 			b.answLogging(req, bodyBytes, res)
-
-		case "warning":
-			if res.StatusCode >= 400 {
-				b.answLogging(req, bodyBytes, res)
-			}
-
-		case "error":
-			if res.StatusCode >= 500 {
-				b.answLogging(req, bodyBytes, res)
-			}
 		}
 	}
 
@@ -256,10 +247,16 @@ func (b *BaseGun) Shoot(ammo Ammo) {
 }
 
 func (b *BaseGun) Close() error {
+	var err error
 	if b.OnClose != nil {
-		return b.OnClose()
+		err = b.OnClose()
 	}
-	return nil
+	if b.AnswLogCloser != nil {
+		if closeErr := b.AnswLogCloser.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
 }
 
 func (b *BaseGun) verboseLogging(res *http.Response) {
@@ -315,6 +312,45 @@ func (b *BaseGun) answLogging(req *http.Request, bodyBytes []byte, res *http.Res
 	b.AnswLog.Debug(msg)
 }
 
+func (b *BaseGun) answJSONLogging(sample *netsample.Sample, req *http.Request, bodyBytes []byte, res *http.Response) {
+	respBytes, err := io.ReadAll(res.Body)
+	if err != nil {
+		b.Log.Warn("Body read fail", zap.Error(err))
+	}
+	res.Body = io.NopCloser(bytes.NewReader(respBytes))
+	b.AnswRules().Write(b.AnswLog, AnswEntry(sample, req, bodyBytes, res, respBytes))
+}
+
+// AnswRules returns rules of answer log, created from config on first call.
+func (b *BaseGun) AnswRules() *answlog.Rules {
+	if b.answRules == nil {
+		b.answRules = answlog.NewRules(b.Config.AnswLog)
+	}
+	return b.answRules
+}
+
+// AnswEntry returns JSON answer log entry of request and response of sample.
+func AnswEntry(sample *netsample.Sample, req *http.Request, reqBody []byte, res *http.Response, respBody []byte) *answlog.Entry {
+	return &answlog.Entry{
+		Time:     sample.Timestamp(),
+		AmmoID:   sample.ID(),
+		Tag:      sample.Tags(),
+		Duration: time.Since(sample.Timestamp()),
+		Code:     res.StatusCode,
+		Request: answlog.Message{
+			Method:  req.Method,
+			URL:     req.URL.String(),
+			Headers: req.Header,
+			Body:    reqBody,
+		},
+		Response: answlog.Message{
+			Status:  res.Status,
+			Headers: res.Header,
+			Body:    respBody,
+		},
+	}
+}
+
 func autotag(depth int, URL *url.URL) string {
 	path := URL.Path
 	var ind int
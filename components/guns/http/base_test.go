@@ -1,6 +1,7 @@
 package phttp
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"io"
@@ -11,14 +12,18 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/klauspost/compress/gzip"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	ammomock "github.com/yandex/pandora/components/guns/http/mocks"
 	"github.com/yandex/pandora/core"
 	"github.com/yandex/pandora/core/aggregator/netsample"
 	"github.com/yandex/pandora/core/coretest"
+	"github.com/yandex/pandora/core/datasink"
 	"github.com/yandex/pandora/core/engine"
+	"github.com/yandex/pandora/lib/answlog"
 	"github.com/yandex/pandora/lib/monitoring"
 	"github.com/yandex/pandora/lib/testutil"
 	"go.uber.org/zap"
@@ -216,6 +221,25 @@ func (s *BaseGunSuite) Test_Shoot() {
 			s.Assert().ErrorIs(err, io.EOF, "body should be read fully")
 		})
 
+		s.Run("json answer log", func() {
+			s.SetupTest()
+			beforeEach()
+			beforeEachDoOk()
+			sink := datasink.NewBuffer()
+			answLog, closer, err := answlog.New(sink, answlog.FormatJSON)
+			s.Require().NoError(err)
+			s.base.AnswLog = answLog
+			s.base.Config.AnswLog = answlog.Config{Enabled: true, Filter: "warning", Format: answlog.FormatJSON}
+			justBeforeEach()
+
+			s.Require().NoError(closer.Close())
+			s.Assert().NoError(shootErr)
+			s.Assert().Contains(sink.String(), `"code":404`)
+			s.Assert().Contains(sink.String(), `"body":"aaaaaaa"`)
+			_, err = body.Read([]byte{0})
+			s.Assert().ErrorIs(err, io.EOF, "body should be read fully")
+		})
+
 		s.Run("autotag options is set", func() {
 			beforeEacAautotag := (func() { s.base.Config.AutoTag.Enabled = true })
 
@@ -334,6 +358,30 @@ func Test_Autotag(t *testing.T) {
 	}
 }
 
+func Test_CloseReleasesAnswLog(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	answLog, closer, err := answlog.Init(fs, answlog.Config{Enabled: true, Path: "answ.log.gz", Compression: datasink.CompressionGzip})
+	require.NoError(t, err)
+	conf := DefaultHTTPGunConfig()
+	conf.Target = "localhost:80"
+	base := NewHTTP1Gun(conf, answLog)
+	base.AnswLogCloser = closer
+	answLog.Debug("answer")
+
+	gun := WrapGun(base)
+	require.Implements(t, (*io.Closer)(nil), gun)
+	require.NoError(t, gun.(io.Closer).Close())
+
+	// Compressed stream is finished, so the whole file is read.
+	data, err := afero.ReadFile(fs, "answ.log.gz")
+	require.NoError(t, err)
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	got, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Contains(t, string(got), "answer")
+}
+
 func Test_ConfigDecode(t *testing.T) {
 	var conf GunConfig
 	coretest.DecodeAndValidateT(t, `
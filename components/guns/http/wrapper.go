@@ -1,6 +1,7 @@
 package phttp
 
 import (
+	"io"
 	"net/http"
 
 	"github.com/yandex/pandora/core"
@@ -48,3 +49,10 @@ func (g *gunWrapper) Bind(a core.Aggregator, deps core.GunDeps) error {
 func (g *gunWrapper) WarmUp(opts *warmup.Options) (any, error) {
 	return g.Gun.WarmUp(opts)
 }
+
+func (g *gunWrapper) Close() error {
+	if closer, ok := g.Gun.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
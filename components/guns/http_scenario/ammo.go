@@ -1,11 +1,14 @@
 package httpscenario
 
 import (
+	"fmt"
 	"io"
 	"net/http"
 	"time"
 
 	"github.com/yandex/pandora/components/providers/scenario"
+	"github.com/yandex/pandora/components/providers/scenario/flow"
+	"github.com/yandex/pandora/core/aggregator/netsample"
 )
 
 type SourceStorage interface {
@@ -18,6 +21,8 @@ type Scenario struct {
 	Name            string
 	MinWaitingTime  time.Duration
 	VariableStorage SourceStorage
+	FailurePolicy   *scenario.FailurePolicy
+	CookieJar       CookieJarScope
 }
 
 func (a *Scenario) SetID(id uint64) {
@@ -30,6 +35,8 @@ func (a *Scenario) Clone() scenario.ProvAmmo {
 		Name:            a.Name,
 		MinWaitingTime:  a.MinWaitingTime,
 		VariableStorage: a.VariableStorage,
+		FailurePolicy:   a.FailurePolicy,
+		CookieJar:       a.CookieJar,
 	}
 }
 
@@ -44,6 +51,15 @@ type Request struct {
 	Postprocessors []Postprocessor
 	Templater      Templater
 	Sleep          time.Duration
+	// OnError overrides scenario failure policy for the request, if it is set.
+	OnError scenario.OnError
+	Retry   scenario.Retry
+	// Session is processed after response postprocessors. Its result is stored to session variables
+	// of the instance, that are available in templates as .session.
+	Session Preprocessor
+	// Control is set for condition, loop and choice steps. Such steps make no request
+	// by themselves, but run nested steps.
+	Control *flow.Control[Request]
 }
 
 func (r *Request) GetBody() []byte {
@@ -72,6 +88,40 @@ type Postprocessor interface {
 	Process(resp *http.Response, body io.Reader) (map[string]any, error)
 }
 
+// TimedPostprocessor is a Postprocessor, that also needs response time of request.
+// Gun calls ProcessTimed instead of Process for it.
+type TimedPostprocessor interface {
+	Postprocessor
+	ProcessTimed(resp *http.Response, body io.Reader, responseTime time.Duration) (map[string]any, error)
+}
+
+// SamplePostprocessor is a Postprocessor, that also sets custom labels and metrics of request sample.
+// Gun calls ProcessSample instead of Process for it.
+type SamplePostprocessor interface {
+	Postprocessor
+	ProcessSample(sample *netsample.Sample, resp *http.Response, body io.Reader) (map[string]any, error)
+}
+
+// CookieJarScope defines, how long cookies received by scenario requests are kept.
+type CookieJarScope string
+
+const (
+	// CookieJarNone disables cookie jar. Default.
+	CookieJarNone CookieJarScope = ""
+	// CookieJarIteration keeps cookies during one scenario iteration.
+	CookieJarIteration CookieJarScope = "iteration"
+	// CookieJarInstance keeps cookies for all scenario iterations of the instance.
+	CookieJarInstance CookieJarScope = "instance"
+)
+
+func ParseCookieJarScope(s string) (CookieJarScope, error) {
+	switch scope := CookieJarScope(s); scope {
+	case CookieJarNone, CookieJarIteration, CookieJarInstance:
+		return scope, nil
+	}
+	return "", fmt.Errorf("invalid cookie_jar %q: should be one of %s, %s", s, CookieJarIteration, CookieJarInstance)
+}
+
 type RequestParts struct {
 	URL     string
 	Method  string
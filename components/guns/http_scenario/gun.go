@@ -2,18 +2,22 @@ package httpscenario
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"math/rand"
 	"net"
 	"net/http"
+	"net/http/cookiejar"
 	"net/http/httptrace"
 	"net/http/httputil"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
 	phttp "github.com/yandex/pandora/components/guns/http"
+	"github.com/yandex/pandora/components/providers/scenario"
 	"github.com/yandex/pandora/core"
 	"github.com/yandex/pandora/core/aggregator/netsample"
 	"github.com/yandex/pandora/core/warmup"
@@ -32,6 +36,10 @@ const (
 
 type ScenarioGun struct {
 	base *phttp.BaseGun
+	// jar is a cookie jar of the instance, it is shared by scenarios with instance cookie jar scope.
+	jar http.CookieJar
+	// session contains variables, that persist between scenario iterations of the instance.
+	session map[string]any
 }
 
 var _ Gun = (*ScenarioGun)(nil)
@@ -59,10 +67,23 @@ func (g *ScenarioGun) Shoot(ammo *Scenario) {
 	}
 
 	templateVars := map[string]any{
-		"source": ammo.VariableStorage.Variables(),
+		"source":   ammo.VariableStorage.Variables(),
+		"instance": map[string]any{"id": g.base.InstanceID},
+		"session":  g.sessionVars(),
 	}
 
 	err := g.shoot(ammo, templateVars)
+	if errors.Is(err, scenario.ErrShootingStopped) {
+		return
+	}
+	var abortErr *scenario.AbortError
+	if errors.As(err, &abortErr) {
+		g.base.Log.Error("Shooting aborted", zap.Uint64("request", ammo.ID), zap.Error(err))
+		if g.base.Abort != nil {
+			g.base.Abort(err)
+		}
+		return
+	}
 	if err != nil {
 		g.base.Log.Warn("Invalid ammo", zap.Uint64("request", ammo.ID), zap.Error(err))
 		return
@@ -74,10 +95,7 @@ func (g *ScenarioGun) Do(req *http.Request) (*http.Response, error) {
 }
 
 func (g *ScenarioGun) Close() error {
-	if g.base.OnClose != nil {
-		return g.base.OnClose()
-	}
-	return nil
+	return g.base.Close()
 }
 
 func (g *ScenarioGun) shoot(ammo *Scenario, templateVars map[string]any) error {
@@ -89,27 +107,133 @@ func (g *ScenarioGun) shoot(ammo *Scenario, templateVars map[string]any) error {
 	templateVars["request"] = requestVars
 
 	startAt := time.Now()
-	var idBuilder strings.Builder
-	rnd := strconv.Itoa(rand.Int())
-	for _, req := range ammo.Requests {
-		tag := ammo.Name + "." + req.Name
-		g.buildLogID(&idBuilder, tag, ammo.ID, rnd)
-		sample := netsample.Acquire(tag)
-
-		err := g.shootStep(req, sample, ammo.Name, templateVars, requestVars, idBuilder.String())
-		if err != nil {
-			g.reportErr(sample, err)
-			return err
-		}
+	st := &shootState{
+		ammo:         ammo,
+		templateVars: templateVars,
+		requestVars:  requestVars,
+		rnd:          strconv.Itoa(rand.Int()),
+		tx:           scenario.StartTransaction(g.base.Ctx, ammo.Name, http.StatusOK),
+		jar:          g.cookieJar(ammo.CookieJar),
+	}
+	err := g.shootSteps(st, ammo.Requests)
+	if errors.Is(err, scenario.ErrShootingStopped) {
+		return err
+	}
+	g.base.Aggregator.Report(st.tx.Finish())
+	if err != nil {
+		return err
 	}
 	spent := time.Since(startAt)
 	if ammo.MinWaitingTime > spent {
 		time.Sleep(ammo.MinWaitingTime - spent)
 	}
+	// Errors of steps with continue policy are reported by transaction sample only.
+	return nil
+}
+
+// shootState is a state of one scenario execution shared by all its steps.
+type shootState struct {
+	ammo         *Scenario
+	templateVars map[string]any
+	requestVars  map[string]any
+	idBuilder    strings.Builder
+	rnd          string
+	tx           *scenario.Transaction
+	jar          http.CookieJar
+}
+
+// cookieJar returns cookie jar for scenario iteration according to scope.
+func (g *ScenarioGun) cookieJar(scope CookieJarScope) http.CookieJar {
+	switch scope {
+	case CookieJarIteration:
+		jar, _ := cookiejar.New(nil) // never fails without options
+		return jar
+	case CookieJarInstance:
+		if g.jar == nil {
+			g.jar, _ = cookiejar.New(nil)
+		}
+		return g.jar
+	}
+	return nil
+}
+
+func (g *ScenarioGun) sessionVars() map[string]any {
+	if g.session == nil {
+		g.session = map[string]any{}
+	}
+	return g.session
+}
+
+func (g *ScenarioGun) shootSteps(st *shootState, steps []Request) error {
+	for _, req := range steps {
+		if req.Control != nil {
+			var stepsFailed bool
+			err := req.Control.Exec(st.templateVars, func(steps []Request) error {
+				err := g.shootSteps(st, steps)
+				stepsFailed = err != nil
+				return err
+			})
+			if err != nil {
+				st.tx.Fail(req.Name, err)
+				if !stepsFailed {
+					// Error of control itself, e.g. loop has reached max iterations. Errors of nested steps are already handled.
+					err = st.ammo.FailurePolicy.Handle(st.ammo.Name, req.OnError, err)
+				}
+				if err != nil {
+					return err
+				}
+			}
+			if err := st.tx.Sleep(req.Sleep); err != nil {
+				return err
+			}
+			continue
+		}
+		err := g.shootWithRetry(st, req)
+		if errors.Is(err, scenario.ErrShootingStopped) {
+			return err
+		}
+		if err != nil {
+			st.tx.Fail(req.Name, err)
+			err = st.ammo.FailurePolicy.Handle(st.ammo.Name, req.OnError, err)
+			if err != nil {
+				return err
+			}
+		}
+		if err := st.tx.Sleep(req.Sleep); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func (g *ScenarioGun) shootStep(step Request, sample *netsample.Sample, ammoName string, templateVars map[string]any, requestVars map[string]any, stepLogID string) error {
+// shootWithRetry shoots request and retries it on failure, if it is configured.
+// Every attempt is reported as a separate sample, retry attempts have additional retry tag.
+// Retry delays are not included into transaction duration.
+func (g *ScenarioGun) shootWithRetry(st *shootState, req Request) error {
+	tag := st.ammo.Name + "." + req.Name
+	var err error
+	for attempt := 0; attempt <= req.Retry.Attempts; attempt++ {
+		if attempt > 0 {
+			if sleepErr := st.tx.Sleep(req.Retry.Delay(attempt)); sleepErr != nil {
+				return sleepErr
+			}
+		}
+		g.buildLogID(&st.idBuilder, tag, st.ammo.ID, st.rnd)
+		sample := netsample.Acquire(tag)
+		if attempt > 0 {
+			sample.AddTag(scenario.RetryTag(attempt))
+		}
+
+		err = g.shootStep(req, sample, st.ammo.Name, st.templateVars, st.requestVars, st.jar, st.idBuilder.String())
+		if err == nil {
+			return nil
+		}
+		g.reportErr(sample, err)
+	}
+	return err
+}
+
+func (g *ScenarioGun) shootStep(step Request, sample *netsample.Sample, ammoName string, templateVars map[string]any, requestVars map[string]any, jar http.CookieJar, stepLogID string) error {
 	const op = "base_gun.shootStep"
 
 	stepVars := map[string]any{}
@@ -145,9 +269,16 @@ func (g *ScenarioGun) shootStep(step Request, sample *netsample.Sample, ammoName
 	if err != nil {
 		return fmt.Errorf("%s prepareRequest %w", op, err)
 	}
+	if jar != nil {
+		for _, cookie := range jar.Cookies(cookieURL(req)) {
+			req.AddCookie(cookie)
+		}
+	}
 
 	var reqBytes []byte
-	if g.base.Config.AnswLog.Enabled {
+	if g.base.Config.AnswLog.Enabled && g.base.AnswRules().JSON() {
+		reqBytes = reqParts.Body
+	} else if g.base.Config.AnswLog.Enabled {
 		var dumpErr error
 		reqBytes, dumpErr = httputil.DumpRequestOut(req, true)
 		if dumpErr != nil {
@@ -157,6 +288,7 @@ func (g *ScenarioGun) shootStep(step Request, sample *netsample.Sample, ammoName
 
 	timings, req := g.initTracing(req, sample)
 
+	requestStart := time.Now()
 	resp, err := g.base.Client.Do(req)
 
 	g.saveTrace(timings, sample, resp)
@@ -164,6 +296,9 @@ func (g *ScenarioGun) shootStep(step Request, sample *netsample.Sample, ammoName
 	if err != nil {
 		return fmt.Errorf("%s g.Do %w", op, err)
 	}
+	if jar != nil {
+		jar.SetCookies(cookieURL(req), resp.Cookies())
+	}
 
 	// Log
 	processors := step.Postprocessors
@@ -180,6 +315,7 @@ func (g *ScenarioGun) shootStep(step Request, sample *netsample.Sample, ammoName
 	if err != nil {
 		return fmt.Errorf("%s io.Copy %w", op, err)
 	}
+	responseTime := time.Since(requestStart)
 	defer func() {
 		closeErr := resp.Body.Close()
 		if closeErr != nil {
@@ -190,16 +326,34 @@ func (g *ScenarioGun) shootStep(step Request, sample *netsample.Sample, ammoName
 	if g.base.DebugLog {
 		g.verboseLogging(resp, reqBytes, respBodyBytes)
 	}
-	if g.base.Config.AnswLog.Enabled {
-		g.answReqRespLogging(reqBytes, resp, respBodyBytes, stepLogID)
+	if g.base.Config.AnswLog.Enabled && g.base.AnswRules().Match(resp.StatusCode) {
+		if g.base.AnswRules().JSON() {
+			entry := phttp.AnswEntry(sample, req, reqBytes, resp, respBodyBytes)
+			entry.Step = step.Name
+			g.base.AnswRules().Write(g.base.AnswLog, entry)
+		} else {
+			g.answLogging(reqBytes, resp, respBodyBytes, stepLogID)
+		}
 	}
 
 	// Postprocessor
 	postprocessorVars := map[string]any{}
 	var vars map[string]any
 	for _, postprocessor := range processors {
-		vars, err = postprocessor.Process(resp, respBody)
+		if sp, ok := postprocessor.(SamplePostprocessor); ok {
+			vars, err = sp.ProcessSample(sample, resp, respBody)
+		} else if timed, ok := postprocessor.(TimedPostprocessor); ok {
+			vars, err = timed.ProcessTimed(resp, respBody, responseTime)
+		} else {
+			vars, err = postprocessor.Process(resp, respBody)
+		}
 		if err != nil {
+			if scenario.IsAssertError(err) {
+				// Response is received, so it is reported with its code, but marked as failed assertion.
+				sample.AddTag(scenario.AssertFailedTag)
+				sample.SetProtoCode(resp.StatusCode)
+				g.base.Aggregator.Report(sample)
+			}
 			return fmt.Errorf("%s postprocessor.Postprocess %w", op, err)
 		}
 		for k, v := range vars {
@@ -211,6 +365,22 @@ func (g *ScenarioGun) shootStep(step Request, sample *netsample.Sample, ammoName
 		}
 	}
 	stepVars["postprocessor"] = postprocessorVars
+	stepVars["status"] = resp.StatusCode
+
+	// Session
+	if step.Session != nil {
+		sessionVars, err := step.Session.Process(templateVars)
+		if err != nil {
+			return fmt.Errorf("%s session %w", op, err)
+		}
+		session := g.sessionVars()
+		for k, v := range sessionVars {
+			session[k] = v
+		}
+		if g.base.DebugLog {
+			g.base.GunDeps.Log.Debug("Session variables", zap.Any(".session", session))
+		}
+	}
 
 	sample.SetProtoCode(resp.StatusCode)
 	g.base.Aggregator.Report(sample)
@@ -219,9 +389,6 @@ func (g *ScenarioGun) shootStep(step Request, sample *netsample.Sample, ammoName
 		g.base.GunDeps.Log.Debug("Postprocessor variables", zap.Any(fmt.Sprintf(".request.%s.postprocessor", step.Name), postprocessorVars))
 	}
 
-	if step.Sleep > 0 {
-		time.Sleep(step.Sleep)
-	}
 	return nil
 }
 
@@ -341,23 +508,9 @@ func (g *ScenarioGun) answLogging(bodyBytes []byte, resp *http.Response, respByt
 	g.base.AnswLog.Debug(msg)
 }
 
-func (g *ScenarioGun) answReqRespLogging(reqBytes []byte, resp *http.Response, respBytes []byte, stepName string) {
-	switch g.base.Config.AnswLog.Filter {
-	case "all":
-		g.answLogging(reqBytes, resp, respBytes, stepName)
-	case "warning":
-		if resp.StatusCode >= 400 {
-			g.answLogging(reqBytes, resp, respBytes, stepName)
-		}
-	case "error":
-		if resp.StatusCode >= 500 {
-			g.answLogging(reqBytes, resp, respBytes, stepName)
-		}
-	}
-}
-
 func (g *ScenarioGun) reportErr(sample *netsample.Sample, err error) {
-	if err == nil {
+	if err == nil || scenario.IsAssertError(err) {
+		// Sample of failed assertion is already reported by shootStep.
 		return
 	}
 	sample.AddTag(EmptyTag)
@@ -366,6 +519,11 @@ func (g *ScenarioGun) reportErr(sample *netsample.Sample, err error) {
 	g.base.Aggregator.Report(sample)
 }
 
+// cookieURL returns request URL with original host, because request URL contains resolved target address.
+func cookieURL(req *http.Request) *url.URL {
+	return &url.URL{Scheme: req.URL.Scheme, Host: req.Host, Path: req.URL.Path}
+}
+
 func getHostWithoutPort(target string) string {
 	host, _, err := net.SplitHostPort(target)
 	if err != nil {
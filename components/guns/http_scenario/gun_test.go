@@ -2,16 +2,21 @@ package httpscenario
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	phttp "github.com/yandex/pandora/components/guns/http"
+	"github.com/yandex/pandora/components/providers/scenario"
+	"github.com/yandex/pandora/components/providers/scenario/flow"
+	"github.com/yandex/pandora/components/providers/scenario/http/preprocessor"
 	"github.com/yandex/pandora/core"
 	"github.com/yandex/pandora/core/aggregator/netsample"
 	"go.uber.org/zap"
@@ -46,8 +51,8 @@ func TestBaseGun_shoot(t *testing.T) {
 			templateVars: map[string]any{"source": map[string]any{"users": []map[string]any{{"id": 1, "name": "test1"}, {"id": 2, "name": "test2"}}}},
 			wantTempateVars: map[string]any{
 				"request": map[string]any{
-					"step 1": map[string]any{"postprocessor": map[string]any{}},
-					"step 2": map[string]any{"postprocessor": map[string]any{}},
+					"step 1": map[string]any{"postprocessor": map[string]any{}, "status": 0},
+					"step 2": map[string]any{"postprocessor": map[string]any{}, "status": 0},
 				},
 				"source": map[string]any{"users": []map[string]any{{"id": 1, "name": "test1"}, {"id": 2, "name": "test2"}}},
 			},
@@ -91,6 +96,7 @@ func TestBaseGun_shoot(t *testing.T) {
 							"preprocessor_var": "preprocessor_test",
 						},
 						"postprocessor": map[string]any{},
+						"status":        0,
 					},
 				},
 				"source": map[string]any{"users": []map[string]any{{"id": 1, "name": "test1"}, {"id": 2, "name": "test2"}}},
@@ -133,6 +139,7 @@ func TestBaseGun_shoot(t *testing.T) {
 							"token":         "body_token",
 							"Conteant-Type": "application/json",
 						},
+						"status": 0,
 					},
 				},
 				"source": map[string]any{"users": []map[string]any{{"id": 1, "name": "test1"}, {"id": 2, "name": "test2"}}},
@@ -309,3 +316,302 @@ func (m *MockTemplater) Apply(request *RequestParts, variables map[string]any, s
 func (m *MockTemplater) validateCalls(t *testing.T, stepName string) {
 	assert.Equalf(t, 0, m.applyCalls, "wrong template.applyCalls calls with step name `%s`", stepName)
 }
+
+type readyPostprocessor struct {
+	calls int
+	ready int
+}
+
+func (p *readyPostprocessor) Process(_ *http.Response, _ io.Reader) (map[string]any, error) {
+	p.calls++
+	return map[string]any{"ready": p.calls >= p.ready}, nil
+}
+
+type slowResponsePostprocessor struct {
+	limit time.Duration
+}
+
+func (p *slowResponsePostprocessor) Process(resp *http.Response, body io.Reader) (map[string]any, error) {
+	return p.ProcessTimed(resp, body, 0)
+}
+
+func (p *slowResponsePostprocessor) ProcessTimed(_ *http.Response, _ io.Reader, responseTime time.Duration) (map[string]any, error) {
+	if responseTime > p.limit {
+		return nil, &scenario.AssertError{Err: errors.New("slow response")}
+	}
+	return nil, nil
+}
+
+type labelPostprocessor struct{}
+
+func (p *labelPostprocessor) Process(_ *http.Response, _ io.Reader) (map[string]any, error) {
+	return nil, errors.New("ProcessSample should be called")
+}
+
+func (p *labelPostprocessor) ProcessSample(sample *netsample.Sample, resp *http.Response, _ io.Reader) (map[string]any, error) {
+	sample.SetLabel("cache", resp.Header.Get("X-Cache"))
+	sample.SetMetric("items", 3)
+	return map[string]any{"labeled": true}, nil
+}
+
+func TestScenarioGun_samplePostprocessor(t *testing.T) {
+	client := NewMockClient(t)
+	client.On("Do", mock.Anything).Return(&http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"X-Cache": {"hit"}},
+		Body:       io.NopCloser(strings.NewReader("")),
+	}, nil)
+	var samples []*netsample.Sample
+	aggregator := netsample.NewMockAggregator(t)
+	aggregator.On("Report", mock.Anything).Run(func(args mock.Arguments) {
+		samples = append(samples, args.Get(0).(*netsample.Sample))
+	})
+	ammo := &Scenario{
+		Name: "sc",
+		Requests: []Request{{
+			Name: "get", Method: "GET", URI: "/get",
+			Templater:      &MockTemplater{expectedArgs: [][2]string{{"sc", "get"}}},
+			Postprocessors: []Postprocessor{&labelPostprocessor{}},
+		}},
+	}
+
+	g := &ScenarioGun{base: &phttp.BaseGun{Aggregator: aggregator, Client: client}}
+	require.NoError(t, g.shoot(ammo, map[string]any{}))
+	require.Len(t, samples, 2) // step and transaction
+	assert.Equal(t, []netsample.Label{{Key: "cache", Value: "hit"}}, samples[0].Labels())
+	assert.Equal(t, []netsample.Metric{{Name: "items", Value: 3}}, samples[0].Metrics())
+}
+
+func TestScenarioGun_shootControl(t *testing.T) {
+	newRequest := func(name, method, uri string) Request {
+		return Request{Name: name, Method: method, URI: uri, Templater: &MockTemplater{expectedArgs: [][2]string{{"flow", name}}}}
+	}
+	mustCondition := func(expr string) *flow.Condition {
+		c, err := flow.NewCondition(expr, nil)
+		require.NoError(t, err)
+		return c
+	}
+
+	status := newRequest("status", "GET", "/status")
+	status.Postprocessors = []Postprocessor{&readyPostprocessor{ready: 3}}
+	choice, err := flow.NewChoice("choice", []flow.Option[Request]{
+		{Weight: 1, Steps: []Request{newRequest("list", "GET", "/list")}},
+	})
+	require.NoError(t, err)
+
+	ammo := &Scenario{
+		Name: "flow",
+		Requests: []Request{
+			newRequest("get", "GET", "/item"),
+			{Name: "save", Control: flow.NewIf("save", mustCondition("eq .request.get.status 404"),
+				[]Request{newRequest("create", "POST", "/item")},
+				[]Request{newRequest("update", "PUT", "/item")})},
+			{Name: "wait", Control: flow.NewLoop("wait", []Request{status}, nil, mustCondition(".request.status.postprocessor.ready"), 10)},
+			{Name: "choice", Control: choice},
+		},
+	}
+
+	var calls []string
+	client := NewMockClient(t)
+	client.On("Do", mock.Anything).Return(func(req *http.Request) (*http.Response, error) {
+		calls = append(calls, req.Method+" "+req.URL.Path)
+		code := http.StatusOK
+		if req.Method == "GET" && req.URL.Path == "/item" {
+			code = http.StatusNotFound
+		}
+		return &http.Response{StatusCode: code, Body: io.NopCloser(strings.NewReader(""))}, nil
+	})
+	aggregator := netsample.NewMockAggregator(t)
+	aggregator.On("Report", mock.Anything)
+
+	g := &ScenarioGun{base: &phttp.BaseGun{Aggregator: aggregator, Client: client}}
+	err = g.shoot(ammo, map[string]any{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GET /item", "POST /item", "GET /status", "GET /status", "GET /status", "GET /list"}, calls)
+	aggregator.AssertNumberOfCalls(t, "Report", len(calls)+1) // steps and transaction
+}
+
+func TestScenarioGun_shootOnError(t *testing.T) {
+	newRequest := func(name string) Request {
+		return Request{Name: name, Method: "GET", URI: "/" + name, Templater: &MockTemplater{expectedArgs: [][2]string{{"sc", name}}}}
+	}
+	// newGun returns gun, tags of reported samples and error of the last one, that is transaction sample.
+	newGun := func(t *testing.T, failures map[string]int) (*ScenarioGun, *[]string, *error) {
+		var (
+			tags    []string
+			lastErr error
+		)
+		client := NewMockClient(t)
+		client.On("Do", mock.Anything).Return(func(req *http.Request) (*http.Response, error) {
+			if failures[req.URL.Path] > 0 {
+				failures[req.URL.Path]--
+				return nil, errors.New("connection reset")
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}, nil
+		})
+		aggregator := netsample.NewMockAggregator(t)
+		aggregator.On("Report", mock.Anything).Run(func(args mock.Arguments) {
+			tags = append(tags, args.Get(0).(*netsample.Sample).Tags())
+			lastErr = args.Get(0).(*netsample.Sample).Err()
+		})
+		return &ScenarioGun{base: &phttp.BaseGun{Aggregator: aggregator, Client: client}}, &tags, &lastErr
+	}
+
+	t.Run("retry and continue", func(t *testing.T) {
+		retried := newRequest("retried")
+		retried.Retry = scenario.Retry{Attempts: 2, Backoff: time.Millisecond}
+		skipped := newRequest("skipped")
+		skipped.OnError = scenario.OnErrorContinue
+		ammo := &Scenario{
+			Name:          "sc",
+			Requests:      []Request{retried, skipped, newRequest("last")},
+			FailurePolicy: scenario.NewFailurePolicy(scenario.OnErrorStop, 0),
+		}
+		g, tags, txErr := newGun(t, map[string]int{"/retried": 2, "/skipped": 1})
+
+		err := g.shoot(ammo, nil)
+		require.NoError(t, err)
+		require.Error(t, *txErr)
+		assert.Equal(t, []string{
+			"sc.retried|__EMPTY__",
+			"sc.retried|retry_1|__EMPTY__",
+			"sc.retried|retry_2",
+			"sc.skipped|__EMPTY__",
+			"sc.last",
+			"sc|skipped",
+		}, *tags)
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		ammo := &Scenario{
+			Name:          "sc",
+			Requests:      []Request{newRequest("first"), newRequest("last")},
+			FailurePolicy: scenario.NewFailurePolicy(scenario.OnErrorStop, 0),
+		}
+		g, tags, _ := newGun(t, map[string]int{"/first": 1})
+
+		err := g.shoot(ammo, nil)
+		require.Error(t, err)
+		assert.Equal(t, []string{"sc.first|__EMPTY__", "sc|first"}, *tags)
+	})
+
+	t.Run("assert failed", func(t *testing.T) {
+		asserted := newRequest("asserted")
+		asserted.OnError = scenario.OnErrorContinue
+		asserted.Postprocessors = []Postprocessor{&slowResponsePostprocessor{limit: -1}}
+		ammo := &Scenario{
+			Name:          "sc",
+			Requests:      []Request{asserted, newRequest("last")},
+			FailurePolicy: scenario.NewFailurePolicy(scenario.OnErrorStop, 0),
+		}
+		g, tags, txErr := newGun(t, nil)
+
+		err := g.shoot(ammo, nil)
+		require.NoError(t, err)
+		require.True(t, scenario.IsAssertError(*txErr))
+		assert.Equal(t, []string{"sc.asserted|assert_failed", "sc.last", "sc|asserted"}, *tags)
+	})
+
+	t.Run("loop max iterations", func(t *testing.T) {
+		until, err := flow.NewCondition("false", nil)
+		require.NoError(t, err)
+		ammo := &Scenario{
+			Name: "sc",
+			Requests: []Request{
+				{Name: "poll", Control: flow.NewLoop("poll", []Request{newRequest("status")}, nil, until, 2)},
+				newRequest("last"),
+			},
+			FailurePolicy: scenario.NewFailurePolicy(scenario.OnErrorContinue, 0),
+		}
+		g, tags, txErr := newGun(t, nil)
+
+		err = g.shoot(ammo, nil)
+		require.NoError(t, err)
+		require.ErrorIs(t, *txErr, flow.ErrMaxIterations)
+		assert.Equal(t, []string{"sc.status", "sc.status", "sc.last", "sc|poll"}, *tags)
+	})
+
+	t.Run("abort after", func(t *testing.T) {
+		ammo := &Scenario{
+			Name:          "sc",
+			Requests:      []Request{newRequest("first")},
+			FailurePolicy: scenario.NewFailurePolicy(scenario.OnErrorAbort, 2),
+		}
+		g, _, _ := newGun(t, map[string]int{"/first": 2})
+
+		err := g.shoot(ammo, nil)
+		var abortErr *scenario.AbortError
+		require.Error(t, err)
+		require.False(t, errors.As(err, &abortErr))
+
+		err = g.shoot(ammo, nil)
+		require.ErrorAs(t, err, &abortErr)
+	})
+
+	t.Run("abort shooting", func(t *testing.T) {
+		ammo := &Scenario{
+			Name:            "sc",
+			Requests:        []Request{newRequest("first")},
+			VariableStorage: emptyStorage{},
+			FailurePolicy:   scenario.NewFailurePolicy(scenario.OnErrorAbort, 0),
+		}
+		g, _, _ := newGun(t, map[string]int{"/first": 1})
+		var aborted error
+		g.base.GunDeps = core.GunDeps{Log: zap.NewNop(), Abort: func(err error) { aborted = err }}
+
+		g.Shoot(ammo)
+		var abortErr *scenario.AbortError
+		require.ErrorAs(t, aborted, &abortErr)
+	})
+}
+
+type emptyStorage struct{}
+
+func (emptyStorage) Variables() map[string]any {
+	return map[string]any{}
+}
+
+func TestScenarioGun_shootSession(t *testing.T) {
+	newRequest := func(name string) Request {
+		return Request{Name: name, Method: "GET", URI: "/" + name, Templater: &MockTemplater{expectedArgs: [][2]string{{"sc", name}}}}
+	}
+	login := newRequest("login")
+	login.Postprocessors = []Postprocessor{&readyPostprocessor{ready: 1}}
+	login.Session = &preprocessor.Preprocessor{Mapping: map[string]string{"logged_in": "request.login.postprocessor.ready"}}
+
+	tests := []struct {
+		name        string
+		scope       CookieJarScope
+		wantCookies []string
+	}{
+		{name: "no jar", scope: CookieJarNone, wantCookies: []string{"", "", "", ""}},
+		{name: "iteration", scope: CookieJarIteration, wantCookies: []string{"", "sid=1", "", "sid=2"}},
+		{name: "instance", scope: CookieJarInstance, wantCookies: []string{"", "sid=1", "sid=1", "sid=2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cookies []string
+			sid := 0
+			client := NewMockClient(t)
+			client.On("Do", mock.Anything).Return(func(req *http.Request) (*http.Response, error) {
+				cookies = append(cookies, req.Header.Get("Cookie"))
+				header := http.Header{}
+				if req.URL.Path == "/login" {
+					sid++
+					header.Set("Set-Cookie", fmt.Sprintf("sid=%d; Path=/", sid))
+				}
+				return &http.Response{StatusCode: http.StatusOK, Header: header, Body: io.NopCloser(strings.NewReader(""))}, nil
+			})
+			aggregator := netsample.NewMockAggregator(t)
+			aggregator.On("Report", mock.Anything)
+			g := &ScenarioGun{base: &phttp.BaseGun{Aggregator: aggregator, Client: client, Config: phttp.GunConfig{Target: "example.com:80"}}}
+
+			ammo := &Scenario{Name: "sc", Requests: []Request{login, newRequest("profile")}, CookieJar: tt.scope}
+			for i := 0; i < 2; i++ {
+				require.NoError(t, g.shoot(ammo, nil))
+			}
+			assert.Equal(t, tt.wantCookies, cookies)
+			assert.Equal(t, map[string]any{"logged_in": true}, g.session)
+		})
+	}
+}
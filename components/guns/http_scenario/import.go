@@ -1,6 +1,8 @@
 package httpscenario
 
 import (
+	"io"
+
 	"github.com/spf13/afero"
 	phttp "github.com/yandex/pandora/components/guns/http"
 	"github.com/yandex/pandora/core"
@@ -28,24 +30,43 @@ func (g *gunWrapper) Bind(a core.Aggregator, deps core.GunDeps) error {
 	return g.Gun.Bind(netsample.UnwrapAggregator(a), deps)
 }
 
+func (g *gunWrapper) Close() error {
+	if closer, ok := g.Gun.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
 func Import(fs afero.Fs) {
-	register.Gun("http/scenario", func(conf phttp.GunConfig) func() core.Gun {
+	register.Gun("http/scenario", func(conf phttp.GunConfig) (func() (core.Gun, error), error) {
 		targetResolved, _ := phttp.PreResolveTargetAddr(&conf.Client, conf.Target)
 		conf.TargetResolved = targetResolved
-		answLog := answlog.Init(conf.AnswLog.Path, conf.AnswLog.Enabled)
-		return func() core.Gun {
+		return func() (core.Gun, error) {
+			answLog, answLogCloser, err := answlog.Init(fs, conf.AnswLog)
+			if err != nil {
+				return nil, err
+			}
 			gun := NewHTTPGun(conf, answLog)
-			return WrapGun(gun)
-		}
+			gun.base.AnswLogCloser = answLogCloser
+			return WrapGun(gun), nil
+		}, nil
 	}, phttp.DefaultHTTPGunConfig)
 
-	register.Gun("http2/scenario", func(conf phttp.GunConfig) func() (core.Gun, error) {
+	register.Gun("http2/scenario", func(conf phttp.GunConfig) (func() (core.Gun, error), error) {
 		targetResolved, _ := phttp.PreResolveTargetAddr(&conf.Client, conf.Target)
 		conf.TargetResolved = targetResolved
-		answLog := answlog.Init(conf.AnswLog.Path, conf.AnswLog.Enabled)
 		return func() (core.Gun, error) {
+			answLog, answLogCloser, err := answlog.Init(fs, conf.AnswLog)
+			if err != nil {
+				return nil, err
+			}
 			gun, err := NewHTTP2Gun(conf, answLog)
-			return WrapGun(gun), err
-		}
+			if err != nil {
+				_ = answLogCloser.Close()
+				return nil, err
+			}
+			gun.base.AnswLogCloser = answLogCloser
+			return WrapGun(gun), nil
+		}, nil
 	}, phttp.DefaultHTTP2GunConfig)
 }
@@ -16,29 +16,49 @@ func Import(fs afero.Fs) {
 	scenarioGun.Import(fs)
 	scenarioProvider.Import(fs)
 
-	register.Gun("http", func(conf phttp.GunConfig) func() core.Gun {
+	register.Gun("http", func(conf phttp.GunConfig) (func() (core.Gun, error), error) {
 		targetResolved, _ := phttp.PreResolveTargetAddr(&conf.Client, conf.Target)
 		conf.TargetResolved = targetResolved
-		answLog := answlog.Init(conf.AnswLog.Path, conf.AnswLog.Enabled)
-		return func() core.Gun { return phttp.WrapGun(phttp.NewHTTP1Gun(conf, answLog)) }
+		return func() (core.Gun, error) {
+			answLog, answLogCloser, err := answlog.Init(fs, conf.AnswLog)
+			if err != nil {
+				return nil, err
+			}
+			gun := phttp.NewHTTP1Gun(conf, answLog)
+			gun.AnswLogCloser = answLogCloser
+			return phttp.WrapGun(gun), nil
+		}, nil
 	}, phttp.DefaultHTTPGunConfig)
 
-	register.Gun("http2", func(conf phttp.GunConfig) func() (core.Gun, error) {
+	register.Gun("http2", func(conf phttp.GunConfig) (func() (core.Gun, error), error) {
 		targetResolved, _ := phttp.PreResolveTargetAddr(&conf.Client, conf.Target)
 		conf.TargetResolved = targetResolved
-		answLog := answlog.Init(conf.AnswLog.Path, conf.AnswLog.Enabled)
 		return func() (core.Gun, error) {
+			answLog, answLogCloser, err := answlog.Init(fs, conf.AnswLog)
+			if err != nil {
+				return nil, err
+			}
 			gun, err := phttp.NewHTTP2Gun(conf, answLog)
-			return phttp.WrapGun(gun), err
-		}
+			if err != nil {
+				_ = answLogCloser.Close()
+				return nil, err
+			}
+			gun.AnswLogCloser = answLogCloser
+			return phttp.WrapGun(gun), nil
+		}, nil
 	}, phttp.DefaultHTTP2GunConfig)
 
-	register.Gun("connect", func(conf phttp.GunConfig) func() core.Gun {
+	register.Gun("connect", func(conf phttp.GunConfig) (func() (core.Gun, error), error) {
 		conf.Target, _ = phttp.PreResolveTargetAddr(&conf.Client, conf.Target)
 		conf.TargetResolved = conf.Target
-		answLog := answlog.Init(conf.AnswLog.Path, conf.AnswLog.Enabled)
-		return func() core.Gun {
-			return phttp.WrapGun(phttp.NewConnectGun(conf, answLog))
-		}
+		return func() (core.Gun, error) {
+			answLog, answLogCloser, err := answlog.Init(fs, conf.AnswLog)
+			if err != nil {
+				return nil, err
+			}
+			gun := phttp.NewConnectGun(conf, answLog)
+			gun.AnswLogCloser = answLogCloser
+			return phttp.WrapGun(gun), nil
+		}, nil
 	}, phttp.DefaultConnectGunConfig)
 }
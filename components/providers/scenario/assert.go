@@ -0,0 +1,363 @@
+package scenario
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PaesslerAG/jsonpath"
+	multierr "github.com/hashicorp/go-multierror"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/yandex/pandora/lib/numbers"
+)
+
+// AssertFailedTag is an additional sample tag of step, which response has failed assertion.
+const AssertFailedTag = "assert_failed"
+
+// AssertError is returned by assertion postprocessors, when response does not satisfy assertion.
+// Scenario guns report sample of such step with response code and AssertFailedTag instead of
+// error sample, and then handle it as step failure.
+type AssertError struct {
+	Err error
+}
+
+func (e *AssertError) Error() string {
+	return "assert failed: " + e.Err.Error()
+}
+
+func (e *AssertError) Unwrap() error {
+	return e.Err
+}
+
+// IsAssertError returns true, if err is caused by failed assertion.
+func IsAssertError(err error) bool {
+	var assertErr *AssertError
+	return errors.As(err, &assertErr)
+}
+
+// AssertConfig is a config of response assertion shared by HTTP and gRPC scenarios.
+type AssertConfig struct {
+	// Status is a list of expected status codes: exact "200", class "2xx" or range "200-299".
+	// Code should match any of them. Code prefixed with "!" should not match.
+	Status []string
+	// ResponseTime is a maximum response time. Zero means no limit.
+	ResponseTime time.Duration `config:"response_time" validate:"min-time=0s"`
+	// Schema is a JSON Schema, that response body should be valid against.
+	Schema   string
+	JSONPath []JSONPathAssert `config:"jsonpath"`
+}
+
+// JSONPathAssert compares value got by jsonpath from response body with Value.
+type JSONPathAssert struct {
+	Path string `validate:"required"`
+	// Op is exists, eq (default), regexp, contains, lt, le, gt or ge.
+	// Value is compared as number, if both values are JSON numbers, e.g. "007" is not a number.
+	Op    string
+	Value any
+	// Not negates assertion.
+	Not bool
+}
+
+// AssertResponse is a response checked by Assertion.
+type AssertResponse struct {
+	Code         int
+	ResponseTime time.Duration
+	// Body is read only if the assertion needs it.
+	Body func() ([]byte, error)
+}
+
+// Assertion checks response according to AssertConfig.
+type Assertion struct {
+	status       []statusRange
+	responseTime time.Duration
+	schema       *jsonschema.Schema
+	jsonpath     []jsonpathCheck
+}
+
+type statusRange struct {
+	numbers.CodeRange
+	not bool
+}
+
+type jsonpathCheck struct {
+	path   string
+	op     string
+	not    bool
+	value  string
+	number *float64
+	re     *regexp.Regexp
+}
+
+const (
+	assertOpExists   = "exists"
+	assertOpEq       = "eq"
+	assertOpRegexp   = "regexp"
+	assertOpContains = "contains"
+	assertOpLt       = "lt"
+	assertOpLe       = "le"
+	assertOpGt       = "gt"
+	assertOpGe       = "ge"
+)
+
+var assertOpAliases = map[string]string{
+	"":   assertOpEq,
+	"=":  assertOpEq,
+	"==": assertOpEq,
+	"~":  assertOpRegexp,
+	"<":  assertOpLt,
+	"<=": assertOpLe,
+	">":  assertOpGt,
+	">=": assertOpGe,
+}
+
+func NewAssertion(cfg AssertConfig) (*Assertion, error) {
+	a := &Assertion{responseTime: cfg.ResponseTime}
+	for _, s := range cfg.Status {
+		r, err := parseStatusRange(s)
+		if err != nil {
+			return nil, err
+		}
+		a.status = append(a.status, r)
+	}
+	if cfg.Schema != "" {
+		schema, err := jsonschema.CompileString("schema.json", cfg.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("invalid json schema: %w", err)
+		}
+		a.schema = schema
+	}
+	for _, jp := range cfg.JSONPath {
+		c, err := newJSONPathCheck(jp)
+		if err != nil {
+			return nil, err
+		}
+		a.jsonpath = append(a.jsonpath, c)
+	}
+	return a, nil
+}
+
+// parseStatusRange parses code range with optional negation like !5xx.
+func parseStatusRange(s string) (statusRange, error) {
+	var r statusRange
+	str := strings.TrimSpace(s)
+	if strings.HasPrefix(str, "!") {
+		r.not = true
+		str = str[1:]
+	}
+	var err error
+	r.CodeRange, err = numbers.ParseCodeRange(str)
+	if err != nil {
+		return r, fmt.Errorf("invalid status %q: should be code, class like 2xx or range like 200-299", s)
+	}
+	return r, nil
+}
+
+func newJSONPathCheck(cfg JSONPathAssert) (jsonpathCheck, error) {
+	c := jsonpathCheck{path: cfg.Path, op: cfg.Op, not: cfg.Not}
+	if op, ok := assertOpAliases[c.op]; ok {
+		c.op = op
+	}
+	if cfg.Value != nil {
+		c.value = formatAssertValue(cfg.Value)
+	}
+	switch c.op {
+	case assertOpExists, assertOpContains:
+	case assertOpEq:
+		if isJSONNumber(c.value) {
+			c.number = parseAssertNumber(c.value)
+		}
+	case assertOpRegexp:
+		re, err := regexp.Compile(c.value)
+		if err != nil {
+			return c, fmt.Errorf("invalid regexp for jsonpath %s: %w", c.path, err)
+		}
+		c.re = re
+	case assertOpLt, assertOpLe, assertOpGt, assertOpGe:
+		c.number = parseAssertNumber(c.value)
+		if c.number == nil {
+			return c, fmt.Errorf("value %q for jsonpath %s %s should be a number", c.value, c.path, c.op)
+		}
+	default:
+		return c, fmt.Errorf("unknown op %q for jsonpath %s", cfg.Op, c.path)
+	}
+	return c, nil
+}
+
+// Check returns *AssertError, if response does not satisfy assertion.
+// Other errors are returned, if response body can't be read.
+func (a *Assertion) Check(resp AssertResponse) error {
+	var failed error
+	if !a.checkStatus(resp.Code) {
+		failed = multierr.Append(failed, fmt.Errorf("unexpected status %d", resp.Code))
+	}
+	if a.responseTime > 0 && resp.ResponseTime > a.responseTime {
+		failed = multierr.Append(failed, fmt.Errorf("response time %s exceeds %s", resp.ResponseTime, a.responseTime))
+	}
+	if a.schema != nil || len(a.jsonpath) > 0 {
+		err := a.checkBody(resp)
+		var assertErr *AssertError
+		switch {
+		case errors.As(err, &assertErr):
+			failed = multierr.Append(failed, assertErr.Err)
+		case err != nil:
+			return err
+		}
+	}
+	if failed != nil {
+		return &AssertError{Err: failed}
+	}
+	return nil
+}
+
+func (a *Assertion) checkStatus(code int) bool {
+	matched, hasPositive := false, false
+	for _, r := range a.status {
+		in := r.Contains(code)
+		if r.not {
+			if in {
+				return false
+			}
+			continue
+		}
+		hasPositive = true
+		matched = matched || in
+	}
+	return matched || !hasPositive
+}
+
+func (a *Assertion) checkBody(resp AssertResponse) error {
+	if resp.Body == nil {
+		return &AssertError{Err: errors.New("response has no body")}
+	}
+	b, err := resp.Body()
+	if err != nil {
+		return fmt.Errorf("cant read body: %w", err)
+	}
+	var data any
+	if err := json.Unmarshal(b, &data); err != nil {
+		return &AssertError{Err: fmt.Errorf("body is not json: %w", err)}
+	}
+	var failed error
+	if a.schema != nil {
+		if err := a.schema.Validate(data); err != nil {
+			failed = multierr.Append(failed, fmt.Errorf("body does not match json schema: %w", err))
+		}
+	}
+	for _, c := range a.jsonpath {
+		if err := c.check(data); err != nil {
+			failed = multierr.Append(failed, err)
+		}
+	}
+	if failed != nil {
+		return &AssertError{Err: failed}
+	}
+	return nil
+}
+
+func (c jsonpathCheck) check(data any) error {
+	val, err := jsonpath.Get(c.path, data)
+	exists := err == nil
+	var ok bool
+	switch c.op {
+	case assertOpExists:
+		ok = exists
+	default:
+		ok = exists && c.compare(val)
+	}
+	if ok != c.not {
+		return nil
+	}
+	var actual string
+	if exists {
+		actual = formatAssertValue(val)
+	} else {
+		actual = "not found"
+	}
+	not := ""
+	if c.not {
+		not = "not "
+	}
+	if c.op == assertOpExists {
+		return fmt.Errorf("jsonpath %s should %sexist", c.path, not)
+	}
+	return fmt.Errorf("jsonpath %s should %s%s %q, got %s", c.path, not, c.op, c.value, actual)
+}
+
+func (c jsonpathCheck) compare(val any) bool {
+	actual := formatAssertValue(val)
+	switch c.op {
+	case assertOpEq:
+		if n, ok := val.(float64); ok && c.number != nil {
+			return n == *c.number
+		}
+		return actual == c.value
+	case assertOpRegexp:
+		return c.re.MatchString(actual)
+	case assertOpContains:
+		return strings.Contains(actual, c.value)
+	}
+	n := parseAssertNumber(val)
+	if n == nil {
+		return false
+	}
+	switch c.op {
+	case assertOpLt:
+		return *n < *c.number
+	case assertOpLe:
+		return *n <= *c.number
+	case assertOpGt:
+		return *n > *c.number
+	case assertOpGe:
+		return *n >= *c.number
+	}
+	return false
+}
+
+// formatAssertValue formats scalars as strings and objects as compact JSON.
+func formatAssertValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case map[string]any, []any:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(b)
+	case nil:
+		return "null"
+	}
+	return fmt.Sprint(v)
+}
+
+// isJSONNumber returns true, if s is a number in JSON syntax without spaces.
+func isJSONNumber(s string) bool {
+	return s != "" && (s[0] == '-' || s[0] >= '0' && s[0] <= '9') && json.Valid([]byte(s))
+}
+
+func parseAssertNumber(v any) *float64 {
+	var f float64
+	var err error
+	switch v := v.(type) {
+	case string:
+		f, err = strconv.ParseFloat(strings.TrimSpace(v), 64)
+	case int:
+		f = float64(v)
+	case int64:
+		f = float64(v)
+	case float64:
+		f = v
+	default:
+		return nil
+	}
+	if err != nil {
+		return nil
+	}
+	return &f
+}
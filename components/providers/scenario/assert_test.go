@@ -0,0 +1,125 @@
+package scenario
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssertion_Check(t *testing.T) {
+	body := func(s string) func() ([]byte, error) {
+		return func() ([]byte, error) { return []byte(s), nil }
+	}
+	const data = `{"id": 12345678, "name": "item", "price": 9.5, "tags": ["a", "b"], "ok": true, "code": "007", "num": 7}`
+	tests := []struct {
+		name    string
+		cfg     AssertConfig
+		resp    AssertResponse
+		wantErr bool
+	}{
+		{name: "empty", resp: AssertResponse{Code: 500}},
+		{name: "status class", cfg: AssertConfig{Status: []string{"2xx"}}, resp: AssertResponse{Code: 201}},
+		{name: "status class failed", cfg: AssertConfig{Status: []string{"2xx"}}, resp: AssertResponse{Code: 302}, wantErr: true},
+		{name: "status range", cfg: AssertConfig{Status: []string{"200", "300-399"}}, resp: AssertResponse{Code: 302}},
+		{name: "status negation", cfg: AssertConfig{Status: []string{"!5xx"}}, resp: AssertResponse{Code: 404}},
+		{name: "status negation failed", cfg: AssertConfig{Status: []string{"2xx", "!204"}}, resp: AssertResponse{Code: 204}, wantErr: true},
+		{name: "response time", cfg: AssertConfig{ResponseTime: time.Second}, resp: AssertResponse{ResponseTime: time.Second}},
+		{name: "response time failed", cfg: AssertConfig{ResponseTime: time.Second}, resp: AssertResponse{ResponseTime: 2 * time.Second}, wantErr: true},
+		{
+			name: "jsonpath",
+			cfg: AssertConfig{JSONPath: []JSONPathAssert{
+				{Path: "$.id", Value: 12345678},
+				{Path: "$.id", Value: "12345678"},
+				{Path: "$.name", Op: "regexp", Value: "^it"},
+				{Path: "$.price", Op: "<", Value: "10"},
+				{Path: "$.price", Op: "ge", Value: 9.5},
+				{Path: "$.tags", Value: `["a","b"]`},
+				{Path: "$.tags", Op: "contains", Value: `"b"`},
+				{Path: "$.ok", Value: true},
+				{Path: "$.error", Op: "exists", Not: true},
+				{Path: "$.name", Value: "other", Not: true},
+				{Path: "$.price", Value: "9.50"},
+				{Path: "$.code", Value: "007"},
+				{Path: "$.code", Value: 7, Not: true},
+				{Path: "$.num", Value: "007", Not: true},
+			}},
+			resp: AssertResponse{Body: body(data)},
+		},
+		{
+			name:    "jsonpath failed",
+			cfg:     AssertConfig{JSONPath: []JSONPathAssert{{Path: "$.price", Op: "gt", Value: 10}}},
+			resp:    AssertResponse{Body: body(data)},
+			wantErr: true,
+		},
+		{
+			name:    "jsonpath not found",
+			cfg:     AssertConfig{JSONPath: []JSONPathAssert{{Path: "$.error", Value: ""}}},
+			resp:    AssertResponse{Body: body(data)},
+			wantErr: true,
+		},
+		{
+			name: "schema",
+			cfg:  AssertConfig{Schema: `{"type": "object", "required": ["id"], "properties": {"id": {"type": "integer"}}}`},
+			resp: AssertResponse{Body: body(data)},
+		},
+		{
+			name:    "schema failed",
+			cfg:     AssertConfig{Schema: `{"type": "object", "required": ["error"]}`},
+			resp:    AssertResponse{Body: body(data)},
+			wantErr: true,
+		},
+		{
+			name:    "not json",
+			cfg:     AssertConfig{JSONPath: []JSONPathAssert{{Path: "$.id", Op: "exists"}}},
+			resp:    AssertResponse{Body: body("<html>")},
+			wantErr: true,
+		},
+		{
+			name:    "no body",
+			cfg:     AssertConfig{JSONPath: []JSONPathAssert{{Path: "$.id", Op: "exists"}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := NewAssertion(tt.cfg)
+			require.NoError(t, err)
+			err = a.Check(tt.resp)
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+			var assertErr *AssertError
+			assert.ErrorAs(t, err, &assertErr)
+		})
+	}
+
+	t.Run("body read error", func(t *testing.T) {
+		a, err := NewAssertion(AssertConfig{Schema: `{}`})
+		require.NoError(t, err)
+		readErr := errors.New("read failed")
+		err = a.Check(AssertResponse{Body: func() ([]byte, error) { return nil, readErr }})
+		assert.ErrorIs(t, err, readErr)
+		assert.False(t, IsAssertError(err))
+	})
+}
+
+func TestNewAssertion_Invalid(t *testing.T) {
+	for name, cfg := range map[string]AssertConfig{
+		"status":      {Status: []string{"2x"}},
+		"range":       {Status: []string{"299-200"}},
+		"schema":      {Schema: `{"type": 1}`},
+		"op":          {JSONPath: []JSONPathAssert{{Path: "$.id", Op: "like"}}},
+		"regexp":      {JSONPath: []JSONPathAssert{{Path: "$.id", Op: "regexp", Value: "("}}},
+		"number":      {JSONPath: []JSONPathAssert{{Path: "$.id", Op: "lt", Value: "ten"}}},
+		"status text": {Status: []string{"ok"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewAssertion(cfg)
+			assert.Error(t, err)
+		})
+	}
+}
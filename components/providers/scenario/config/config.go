@@ -3,10 +3,12 @@ package config
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/spf13/afero"
 	grpcgun "github.com/yandex/pandora/components/guns/grpc/scenario"
 	httpscenario "github.com/yandex/pandora/components/guns/http_scenario"
+	"github.com/yandex/pandora/components/providers/scenario"
 	"github.com/yandex/pandora/components/providers/scenario/http/preprocessor"
 	"github.com/yandex/pandora/components/providers/scenario/vs"
 )
@@ -26,6 +28,72 @@ type ScenarioConfig struct {
 	Weight         int64
 	MinWaitingTime int64 `config:"min_waiting_time"`
 	Requests       []string
+	Conditions     []ConditionConfig
+	Loops          []LoopConfig
+	Choices        []ChoiceConfig
+	// OnError is a default failure policy of scenario requests: continue, stop (default) or abort.
+	OnError string `config:"on_error"`
+	// AbortAfter is a number of scenario failures with abort policy, after that shooting is aborted.
+	AbortAfter int64 `config:"abort_after"`
+	// CookieJar enables cookie jar of HTTP scenario: iteration or instance.
+	CookieJar string `config:"cookie_jar"`
+}
+
+// ConditionConfig is a named if-then-else step. It may be referenced in scenario requests,
+// branches of other conditions, loops and choices.
+type ConditionConfig struct {
+	Name string
+	If   string `config:"if"`
+	Then []string
+	Else []string
+}
+
+// LoopConfig is a named step, that repeats requests while While is true or until Until is true,
+// but no more than Max times.
+type LoopConfig struct {
+	Name     string
+	Requests []string
+	While    string
+	Until    string
+	Max      int
+}
+
+// ChoiceConfig is a named step, that runs one of options chosen randomly by weight.
+type ChoiceConfig struct {
+	Name    string
+	Options []ChoiceOptionConfig
+}
+
+// ChoiceOptionConfig is an option of choice. Option with unset weight has weight 1,
+// option with zero weight is never chosen.
+type ChoiceOptionConfig struct {
+	Weight   *int64
+	Requests []string
+}
+
+func (o ChoiceOptionConfig) weight() int64 {
+	if o.Weight == nil {
+		return 1
+	}
+	return *o.Weight
+}
+
+// RetryConfig describes retries of failed request (call). Backoff and MaxBackoff are in milliseconds.
+type RetryConfig struct {
+	Attempts   int
+	Backoff    int64
+	MaxBackoff int64 `config:"max_backoff"`
+}
+
+func (r *RetryConfig) Retry() scenario.Retry {
+	if r == nil {
+		return scenario.Retry{}
+	}
+	return scenario.Retry{
+		Attempts:   r.Attempts,
+		Backoff:    time.Millisecond * time.Duration(r.Backoff),
+		MaxBackoff: time.Millisecond * time.Duration(r.MaxBackoff),
+	}
 }
 
 // RequestConfig is a config for dynamic converting from map[string]interface{}
@@ -39,6 +107,10 @@ type RequestConfig struct {
 	Preprocessor   *preprocessor.Preprocessor
 	Postprocessors []httpscenario.Postprocessor
 	Templater      httpscenario.Templater
+	OnError        string `config:"on_error"`
+	Retry          *RetryConfig
+	// Session maps variables to session variables of the instance, same as Preprocessor mapping.
+	Session map[string]string
 }
 
 type CallConfig struct {
@@ -49,6 +121,8 @@ type CallConfig struct {
 	Metadata       map[string]string
 	Preprocessors  []grpcgun.Preprocessor
 	Postprocessors []grpcgun.Postprocessor
+	OnError        string `config:"on_error"`
+	Retry          *RetryConfig
 }
 
 func ReadAmmoConfig(fs afero.Fs, fileName string) (ammoCfg *AmmoConfig, err error) {
@@ -85,7 +159,7 @@ func ReadAmmoConfig(fs afero.Fs, fileName string) (ammoCfg *AmmoConfig, err erro
 			return
 		}
 		ammoCfg, err = ConvertHCLToAmmo(ammoHcl)
-	case strings.HasSuffix(lowerName, ".yaml") || strings.HasPrefix(lowerName, ".yml"):
+	case strings.HasSuffix(lowerName, ".yaml") || strings.HasSuffix(lowerName, ".yml"):
 		ammoCfg, err = ParseAmmoConfig(file)
 	default:
 		err = fmt.Errorf("%s file extension should be .yaml or .yml", op)
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"strconv"
@@ -60,7 +61,7 @@ func ExtractVariableStorage(cfg *AmmoConfig) (*vs.SourceStorage, error) {
 	for _, source := range cfg.VariableSources {
 		err := source.Init()
 		if err != nil {
-			return storage, err
+			return storage, errors.Join(err, storage.Close())
 		}
 		storage.AddSource(source.GetName(), source.GetVariables())
 	}
@@ -21,10 +21,47 @@ type AmmoHCL struct {
 }
 
 type ScenarioHCL struct {
-	Name           string   `hcl:"name,label"`
-	Weight         *int64   `hcl:"weight" yaml:"weight,omitempty"`
-	MinWaitingTime *int64   `hcl:"min_waiting_time" config:"min_waiting_time" yaml:"min_waiting_time,omitempty"`
-	Requests       []string `hcl:"requests" yaml:"requests"`
+	Name           string         `hcl:"name,label"`
+	Weight         *int64         `hcl:"weight" yaml:"weight,omitempty"`
+	MinWaitingTime *int64         `hcl:"min_waiting_time" config:"min_waiting_time" yaml:"min_waiting_time,omitempty"`
+	Requests       []string       `hcl:"requests" yaml:"requests"`
+	Conditions     []ConditionHCL `hcl:"condition,block" yaml:"conditions,omitempty"`
+	Loops          []LoopHCL      `hcl:"loop,block" yaml:"loops,omitempty"`
+	Choices        []ChoiceHCL    `hcl:"choice,block" yaml:"choices,omitempty"`
+	OnError        *string        `hcl:"on_error" yaml:"on_error,omitempty"`
+	AbortAfter     *int64         `hcl:"abort_after" yaml:"abort_after,omitempty"`
+	CookieJar      *string        `hcl:"cookie_jar" yaml:"cookie_jar,omitempty"`
+}
+
+type RetryHCL struct {
+	Attempts   int    `hcl:"attempts" yaml:"attempts"`
+	Backoff    *int64 `hcl:"backoff" yaml:"backoff,omitempty"`
+	MaxBackoff *int64 `hcl:"max_backoff" yaml:"max_backoff,omitempty"`
+}
+
+type ConditionHCL struct {
+	Name string    `hcl:"name,label"`
+	If   string    `hcl:"if" yaml:"if"`
+	Then *[]string `hcl:"then" yaml:"then,omitempty"`
+	Else *[]string `hcl:"else" yaml:"else,omitempty"`
+}
+
+type LoopHCL struct {
+	Name     string   `hcl:"name,label"`
+	Requests []string `hcl:"requests" yaml:"requests"`
+	While    *string  `hcl:"while" yaml:"while,omitempty"`
+	Until    *string  `hcl:"until" yaml:"until,omitempty"`
+	Max      *int     `hcl:"max" yaml:"max,omitempty"`
+}
+
+type ChoiceHCL struct {
+	Name    string            `hcl:"name,label"`
+	Options []ChoiceOptionHCL `hcl:"option,block" yaml:"options"`
+}
+
+type ChoiceOptionHCL struct {
+	Weight   *int64   `hcl:"weight" yaml:"weight,omitempty"`
+	Requests []string `hcl:"requests" yaml:"requests"`
 }
 
 type SourceHCL struct {
@@ -35,6 +72,13 @@ type SourceHCL struct {
 	IgnoreFirstLine *bool              `hcl:"ignore_first_line" yaml:"ignore_first_line,omitempty"`
 	Delimiter       *string            `hcl:"delimiter" yaml:"delimiter,omitempty"`
 	Variables       *map[string]string `hcl:"variables" yaml:"variables,omitempty"`
+	SkipEmpty       *bool              `hcl:"skip_empty" yaml:"skip_empty,omitempty"`
+	Query           *string            `hcl:"query" yaml:"query,omitempty"`
+	Unique          *bool              `hcl:"unique" yaml:"unique,omitempty"`
+	OnExhausted     *string            `hcl:"on_exhausted" yaml:"on_exhausted,omitempty"`
+	Stream          *bool              `hcl:"stream" yaml:"stream,omitempty"`
+	Buffer          *int               `hcl:"buffer" yaml:"buffer,omitempty"`
+	ShuffleWindow   *int               `hcl:"shuffle_window" yaml:"shuffle_window,omitempty"`
 }
 
 type RequestHCL struct {
@@ -47,6 +91,9 @@ type RequestHCL struct {
 	Preprocessor   *RequestPreprocessorHCL   `hcl:"preprocessor,block" yaml:"preprocessor,omitempty"`
 	Postprocessors []RequestPostprocessorHCL `hcl:"postprocessor,block" yaml:"postprocessors,omitempty"`
 	Templater      *TemplaterHCL             `hcl:"templater,block" yaml:"templater,omitempty"`
+	OnError        *string                   `hcl:"on_error" yaml:"on_error,omitempty"`
+	Retry          *RetryHCL                 `hcl:"retry,block" yaml:"retry,omitempty"`
+	Session        *map[string]string        `hcl:"session" yaml:"session,omitempty"`
 }
 
 type TemplaterHCL struct {
@@ -59,12 +106,27 @@ type AssertSizeHCL struct {
 }
 
 type RequestPostprocessorHCL struct {
-	Type       string             `hcl:"type,label"`
-	Mapping    *map[string]string `hcl:"mapping" yaml:"mapping,omitempty"`
-	Headers    *map[string]string `hcl:"headers" yaml:"headers,omitempty"`
-	Body       *[]string          `hcl:"body" yaml:"body,omitempty"`
-	StatusCode *int               `hcl:"status_code" yaml:"status_code,omitempty"`
-	Size       *AssertSizeHCL     `hcl:"size,block" yaml:"size,omitempty"`
+	Type         string                        `hcl:"type,label"`
+	Mapping      *map[string]string            `hcl:"mapping" yaml:"mapping,omitempty"`
+	Headers      *map[string]string            `hcl:"headers" yaml:"headers,omitempty"`
+	Body         *[]string                     `hcl:"body" yaml:"body,omitempty"`
+	StatusCode   *int                          `hcl:"status_code" yaml:"status_code,omitempty"`
+	Size         *AssertSizeHCL                `hcl:"size,block" yaml:"size,omitempty"`
+	Match        *string                       `hcl:"match" yaml:"match,omitempty"`
+	Boundaries   *map[string]map[string]string `hcl:"boundaries" yaml:"boundaries,omitempty"`
+	Status       *[]string                     `hcl:"status" yaml:"status,omitempty"`
+	ResponseTime *string                       `hcl:"response_time" yaml:"response_time,omitempty"`
+	Schema       *string                       `hcl:"schema" yaml:"schema,omitempty"`
+	JSONPath     []JSONPathAssertHCL           `hcl:"jsonpath,block" yaml:"jsonpath,omitempty"`
+	Labels       *map[string]string            `hcl:"labels" yaml:"labels,omitempty"`
+	Metrics      *map[string]string            `hcl:"metrics" yaml:"metrics,omitempty"`
+}
+
+type JSONPathAssertHCL struct {
+	Path  string  `hcl:"path,label" yaml:"path"`
+	Op    *string `hcl:"op" yaml:"op,omitempty"`
+	Value *string `hcl:"value" yaml:"value,omitempty"`
+	Not   *bool   `hcl:"not" yaml:"not,omitempty"`
 }
 
 type RequestPreprocessorHCL struct {
@@ -80,12 +142,19 @@ type CallHCL struct {
 	Payload        string                 `hcl:"payload"`
 	Preprocessor   []CallPreprocessorHCL  `hcl:"preprocessor,block" yaml:"preprocessors,omitempty"`
 	Postprocessors []CallPostprocessorHCL `hcl:"postprocessor,block" yaml:"postprocessors,omitempty"`
+	OnError        *string                `hcl:"on_error" yaml:"on_error,omitempty"`
+	Retry          *RetryHCL              `hcl:"retry,block" yaml:"retry,omitempty"`
 }
 
 type CallPostprocessorHCL struct {
-	Type       string    `hcl:"type,label"`
-	Payload    *[]string `hcl:"payload" yaml:"payload,omitempty"`
-	StatusCode *int      `hcl:"status_code" yaml:"status_code,omitempty"`
+	Type         string              `hcl:"type,label"`
+	Mapping      *map[string]string  `hcl:"mapping" yaml:"mapping,omitempty"`
+	Payload      *[]string           `hcl:"payload" yaml:"payload,omitempty"`
+	StatusCode   *int                `hcl:"status_code" yaml:"status_code,omitempty"`
+	Status       *[]string           `hcl:"status" yaml:"status,omitempty"`
+	ResponseTime *string             `hcl:"response_time" yaml:"response_time,omitempty"`
+	Schema       *string             `hcl:"schema" yaml:"schema,omitempty"`
+	JSONPath     []JSONPathAssertHCL `hcl:"jsonpath,block" yaml:"jsonpath,omitempty"`
 }
 
 type CallPreprocessorHCL struct {
@@ -101,29 +170,38 @@ func ParseHCLFile(file afero.File) (AmmoHCL, error) {
 		return AmmoHCL{}, fmt.Errorf("%s, io.ReadAll, %w", op, err)
 	}
 
+	_, config, diag := parseHCL(bytes, file.Name())
+	if diag != nil {
+		return AmmoHCL{}, diag
+	}
+	return config, nil
+}
+
+// parseHCL parses and decodes HCL file. Diagnostics are not nil only on failure.
+func parseHCL(bytes []byte, fileName string) (*hcl.File, AmmoHCL, hcl.Diagnostics) {
 	parser := hclparse.NewParser()
-	f, diag := parser.ParseHCL(bytes, file.Name())
+	f, diag := parser.ParseHCL(bytes, fileName)
 	if diag.HasErrors() {
-		return AmmoHCL{}, diag
+		return f, AmmoHCL{}, diag
 	}
 
 	localsBodyContent, remainingBody, diag := f.Body.PartialContent(localsSchema())
 	// diag still may have errors, because PartialContent doesn't know about Functions and self-references to locals
 	if localsBodyContent == nil || remainingBody == nil {
-		return AmmoHCL{}, diag
+		return f, AmmoHCL{}, diag
 	}
 
 	hclContext, diag := decodeLocals(localsBodyContent)
 	if diag.HasErrors() {
-		return AmmoHCL{}, diag
+		return f, AmmoHCL{}, diag
 	}
 
 	var config AmmoHCL
 	diag = gohcl.DecodeBody(remainingBody, hclContext, &config)
 	if diag.HasErrors() {
-		return AmmoHCL{}, diag
+		return f, AmmoHCL{}, diag
 	}
-	return config, nil
+	return f, config, nil
 }
 
 func decodeLocals(localsBodyContent *hcl.BodyContent) (*hcl.EvalContext, hcl.Diagnostics) {
@@ -66,5 +66,10 @@ func TestParseHCLFile(t *testing.T) {
 			Name:      "variables",
 			Type:      "variables",
 			Variables: &(map[string]string{"header": "yandex", "b": "s"})})
+		require.Len(t, ammoHCL.Calls[0].Postprocessors, 3)
+		assert.Equal(t, CallPostprocessorHCL{
+			Type:    "var/jsonpath",
+			Mapping: &(map[string]string{"auth_token": "$.token"}),
+		}, ammoHCL.Calls[0].Postprocessors[1])
 	})
 }
@@ -0,0 +1,233 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/spf13/afero"
+	"github.com/yandex/pandora/components/providers/scenario/vs"
+	"gopkg.in/yaml.v3"
+)
+
+// Lint reads scenario ammo file and checks it with ValidateAmmoConfig. Variable sources are initialized,
+// so their files and fields are checked too. Problems are returned as HCL diagnostics with positions in file,
+// files contain sources for hcl.NewDiagnosticTextWriter. Error is returned, if file can't be read.
+func Lint(fs afero.Fs, fileName string) (hcl.Diagnostics, map[string]*hcl.File, error) {
+	data, err := afero.ReadFile(fs, fileName)
+	if err != nil {
+		return nil, nil, err
+	}
+	files := map[string]*hcl.File{fileName: {Bytes: data}}
+
+	var (
+		cfg    *AmmoConfig
+		locate func(path []string) hcl.Range
+	)
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".hcl":
+		file, ammo, diags := parseHCL(data, fileName)
+		if file != nil {
+			files[fileName] = file
+		}
+		if diags != nil {
+			return diags, files, nil
+		}
+		body, ok := file.Body.(*hclsyntax.Body)
+		if !ok {
+			return nil, nil, fmt.Errorf("unexpected HCL body %T", file.Body)
+		}
+		locate = func(path []string) hcl.Range {
+			return hclRange(body, path)
+		}
+		cfg, err = ConvertHCLToAmmo(ammo)
+	case ".yaml", ".yml":
+		var root yaml.Node
+		if yamlErr := yaml.Unmarshal(data, &root); yamlErr != nil {
+			subject := yamlErrorRange(fileName, data, yamlErr)
+			return hcl.Diagnostics{{Severity: hcl.DiagError, Summary: "Invalid YAML", Detail: yamlErr.Error(), Subject: &subject}}, files, nil
+		}
+		locate = func(path []string) hcl.Range {
+			return yamlRange(fileName, data, &root, path)
+		}
+		cfg, err = DecodeMap(data)
+	default:
+		return nil, nil, fmt.Errorf("file %s extension should be .hcl, .yaml or .yml", fileName)
+	}
+	if err != nil {
+		subject := locate(nil)
+		return hcl.Diagnostics{{Severity: hcl.DiagError, Summary: "Invalid scenario config", Detail: err.Error(), Subject: &subject}}, files, nil
+	}
+
+	storage := vs.NewVariableStorage()
+	var problems Problems
+	for _, source := range cfg.VariableSources {
+		if initErr := source.Init(); initErr != nil {
+			problems = append(problems, Problem{Path: []string{"variable_source", source.GetName()}, Message: initErr.Error()})
+			continue
+		}
+		storage.AddSource(source.GetName(), source.GetVariables())
+	}
+	problems = append(problems, ValidateAmmoConfig(cfg, storage)...)
+	if closeErr := storage.Close(); closeErr != nil {
+		problems = append(problems, Problem{Path: []string{"variable_source"}, Message: closeErr.Error()})
+	}
+
+	diags := make(hcl.Diagnostics, 0, len(problems))
+	for _, p := range problems {
+		severity := hcl.DiagError
+		if p.Warning {
+			severity = hcl.DiagWarning
+		}
+		subject := locate(p.Path)
+		diags = append(diags, &hcl.Diagnostic{Severity: severity, Summary: p.Message, Subject: &subject})
+	}
+	sort.SliceStable(diags, func(i, j int) bool {
+		return diags[i].Subject.Start.Byte < diags[j].Subject.Start.Byte
+	})
+	return diags, files, nil
+}
+
+// hclRange returns range of config element located by problem path, or of its closest found parent.
+func hclRange(body *hclsyntax.Body, path []string) hcl.Range {
+	result := hcl.Range{Filename: body.SrcRange.Filename, Start: hcl.InitialPos, End: hcl.InitialPos}
+	for len(path) > 0 {
+		if block, n := hclBlock(body, path); block != nil {
+			result = block.DefRange()
+			body = block.Body
+			path = path[n:]
+			continue
+		}
+		if attr, ok := body.Attributes[path[0]]; ok {
+			return attr.SrcRange
+		}
+		break
+	}
+	return result
+}
+
+// hclBlock finds block by type and label or index of unlabeled block. n is a number of used path elements.
+func hclBlock(body *hclsyntax.Body, path []string) (block *hclsyntax.Block, n int) {
+	var blocks []*hclsyntax.Block
+	for _, b := range body.Blocks {
+		if b.Type == path[0] {
+			blocks = append(blocks, b)
+		}
+	}
+	if len(blocks) == 0 {
+		return nil, 0
+	}
+	if len(path) > 1 {
+		for _, b := range blocks {
+			if len(b.Labels) > 0 && b.Labels[0] == path[1] {
+				return b, 2
+			}
+		}
+		if i, err := strconv.Atoi(path[1]); err == nil && i >= 0 && i < len(blocks) && len(blocks[i].Labels) == 0 {
+			return blocks[i], 2
+		}
+	}
+	if len(blocks[0].Labels) == 0 {
+		return blocks[0], 1
+	}
+	return nil, 0
+}
+
+// yamlRange returns range of config element located by problem path, or of its closest found parent.
+// Blocks of HCL are lists in YAML with plural keys, e.g. request blocks are requests, and are found by name or type.
+func yamlRange(fileName string, data []byte, root *yaml.Node, path []string) hcl.Range {
+	result := hcl.Range{Filename: fileName, Start: hcl.InitialPos, End: hcl.InitialPos}
+	node := root
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	for len(path) > 0 && node.Kind == yaml.MappingNode {
+		if _, list := yamlValue(node, path[0]+"s"); list != nil && list.Kind == yaml.SequenceNode && len(path) > 1 {
+			if item := yamlItem(list, path[1]); item != nil {
+				result = yamlLineRange(fileName, data, item)
+				node = item
+				path = path[2:]
+				continue
+			}
+		}
+		key, value := yamlValue(node, path[0])
+		if key == nil {
+			break
+		}
+		result = yamlLineRange(fileName, data, key)
+		if len(path) == 1 || value.Kind != yaml.MappingNode {
+			break
+		}
+		node = value
+		path = path[1:]
+	}
+	return result
+}
+
+func yamlValue(mapping *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if strings.EqualFold(mapping.Content[i].Value, key) {
+			return mapping.Content[i], mapping.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// yamlItem finds list item by name, type or index.
+func yamlItem(list *yaml.Node, id string) *yaml.Node {
+	for _, item := range list.Content {
+		for _, key := range []string{"name", "type"} {
+			if _, value := yamlValue(item, key); value != nil && value.Value == id {
+				return item
+			}
+		}
+	}
+	if i, err := strconv.Atoi(id); err == nil && i >= 0 && i < len(list.Content) {
+		return list.Content[i]
+	}
+	return nil
+}
+
+// yamlLineRange returns range from node start to the end of its line.
+func yamlLineRange(fileName string, data []byte, node *yaml.Node) hcl.Range {
+	line, column := max(node.Line, 1), max(node.Column, 1)
+	start := lineOffset(data, line)
+	end := bytes.IndexByte(data[start:], '\n')
+	if end < 0 {
+		end = len(data) - start
+	}
+	end = len(bytes.TrimRight(data[start:start+end], "\r"))
+	return hcl.Range{
+		Filename: fileName,
+		Start:    hcl.Pos{Line: line, Column: column, Byte: start + column - 1},
+		End:      hcl.Pos{Line: line, Column: end + 1, Byte: start + end},
+	}
+}
+
+var yamlErrorLine = regexp.MustCompile(`line (\d+)`)
+
+func yamlErrorRange(fileName string, data []byte, err error) hcl.Range {
+	line := 1
+	if m := yamlErrorLine.FindStringSubmatch(err.Error()); m != nil {
+		line, _ = strconv.Atoi(m[1])
+	}
+	return yamlLineRange(fileName, data, &yaml.Node{Line: line, Column: 1})
+}
+
+func lineOffset(data []byte, line int) int {
+	offset := 0
+	for i := 1; i < line; i++ {
+		next := bytes.IndexByte(data[offset:], '\n')
+		if next < 0 {
+			return offset
+		}
+		offset += next + 1
+	}
+	return offset
+}
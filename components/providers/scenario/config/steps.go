@@ -0,0 +1,138 @@
+package config
+
+import (
+	"fmt"
+	"text/template"
+	"time"
+
+	"github.com/yandex/pandora/components/providers/scenario/flow"
+)
+
+// StepBuilder converts scenario step names into gun specific steps.
+// Names are resolved to scenario conditions, loops and choices first, then to requests (calls) via NewStep.
+type StepBuilder[S any] struct {
+	Scenario ScenarioConfig
+	// NewStep returns request (call) step by name. ok is false, if there is no such request.
+	NewStep func(name string) (step S, ok bool)
+	// NewControl wraps control step to gun step.
+	NewControl func(control *flow.Control[S]) S
+	AddSleep   func(step *S, sleep time.Duration)
+	// Funcs are template functions of conditions. templater.GetFuncs is used, if nil.
+	Funcs template.FuncMap
+
+	visiting map[string]bool
+}
+
+func (b *StepBuilder[S]) Build(names []string) ([]S, error) {
+	result := make([]S, 0, len(names))
+	for _, sh := range names {
+		name, cnt, sleep, err := ParseShootName(sh)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse shoot %s: %w", sh, err)
+		}
+		if name == "sleep" {
+			if len(result) == 0 {
+				return nil, fmt.Errorf("sleep must follow a request")
+			}
+			b.AddSleep(&result[len(result)-1], time.Millisecond*time.Duration(cnt))
+			continue
+		}
+		step, err := b.step(name)
+		if err != nil {
+			return nil, err
+		}
+		if sleep > 0 {
+			b.AddSleep(&step, time.Millisecond*time.Duration(sleep))
+		}
+		for i := 0; i < cnt; i++ {
+			result = append(result, step)
+		}
+	}
+	return result, nil
+}
+
+func (b *StepBuilder[S]) step(name string) (S, error) {
+	var empty S
+	control, isControl, err := b.control(name)
+	if err != nil {
+		return empty, err
+	}
+	if isControl {
+		return b.NewControl(control), nil
+	}
+	step, ok := b.NewStep(name)
+	if !ok {
+		return empty, fmt.Errorf("request %s not found", name)
+	}
+	return step, nil
+}
+
+func (b *StepBuilder[S]) control(name string) (*flow.Control[S], bool, error) {
+	if b.visiting == nil {
+		b.visiting = map[string]bool{}
+	}
+	if b.visiting[name] {
+		return nil, true, fmt.Errorf("step %s recursively references itself", name)
+	}
+	b.visiting[name] = true
+	defer delete(b.visiting, name)
+
+	for _, c := range b.Scenario.Conditions {
+		if c.Name != name {
+			continue
+		}
+		cond, err := flow.NewCondition(c.If, b.Funcs)
+		if err != nil {
+			return nil, true, fmt.Errorf("condition %s: %w", name, err)
+		}
+		then, err := b.Build(c.Then)
+		if err != nil {
+			return nil, true, fmt.Errorf("condition %s then: %w", name, err)
+		}
+		els, err := b.Build(c.Else)
+		if err != nil {
+			return nil, true, fmt.Errorf("condition %s else: %w", name, err)
+		}
+		return flow.NewIf(name, cond, then, els), true, nil
+	}
+	for _, l := range b.Scenario.Loops {
+		if l.Name != name {
+			continue
+		}
+		var while, until *flow.Condition
+		var err error
+		if l.While != "" {
+			while, err = flow.NewCondition(l.While, b.Funcs)
+			if err != nil {
+				return nil, true, fmt.Errorf("loop %s while: %w", name, err)
+			}
+		}
+		if l.Until != "" {
+			until, err = flow.NewCondition(l.Until, b.Funcs)
+			if err != nil {
+				return nil, true, fmt.Errorf("loop %s until: %w", name, err)
+			}
+		}
+		body, err := b.Build(l.Requests)
+		if err != nil {
+			return nil, true, fmt.Errorf("loop %s: %w", name, err)
+		}
+		return flow.NewLoop(name, body, while, until, l.Max), true, nil
+	}
+	for _, c := range b.Scenario.Choices {
+		if c.Name != name {
+			continue
+		}
+		options := make([]flow.Option[S], len(c.Options))
+		for i, o := range c.Options {
+			steps, err := b.Build(o.Requests)
+			if err != nil {
+				return nil, true, fmt.Errorf("choice %s option %d: %w", name, i, err)
+			}
+			options[i] = flow.Option[S]{Weight: o.weight(), Steps: steps}
+		}
+		control, err := flow.NewChoice(name, options)
+		return control, true, err
+	}
+	return nil, false, nil
+}
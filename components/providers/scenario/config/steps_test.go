@@ -0,0 +1,89 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yandex/pandora/components/providers/scenario/flow"
+)
+
+type testStep struct {
+	name    string
+	sleep   time.Duration
+	control *flow.Control[testStep]
+}
+
+func newTestBuilder(sc ScenarioConfig, requests ...string) *StepBuilder[testStep] {
+	return &StepBuilder[testStep]{
+		Scenario: sc,
+		NewStep: func(name string) (testStep, bool) {
+			for _, r := range requests {
+				if r == name {
+					return testStep{name: name}, true
+				}
+			}
+			return testStep{}, false
+		},
+		NewControl: func(control *flow.Control[testStep]) testStep {
+			return testStep{name: control.Name, control: control}
+		},
+		AddSleep: func(step *testStep, sleep time.Duration) {
+			step.sleep += sleep
+		},
+	}
+}
+
+func TestStepBuilder_Build(t *testing.T) {
+	t.Run("linear", func(t *testing.T) {
+		b := newTestBuilder(ScenarioConfig{}, "a", "b")
+		got, err := b.Build([]string{"a(2, 10)", "sleep(5)", "b"})
+		require.NoError(t, err)
+		assert.Equal(t, []testStep{
+			{name: "a", sleep: 10 * time.Millisecond},
+			{name: "a", sleep: 15 * time.Millisecond},
+			{name: "b"},
+		}, got)
+	})
+
+	t.Run("controls", func(t *testing.T) {
+		sc := ScenarioConfig{
+			Conditions: []ConditionConfig{{Name: "if", If: "true", Then: []string{"a"}, Else: []string{"loop"}}},
+			Loops:      []LoopConfig{{Name: "loop", Requests: []string{"b", "sleep(10)"}, Until: "true", Max: 3}},
+			Choices:    []ChoiceConfig{{Name: "choice", Options: []ChoiceOptionConfig{{Requests: []string{"a"}}}}},
+		}
+		b := newTestBuilder(sc, "a", "b")
+		got, err := b.Build([]string{"if", "choice"})
+		require.NoError(t, err)
+		require.Len(t, got, 2)
+
+		ifControl := got[0].control
+		require.NotNil(t, ifControl)
+		assert.Equal(t, flow.TypeIf, ifControl.Type)
+		assert.Equal(t, []testStep{{name: "a"}}, ifControl.Then)
+		require.Len(t, ifControl.Else, 1)
+		loop := ifControl.Else[0].control
+		require.NotNil(t, loop)
+		assert.Equal(t, flow.TypeLoop, loop.Type)
+		assert.Equal(t, 3, loop.MaxIterations)
+		assert.Equal(t, []testStep{{name: "b", sleep: 10 * time.Millisecond}}, loop.Body)
+
+		assert.Equal(t, flow.TypeChoice, got[1].control.Type)
+		assert.Equal(t, int64(1), got[1].control.Options[0].Weight)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		sc := ScenarioConfig{
+			Conditions: []ConditionConfig{
+				{Name: "recursive", If: "true", Then: []string{"recursive"}},
+				{Name: "invalid", If: "{{ eq "},
+			},
+		}
+		b := newTestBuilder(sc, "a")
+		for _, names := range [][]string{{"unknown"}, {"sleep(10)"}, {"recursive"}, {"invalid"}} {
+			_, err := b.Build(names)
+			assert.Error(t, err, names)
+		}
+	})
+}
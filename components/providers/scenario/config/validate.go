@@ -0,0 +1,725 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+
+	httpscenario "github.com/yandex/pandora/components/guns/http_scenario"
+	"github.com/yandex/pandora/components/providers/scenario"
+	"github.com/yandex/pandora/components/providers/scenario/flow"
+	grpcpreprocessor "github.com/yandex/pandora/components/providers/scenario/grpc/preprocessor"
+	"github.com/yandex/pandora/components/providers/scenario/templater"
+	"github.com/yandex/pandora/components/providers/scenario/vs"
+)
+
+// Problem is a mistake in scenario ammo config found by ValidateAmmoConfig.
+type Problem struct {
+	// Path locates problem element in config: block types followed by block names (or indexes of unnamed blocks),
+	// and optional attribute name. For example: request, auth, uri or scenario, main, loop, poll, until.
+	Path    []string
+	Message string
+	// Warning is set for likely mistakes, that don't break scenario.
+	Warning bool
+}
+
+func (p Problem) String() string {
+	if len(p.Path) == 0 {
+		return p.Message
+	}
+	return strings.Join(p.Path, ".") + ": " + p.Message
+}
+
+type Problems []Problem
+
+// Err returns all errors joined into one, or nil if there are only warnings.
+func (p Problems) Err() error {
+	var errs []string
+	for _, problem := range p {
+		if !problem.Warning {
+			errs = append(errs, problem.String())
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid scenario: %s", strings.Join(errs, "; "))
+}
+
+// ValidateAmmoConfig checks scenario ammo config before shooting and returns all found problems:
+// unknown and duplicate names, invalid steps, weights and template syntax,
+// and template or mapping variables, that are not defined by variable sources and requests.
+// Variable source fields are checked, if storage with initialized sources is passed.
+func ValidateAmmoConfig(cfg *AmmoConfig, storage *vs.SourceStorage) Problems {
+	v := &validator{
+		cfg:      cfg,
+		requests: map[string]*RequestConfig{},
+		calls:    map[string]*CallConfig{},
+		sources:  map[string]any{},
+		session:  map[string]bool{},
+		refs:     map[string][]reference{},
+		used:     map[string]bool{},
+		reported: map[string]bool{},
+	}
+	v.collect(storage)
+	for i := range cfg.Requests {
+		v.checkRequest(&cfg.Requests[i])
+	}
+	for i := range cfg.Calls {
+		v.checkCall(&cfg.Calls[i])
+	}
+	if len(cfg.Scenarios) == 0 {
+		v.errorf(nil, "no scenarios defined")
+	}
+	for _, sc := range cfg.Scenarios {
+		v.checkScenario(sc)
+	}
+	for _, req := range cfg.Requests {
+		if !v.used[req.Name] {
+			v.warnf([]string{"request", req.Name}, "request %s is not used in scenarios", req.Name)
+		}
+	}
+	for _, call := range cfg.Calls {
+		if !v.used[call.Name] {
+			v.warnf([]string{"call", call.Name}, "call %s is not used in scenarios", call.Name)
+		}
+	}
+	return v.problems
+}
+
+// reference is a variable referenced by template or mapping, e.g. request.auth.postprocessor.token.
+type reference struct {
+	// path is a config element with reference.
+	path []string
+	// segments are variable path segments with optional indexes, e.g. source, users[next], name.
+	segments []string
+	// afterResponse is set for references evaluated after request response, so own postprocessor variables are available.
+	afterResponse bool
+}
+
+type validator struct {
+	cfg      *AmmoConfig
+	requests map[string]*RequestConfig
+	calls    map[string]*CallConfig
+	// sources maps variable source names to their variables, or nil, if they are unknown.
+	sources map[string]any
+	// session is a set of session variables, set by requests.
+	session map[string]bool
+	// refs are variables referenced by requests and calls.
+	refs map[string][]reference
+	// used is a set of requests and calls used in scenarios.
+	used     map[string]bool
+	problems Problems
+	reported map[string]bool
+}
+
+func (v *validator) collect(storage *vs.SourceStorage) {
+	var variables map[string]any
+	if storage != nil {
+		variables = storage.Variables()
+	}
+	for i, source := range v.cfg.VariableSources {
+		name := source.GetName()
+		if _, ok := v.sources[name]; ok {
+			v.errorf([]string{"variable_source", name}, "duplicate variable source %s", name)
+		}
+		if name == "" {
+			v.errorf([]string{"variable_source", strconv.Itoa(i)}, "variable source name is empty")
+		}
+		v.sources[name] = variables[name]
+	}
+	for i := range v.cfg.Requests {
+		req := &v.cfg.Requests[i]
+		if _, ok := v.requests[req.Name]; ok {
+			v.errorf([]string{"request", req.Name}, "duplicate request %s", req.Name)
+		}
+		v.requests[req.Name] = req
+		for name := range req.Session {
+			v.session[name] = true
+		}
+	}
+	for i := range v.cfg.Calls {
+		call := &v.cfg.Calls[i]
+		if _, ok := v.calls[call.Name]; ok {
+			v.errorf([]string{"call", call.Name}, "duplicate call %s", call.Name)
+		}
+		v.calls[call.Name] = call
+	}
+	scenarios := map[string]bool{}
+	for _, sc := range v.cfg.Scenarios {
+		if scenarios[sc.Name] {
+			v.errorf([]string{"scenario", sc.Name}, "duplicate scenario %s", sc.Name)
+		}
+		scenarios[sc.Name] = true
+	}
+}
+
+func (v *validator) checkRequest(req *RequestConfig) {
+	path := []string{"request", req.Name}
+	if _, err := scenario.ParseOnError(req.OnError); err != nil {
+		v.errorf(append(path, "on_error"), "%v", err)
+	}
+	v.checkRetry(append(path, "retry"), req.Retry)
+	refs := v.templateRefs(append(path, "uri"), req.URI)
+	for _, name := range sortedKeys(req.Headers) {
+		refs = append(refs, v.templateRefs(append(path, "headers"), req.Headers[name])...)
+	}
+	if req.Body != nil {
+		refs = append(refs, v.templateRefs(append(path, "body"), *req.Body)...)
+	}
+	if req.Preprocessor != nil {
+		refs = append(refs, mappingRefs(append(path, "preprocessor", "mapping"), req.Preprocessor.Mapping, false)...)
+	}
+	refs = append(refs, mappingRefs(append(path, "session"), req.Session, true)...)
+	v.checkRefs(refs)
+	v.refs[req.Name] = refs
+}
+
+func (v *validator) checkCall(call *CallConfig) {
+	path := []string{"call", call.Name}
+	if _, err := scenario.ParseOnError(call.OnError); err != nil {
+		v.errorf(append(path, "on_error"), "%v", err)
+	}
+	v.checkRetry(append(path, "retry"), call.Retry)
+	refs := v.templateRefs(append(path, "payload"), call.Payload)
+	for _, name := range sortedKeys(call.Metadata) {
+		refs = append(refs, v.templateRefs(append(path, "metadata"), call.Metadata[name])...)
+	}
+	for _, p := range call.Preprocessors {
+		if prepare, ok := p.(*grpcpreprocessor.PreparePreprocessor); ok {
+			refs = append(refs, mappingRefs(append(path, "preprocessor", "prepare", "mapping"), prepare.Mapping, false)...)
+		}
+	}
+	v.checkRefs(refs)
+	v.refs[call.Name] = refs
+}
+
+const (
+	maxRetryAttempts = 100
+	// maxRetryBackoff is a limit of backoff and max_backoff in milliseconds.
+	maxRetryBackoff = int64(time.Hour / time.Millisecond)
+)
+
+func (v *validator) checkRetry(path []string, r *RetryConfig) {
+	if r == nil {
+		return
+	}
+	if r.Attempts < 0 || r.Attempts > maxRetryAttempts {
+		v.errorf(append(path, "attempts"), "attempts %d should be from 0 to %d", r.Attempts, maxRetryAttempts)
+	}
+	if r.Backoff < 0 || r.Backoff > maxRetryBackoff {
+		v.errorf(append(path, "backoff"), "backoff %d should be from 0 to %d ms", r.Backoff, maxRetryBackoff)
+	}
+	if r.MaxBackoff < 0 || r.MaxBackoff > maxRetryBackoff {
+		v.errorf(append(path, "max_backoff"), "max_backoff %d should be from 0 to %d ms", r.MaxBackoff, maxRetryBackoff)
+	}
+}
+
+// templateRefs checks template syntax and returns variables referenced by template.
+func (v *validator) templateRefs(path []string, text string) []reference {
+	if !strings.Contains(text, "{{") {
+		return nil
+	}
+	tmpl, err := template.New(path[len(path)-1]).Funcs(templater.GetFuncs()).Parse(text)
+	if err != nil {
+		v.errorf(path, "invalid template: %v", err)
+		return nil
+	}
+	return treeRefs(path, tmpl)
+}
+
+// treeRefs returns variables referenced by parsed template.
+// Fields inside range and with blocks are relative to their pipelines, so they are skipped.
+func treeRefs(path []string, tmpl *template.Template) []reference {
+	var refs []reference
+	var walk func(node parse.Node, root bool)
+	walk = func(node parse.Node, root bool) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child, root)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe, root)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, cmd := range n.Cmds {
+				walk(cmd, root)
+			}
+		case *parse.CommandNode:
+			for _, arg := range n.Args {
+				walk(arg, root)
+			}
+		case *parse.ChainNode:
+			walk(n.Node, root)
+		case *parse.FieldNode:
+			if root {
+				refs = append(refs, reference{path: path, segments: n.Ident})
+			}
+		case *parse.VariableNode:
+			if len(n.Ident) > 1 && n.Ident[0] == "$" {
+				refs = append(refs, reference{path: path, segments: n.Ident[1:]})
+			}
+		case *parse.IfNode:
+			walk(n.Pipe, root)
+			walk(n.List, root)
+			walk(n.ElseList, root)
+		case *parse.RangeNode:
+			walk(n.Pipe, root)
+			walk(n.List, false)
+			walk(n.ElseList, root)
+		case *parse.WithNode:
+			walk(n.Pipe, root)
+			walk(n.List, false)
+			walk(n.ElseList, root)
+		case *parse.TemplateNode:
+			walk(n.Pipe, root)
+		}
+	}
+	if tmpl.Tree != nil {
+		walk(tmpl.Tree.Root, true)
+	}
+	return refs
+}
+
+// mappingRefs returns variables referenced by preprocessor or session mapping.
+// Mapping values are variable paths or template functions, which arguments may be variable paths.
+func mappingRefs(path []string, mapping map[string]string, afterResponse bool) []reference {
+	var refs []reference
+	for _, name := range sortedKeys(mapping) {
+		value := mapping[name]
+		fun, args := templater.ParseFunc(value)
+		if fun == nil {
+			refs = append(refs, reference{path: path, segments: splitVariablePath(value), afterResponse: afterResponse})
+			continue
+		}
+		for _, arg := range args {
+			// Function arguments, that are not variables, are passed as is.
+			segments := splitVariablePath(arg)
+			switch segmentName(segments[0]) {
+			case "request", "source", "session":
+				refs = append(refs, reference{path: path, segments: segments, afterResponse: afterResponse})
+			}
+		}
+	}
+	return refs
+}
+
+func (v *validator) checkRefs(refs []reference) {
+	for _, ref := range refs {
+		v.checkRef(ref)
+	}
+}
+
+func (v *validator) checkRef(ref reference) {
+	segments := ref.segments
+	if len(segments) < 2 {
+		return
+	}
+	name := segmentName(segments[1])
+	switch root := segmentName(segments[0]); root {
+	case "request":
+		_, isRequest := v.requests[name]
+		_, isCall := v.calls[name]
+		if !isRequest && !isCall {
+			v.errorf(ref.path, "unknown request %s in variable %s", name, variableName(segments))
+			return
+		}
+		if len(segments) < 3 {
+			return
+		}
+		switch field := segmentName(segments[2]); field {
+		case "status":
+		case "preprocessor", "postprocessor":
+			if len(segments) < 4 {
+				return
+			}
+			vars, known := v.stepVars(name, field)
+			if known && !vars[segmentName(segments[3])] {
+				v.errorf(ref.path, "request %s %s does not set variable %s", name, field, segmentName(segments[3]))
+			}
+		default:
+			v.errorf(ref.path, "unknown request variable %s: should be preprocessor, postprocessor or status", variableName(segments))
+		}
+	case "source":
+		variables, ok := v.sources[name]
+		if !ok {
+			v.errorf(ref.path, "unknown variable source %s in variable %s", name, variableName(segments))
+			return
+		}
+		if field, sampled := missingField(variables, segments[1:]); field != "" {
+			if sampled {
+				v.warnf(ref.path, "first row of variable source %s has no field %s", name, field)
+			} else {
+				v.errorf(ref.path, "variable source %s has no field %s", name, field)
+			}
+		}
+	case "session":
+		if !v.session[name] {
+			v.warnf(ref.path, "session variable %s is not set by requests", name)
+		}
+	case "instance":
+	default:
+		v.errorf(ref.path, "unknown variable %s: should start with request, source, session or instance", variableName(segments))
+	}
+}
+
+// stepVars returns names of preprocessor or postprocessor variables of request (call).
+// known is false, if variables can't be determined before shooting, e.g. gRPC call response fields.
+func (v *validator) stepVars(name, field string) (vars map[string]bool, known bool) {
+	vars = map[string]bool{}
+	if req, ok := v.requests[name]; ok {
+		if field == "preprocessor" {
+			if req.Preprocessor != nil {
+				for k := range req.Preprocessor.Mapping {
+					vars[k] = true
+				}
+			}
+			return vars, true
+		}
+		for _, p := range req.Postprocessors {
+			r, ok := p.(returnedParamser)
+			if !ok {
+				// Variables of custom postprocessor are unknown.
+				return nil, false
+			}
+			for _, k := range r.ReturnedParams() {
+				vars[k] = true
+			}
+		}
+		return vars, true
+	}
+	call := v.calls[name]
+	if field == "postprocessor" {
+		return nil, false
+	}
+	for _, p := range call.Preprocessors {
+		prepare, ok := p.(*grpcpreprocessor.PreparePreprocessor)
+		if !ok {
+			return nil, false
+		}
+		for k := range prepare.Mapping {
+			vars[k] = true
+		}
+	}
+	return vars, true
+}
+
+// returnedParamser is implemented by postprocessors, that know variables they set.
+type returnedParamser interface {
+	ReturnedParams() []string
+}
+
+func (v *validator) checkScenario(sc ScenarioConfig) {
+	path := []string{"scenario", sc.Name}
+	if sc.Weight < 0 {
+		v.errorf(append(path, "weight"), "negative weight %d", sc.Weight)
+	}
+	if _, err := scenario.ParseOnError(sc.OnError); err != nil {
+		v.errorf(append(path, "on_error"), "%v", err)
+	}
+	if _, err := httpscenario.ParseCookieJarScope(sc.CookieJar); err != nil {
+		v.errorf(append(path, "cookie_jar"), "%v", err)
+	}
+	w := &scenarioWalker{validator: v, scenario: sc, visiting: map[string]bool{}, used: map[string]bool{}}
+	controls := map[string]bool{}
+	w.forControls(func(kind, name string) {
+		controlPath := append(path, kind, name)
+		if controls[name] {
+			v.errorf(controlPath, "duplicate step %s", name)
+		}
+		controls[name] = true
+		if _, ok := v.requests[name]; ok {
+			v.warnf(controlPath, "%s %s shadows request with the same name", kind, name)
+		}
+		if _, ok := v.calls[name]; ok {
+			v.warnf(controlPath, "%s %s shadows call with the same name", kind, name)
+		}
+	})
+	if len(sc.Requests) == 0 {
+		v.errorf(append(path, "requests"), "scenario has no requests")
+	}
+	w.steps(append(path, "requests"), sc.Requests, map[string]bool{})
+	w.forControls(func(kind, name string) {
+		if !w.used[name] {
+			v.warnf(append(path, kind, name), "%s %s is not used in scenario", kind, name)
+		}
+	})
+}
+
+// scenarioWalker walks scenario steps in order of execution, so it checks, that requests reference
+// variables of requests executed before them.
+type scenarioWalker struct {
+	*validator
+	scenario ScenarioConfig
+	visiting map[string]bool
+	// used is a set of scenario controls used in steps.
+	used map[string]bool
+}
+
+func (w *scenarioWalker) forControls(fn func(kind, name string)) {
+	for _, c := range w.scenario.Conditions {
+		fn("condition", c.Name)
+	}
+	for _, l := range w.scenario.Loops {
+		fn("loop", l.Name)
+	}
+	for _, c := range w.scenario.Choices {
+		fn("choice", c.Name)
+	}
+}
+
+// steps walks steps with names from path. executed is a set of requests (calls) executed before steps,
+// it is filled with requests, that may be executed by steps.
+func (w *scenarioWalker) steps(path []string, names []string, executed map[string]bool) {
+	count := 0
+	for _, shoot := range names {
+		name, _, _, err := ParseShootName(shoot)
+		if err != nil {
+			w.errorf(path, "invalid step %s: %v", shoot, err)
+			continue
+		}
+		if name == "sleep" {
+			if count == 0 {
+				w.errorf(path, "sleep must follow a request")
+			}
+			continue
+		}
+		count++
+		if w.visiting[name] {
+			w.errorf(path, "step %s recursively references itself", name)
+			continue
+		}
+		if w.control(name, executed) {
+			continue
+		}
+		_, isRequest := w.requests[name]
+		_, isCall := w.calls[name]
+		if !isRequest && !isCall {
+			w.errorf(path, "unknown request %s", name)
+			continue
+		}
+		w.validator.used[name] = true
+		for _, ref := range w.refs[name] {
+			w.checkOrder(ref, name, executed)
+		}
+		executed[name] = true
+	}
+}
+
+// control walks scenario control with name, if it exists.
+func (w *scenarioWalker) control(name string, executed map[string]bool) bool {
+	w.visiting[name] = true
+	defer delete(w.visiting, name)
+	path := []string{"scenario", w.scenario.Name}
+	for _, c := range w.scenario.Conditions {
+		if c.Name != name {
+			continue
+		}
+		w.used[name] = true
+		path = append(path, "condition", name)
+		w.condition(append(path, "if"), c.If, executed)
+		then := copyNames(executed)
+		w.steps(append(path, "then"), c.Then, then)
+		w.steps(append(path, "else"), c.Else, executed)
+		mergeNames(executed, then)
+		return true
+	}
+	for _, l := range w.scenario.Loops {
+		if l.Name != name {
+			continue
+		}
+		w.used[name] = true
+		path = append(path, "loop", name)
+		if l.While != "" {
+			w.condition(append(path, "while"), l.While, executed)
+		}
+		if len(l.Requests) == 0 {
+			w.errorf(append(path, "requests"), "loop has no requests")
+		}
+		w.steps(append(path, "requests"), l.Requests, executed)
+		if l.Until != "" {
+			w.condition(append(path, "until"), l.Until, executed)
+		}
+		return true
+	}
+	for _, c := range w.scenario.Choices {
+		if c.Name != name {
+			continue
+		}
+		w.used[name] = true
+		path = append(path, "choice", name)
+		if len(c.Options) == 0 {
+			w.errorf(path, "choice has no options")
+		}
+		before := copyNames(executed)
+		var total int64
+		for i, o := range c.Options {
+			optionPath := append(path, "option", strconv.Itoa(i))
+			if o.weight() < 0 {
+				w.errorf(append(optionPath, "weight"), "negative weight %d", o.weight())
+			} else {
+				total += o.weight()
+			}
+			option := copyNames(before)
+			w.steps(append(optionPath, "requests"), o.Requests, option)
+			mergeNames(executed, option)
+		}
+		if len(c.Options) > 0 && total == 0 {
+			w.errorf(path, "choice has no options with positive weight")
+		}
+		return true
+	}
+	return false
+}
+
+func (w *scenarioWalker) condition(path []string, expr string, executed map[string]bool) {
+	cond, err := flow.NewCondition(expr, nil)
+	if err != nil {
+		w.errorf(path, "%v", err)
+		return
+	}
+	for _, ref := range treeRefs(path, cond.Template()) {
+		w.checkRef(ref)
+		w.checkOrder(ref, "", executed)
+	}
+}
+
+// checkOrder checks, that request variables are referenced after request is executed.
+// step is a name of request (call), that references variables, or empty for scenario controls.
+func (w *scenarioWalker) checkOrder(ref reference, step string, executed map[string]bool) {
+	if len(ref.segments) < 2 || segmentName(ref.segments[0]) != "request" {
+		return
+	}
+	name := segmentName(ref.segments[1])
+	_, isRequest := w.requests[name]
+	_, isCall := w.calls[name]
+	if executed[name] || !isRequest && !isCall {
+		return
+	}
+	if name == step && (ref.afterResponse || len(ref.segments) > 2 && segmentName(ref.segments[2]) == "preprocessor") {
+		return
+	}
+	w.warnf(ref.path, "request %s is referenced before it is executed in scenario %s", name, w.scenario.Name)
+}
+
+func (v *validator) errorf(path []string, format string, args ...any) {
+	v.report(Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) warnf(path []string, format string, args ...any) {
+	v.report(Problem{Path: path, Message: fmt.Sprintf(format, args...), Warning: true})
+}
+
+// report adds problem once, even if shared request or control is checked in several places.
+func (v *validator) report(p Problem) {
+	p.Path = append([]string(nil), p.Path...)
+	key := p.String()
+	if v.reported[key] {
+		return
+	}
+	v.reported[key] = true
+	v.problems = append(v.problems, p)
+}
+
+// missingField walks variable source value by path segments starting with source name, and returns the first missing field.
+// Rows of lists are checked by the first row, then sampled is true.
+// Walk stops at values, which structure is unknown before shooting, e.g. streams.
+func missingField(value any, segments []string) (field string, sampled bool) {
+	for i, segment := range segments {
+		name, indexed := splitSegment(segment)
+		if i > 0 {
+			var ok bool
+			switch m := value.(type) {
+			case map[string]any:
+				value, ok = m[name]
+			case map[string]string:
+				value, ok = m[name]
+			default:
+				return "", false
+			}
+			if !ok {
+				return name, sampled
+			}
+		}
+		if !indexed {
+			continue
+		}
+		switch list := value.(type) {
+		case []map[string]string:
+			if len(list) == 0 {
+				return "", false
+			}
+			value = list[0]
+		case []map[string]any:
+			if len(list) == 0 {
+				return "", false
+			}
+			value = list[0]
+		case []any:
+			if len(list) == 0 {
+				return "", false
+			}
+			value = list[0]
+		default:
+			return "", false
+		}
+		sampled = true
+	}
+	return "", false
+}
+
+func splitVariablePath(path string) []string {
+	segments := strings.Split(strings.TrimPrefix(strings.TrimSpace(path), "."), ".")
+	for i := range segments {
+		segments[i] = strings.TrimSpace(segments[i])
+	}
+	return segments
+}
+
+// splitSegment splits variable path segment into name and index presence, e.g. users[next] into users and true.
+func splitSegment(segment string) (name string, indexed bool) {
+	if i := strings.Index(segment, "["); i >= 0 && strings.HasSuffix(segment, "]") {
+		return segment[:i], true
+	}
+	return segment, false
+}
+
+func segmentName(segment string) string {
+	name, _ := splitSegment(segment)
+	return name
+}
+
+func variableName(segments []string) string {
+	return "." + strings.Join(segments, ".")
+}
+
+func copyNames(names map[string]bool) map[string]bool {
+	result := make(map[string]bool, len(names))
+	mergeNames(result, names)
+	return result
+}
+
+func mergeNames(to, from map[string]bool) {
+	for k := range from {
+		to[k] = true
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
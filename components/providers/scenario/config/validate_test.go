@@ -0,0 +1,262 @@
+package config
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	grpcgun "github.com/yandex/pandora/components/guns/grpc/scenario"
+	httpscenario "github.com/yandex/pandora/components/guns/http_scenario"
+	grpcpreprocessor "github.com/yandex/pandora/components/providers/scenario/grpc/preprocessor"
+	"github.com/yandex/pandora/components/providers/scenario/http/postprocessor"
+	"github.com/yandex/pandora/components/providers/scenario/http/preprocessor"
+	"github.com/yandex/pandora/components/providers/scenario/vs"
+)
+
+// customPostprocessor sets variables, that are unknown before shooting.
+type customPostprocessor struct{}
+
+func (customPostprocessor) Process(*http.Response, io.Reader) (map[string]any, error) {
+	return map[string]any{"custom": true}, nil
+}
+
+func TestValidateAmmoConfig(t *testing.T) {
+	newConfig := func() *AmmoConfig {
+		body := "{{ .request.auth.preprocessor.user }}"
+		return &AmmoConfig{
+			VariableSources: []vs.VariableSource{
+				&mockVS{name: "users"},
+				&mockVS{name: "global"},
+			},
+			Requests: []RequestConfig{
+				{
+					Name:         "auth",
+					URI:          "/auth",
+					Body:         &body,
+					Preprocessor: &preprocessor.Preprocessor{Mapping: map[string]string{"user": "source.users[next].name"}},
+					Postprocessors: []httpscenario.Postprocessor{
+						&postprocessor.VarJsonpathPostprocessor{Mapping: map[string]string{"token": "$.token"}},
+						&postprocessor.AssertResponse{},
+					},
+					Session: map[string]string{"token": "request.auth.postprocessor.token"},
+				},
+				{
+					Name:    "list",
+					URI:     "/list?host={{ .source.global.host }}",
+					Headers: map[string]string{"Authorization": "Bearer {{ .session.token }}"},
+				},
+			},
+			Scenarios: []ScenarioConfig{{
+				Name:     "main",
+				Requests: []string{"auth", "sleep(10)", "poll(2)"},
+				Loops: []LoopConfig{{
+					Name:     "poll",
+					Requests: []string{"list"},
+					Until:    "eq .request.list.status 200",
+				}},
+			}},
+		}
+	}
+	storage := vs.NewVariableStorage()
+	storage.AddSource("users", []map[string]string{{"name": "alice"}})
+	storage.AddSource("global", map[string]any{"host": "example.com"})
+
+	t.Run("valid", func(t *testing.T) {
+		assert.Empty(t, ValidateAmmoConfig(newConfig(), storage))
+		assert.Empty(t, ValidateAmmoConfig(newConfig(), nil))
+	})
+
+	tests := []struct {
+		name   string
+		modify func(cfg *AmmoConfig)
+		want   Problem
+	}{
+		{
+			name:   "unknown request in scenario",
+			modify: func(cfg *AmmoConfig) { cfg.Scenarios[0].Loops[0].Requests = []string{"list", "lst"} },
+			want:   Problem{Path: []string{"scenario", "main", "loop", "poll", "requests"}, Message: "unknown request lst"},
+		},
+		{
+			name:   "sleep first",
+			modify: func(cfg *AmmoConfig) { cfg.Scenarios[0].Requests = []string{"sleep(10)", "auth", "poll"} },
+			want:   Problem{Path: []string{"scenario", "main", "requests"}, Message: "sleep must follow a request"},
+		},
+		{
+			name:   "recursion",
+			modify: func(cfg *AmmoConfig) { cfg.Scenarios[0].Loops[0].Requests = []string{"list", "poll"} },
+			want:   Problem{Path: []string{"scenario", "main", "loop", "poll", "requests"}, Message: "step poll recursively references itself"},
+		},
+		{
+			name:   "invalid condition",
+			modify: func(cfg *AmmoConfig) { cfg.Scenarios[0].Loops[0].Until = "{{ eq .request.list.status 200" },
+			want: Problem{Path: []string{"scenario", "main", "loop", "poll", "until"},
+				Message: "condition `{{ eq .request.list.status 200` parse: template: condition:1: unclosed action"},
+		},
+		{
+			name:   "invalid template",
+			modify: func(cfg *AmmoConfig) { cfg.Requests[1].URI = "/list?{{ .source.global.host" },
+			want:   Problem{Path: []string{"request", "list", "uri"}, Message: "invalid template: template: uri:1: unclosed action"},
+		},
+		{
+			name:   "unknown template function",
+			modify: func(cfg *AmmoConfig) { cfg.Requests[1].URI = "/list?{{ randomInt }}" },
+			want: Problem{Path: []string{"request", "list", "uri"},
+				Message: `invalid template: template: uri:1: function "randomInt" not defined`},
+		},
+		{
+			name:   "unknown variable source",
+			modify: func(cfg *AmmoConfig) { cfg.Requests[1].URI = "/list?host={{ .source.globals.host }}" },
+			want:   Problem{Path: []string{"request", "list", "uri"}, Message: "unknown variable source globals in variable .source.globals.host"},
+		},
+		{
+			name:   "unknown variable source field",
+			modify: func(cfg *AmmoConfig) { cfg.Requests[1].URI = "/list?host={{ .source.global.hots }}" },
+			want:   Problem{Path: []string{"request", "list", "uri"}, Message: "variable source global has no field hots"},
+		},
+		{
+			name:   "unknown variable source row field",
+			modify: func(cfg *AmmoConfig) { cfg.Requests[0].Preprocessor.Mapping["user"] = "source.users[next].login" },
+			want: Problem{Path: []string{"request", "auth", "preprocessor", "mapping"},
+				Message: "first row of variable source users has no field login", Warning: true},
+		},
+		{
+			name:   "unknown request variable",
+			modify: func(cfg *AmmoConfig) { cfg.Scenarios[0].Loops[0].Until = "eq .request.lst.status 200" },
+			want: Problem{Path: []string{"scenario", "main", "loop", "poll", "until"},
+				Message: "unknown request lst in variable .request.lst.status"},
+		},
+		{
+			name:   "unknown postprocessor variable",
+			modify: func(cfg *AmmoConfig) { cfg.Requests[0].Session["token"] = "request.auth.postprocessor.tokn" },
+			want: Problem{Path: []string{"request", "auth", "session"},
+				Message: "request auth postprocessor does not set variable tokn"},
+		},
+		{
+			name:   "unknown preprocessor variable",
+			modify: func(cfg *AmmoConfig) { *cfg.Requests[0].Body = "{{ .request.auth.preprocessor.usr }}" },
+			want:   Problem{Path: []string{"request", "auth", "body"}, Message: "request auth preprocessor does not set variable usr"},
+		},
+		{
+			name:   "request referenced before execution",
+			modify: func(cfg *AmmoConfig) { cfg.Requests[0].URI = "/auth?{{ .request.list.status }}" },
+			want: Problem{Path: []string{"request", "auth", "uri"},
+				Message: "request list is referenced before it is executed in scenario main", Warning: true},
+		},
+		{
+			name:   "unknown session variable",
+			modify: func(cfg *AmmoConfig) { cfg.Requests[1].Headers["Authorization"] = "Bearer {{ .session.tokn }}" },
+			want: Problem{Path: []string{"request", "list", "headers"},
+				Message: "session variable tokn is not set by requests", Warning: true},
+		},
+		{
+			name:   "unused request",
+			modify: func(cfg *AmmoConfig) { cfg.Requests = append(cfg.Requests, RequestConfig{Name: "logout"}) },
+			want:   Problem{Path: []string{"request", "logout"}, Message: "request logout is not used in scenarios", Warning: true},
+		},
+		{
+			name:   "duplicate request",
+			modify: func(cfg *AmmoConfig) { cfg.Requests = append(cfg.Requests, RequestConfig{Name: "list"}) },
+			want:   Problem{Path: []string{"request", "list"}, Message: "duplicate request list"},
+		},
+		{
+			name:   "negative weight",
+			modify: func(cfg *AmmoConfig) { cfg.Scenarios[0].Weight = -1 },
+			want:   Problem{Path: []string{"scenario", "main", "weight"}, Message: "negative weight -1"},
+		},
+		{
+			name: "negative option weight",
+			modify: func(cfg *AmmoConfig) {
+				negative := int64(-1)
+				cfg.Scenarios[0].Requests = []string{"auth", "browse"}
+				cfg.Scenarios[0].Loops = nil
+				cfg.Scenarios[0].Choices = []ChoiceConfig{{Name: "browse", Options: []ChoiceOptionConfig{
+					{Requests: []string{"list"}},
+					{Weight: &negative, Requests: []string{"list"}},
+				}}}
+			},
+			want: Problem{Path: []string{"scenario", "main", "choice", "browse", "option", "1", "weight"}, Message: "negative weight -1"},
+		},
+		{
+			name: "zero option weights",
+			modify: func(cfg *AmmoConfig) {
+				zero := int64(0)
+				cfg.Scenarios[0].Requests = []string{"auth", "browse"}
+				cfg.Scenarios[0].Loops = nil
+				cfg.Scenarios[0].Choices = []ChoiceConfig{{Name: "browse", Options: []ChoiceOptionConfig{
+					{Weight: &zero, Requests: []string{"list"}},
+				}}}
+			},
+			want: Problem{Path: []string{"scenario", "main", "choice", "browse"}, Message: "choice has no options with positive weight"},
+		},
+		{
+			name:   "negative retry attempts",
+			modify: func(cfg *AmmoConfig) { cfg.Requests[1].Retry = &RetryConfig{Attempts: -1, Backoff: 100} },
+			want:   Problem{Path: []string{"request", "list", "retry", "attempts"}, Message: "attempts -1 should be from 0 to 100"},
+		},
+		{
+			name: "too long retry backoff",
+			modify: func(cfg *AmmoConfig) {
+				cfg.Requests[1].Retry = &RetryConfig{Attempts: 3, Backoff: 1000, MaxBackoff: 1 << 40}
+			},
+			want: Problem{Path: []string{"request", "list", "retry", "max_backoff"},
+				Message: "max_backoff 1099511627776 should be from 0 to 3600000 ms"},
+		},
+		{
+			name:   "unused control",
+			modify: func(cfg *AmmoConfig) { cfg.Scenarios[0].Requests = []string{"auth", "list"} },
+			want:   Problem{Path: []string{"scenario", "main", "loop", "poll"}, Message: "loop poll is not used in scenario", Warning: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newConfig()
+			tt.modify(cfg)
+			problems := ValidateAmmoConfig(cfg, storage)
+			assert.Equal(t, Problems{tt.want}, problems)
+			if tt.want.Warning {
+				assert.NoError(t, problems.Err())
+			} else {
+				assert.Error(t, problems.Err())
+			}
+		})
+	}
+
+	t.Run("branches", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.Requests = append(cfg.Requests, RequestConfig{Name: "create"}, RequestConfig{Name: "update", URI: "/{{ .request.create.status }}"})
+		cfg.Scenarios[0].Requests = []string{"auth", "save", "poll"}
+		cfg.Scenarios[0].Conditions = []ConditionConfig{{Name: "save", If: "true", Then: []string{"create"}, Else: []string{"update"}}}
+		cfg.Scenarios[0].Loops[0].Until = "eq .request.create.status 200"
+		problems := ValidateAmmoConfig(cfg, storage)
+		assert.Equal(t, Problems{{
+			Path:    []string{"request", "update", "uri"},
+			Message: "request create is referenced before it is executed in scenario main",
+			Warning: true,
+		}}, problems)
+	})
+
+	t.Run("custom postprocessor", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.Requests[0].Postprocessors = append(cfg.Requests[0].Postprocessors, customPostprocessor{})
+		cfg.Requests[0].Session["token"] = "request.auth.postprocessor.custom"
+		assert.Empty(t, ValidateAmmoConfig(cfg, storage))
+	})
+
+	t.Run("grpc calls", func(t *testing.T) {
+		cfg := &AmmoConfig{
+			Calls: []CallConfig{
+				{Name: "auth", Payload: `{"user": "{{ .request.auth.preprocessor.user }}"}`, Preprocessors: []grpcgun.Preprocessor{
+					&grpcpreprocessor.PreparePreprocessor{Mapping: map[string]string{"user": "source.users[0].name"}},
+				}},
+				{Name: "list", Metadata: map[string]string{"token": "{{ .request.auth.postprocessor.token }}"}},
+			},
+			Scenarios: []ScenarioConfig{{Name: "main", Requests: []string{"auth", "list", "fail"}}},
+		}
+		problems := ValidateAmmoConfig(cfg, nil)
+		require.Len(t, problems, 2)
+		assert.Equal(t, "call.auth.preprocessor.prepare.mapping: unknown variable source users in variable .source.users[0].name", problems[0].String())
+		assert.Equal(t, "scenario.main.requests: unknown request fail", problems[1].String())
+	})
+}
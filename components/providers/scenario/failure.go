@@ -0,0 +1,127 @@
+package scenario
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// OnError is an action performed by scenario gun after a step failure.
+type OnError string
+
+const (
+	// OnErrorContinue continues scenario iteration with the next step.
+	OnErrorContinue OnError = "continue"
+	// OnErrorStop stops current scenario iteration. Default.
+	OnErrorStop OnError = "stop"
+	// OnErrorAbort stops shooting after FailurePolicy.AbortAfter failures of scenario.
+	OnErrorAbort OnError = "abort"
+)
+
+func ParseOnError(s string) (OnError, error) {
+	switch o := OnError(s); o {
+	case "":
+		return "", nil
+	case OnErrorContinue, OnErrorStop, OnErrorAbort:
+		return o, nil
+	}
+	return "", fmt.Errorf("invalid on_error %q: should be one of %s, %s, %s", s, OnErrorContinue, OnErrorStop, OnErrorAbort)
+}
+
+// FailurePolicy decides, what scenario gun does after a step failure.
+// Policy is shared between all clones of scenario ammo, so failures are counted for the whole test.
+type FailurePolicy struct {
+	OnError    OnError
+	AbortAfter int64
+	failures   *atomic.Int64
+}
+
+func NewFailurePolicy(onError OnError, abortAfter int64) *FailurePolicy {
+	if onError == "" {
+		onError = OnErrorStop
+	}
+	if abortAfter <= 0 {
+		abortAfter = 1
+	}
+	return &FailurePolicy{OnError: onError, AbortAfter: abortAfter, failures: &atomic.Int64{}}
+}
+
+// Handle returns error, that scenario gun should return after step failure err.
+// It is nil for continue action, err for stop action and *AbortError for abort action.
+// Step on_error overrides scenario on_error.
+func (p *FailurePolicy) Handle(scenarioName string, stepOnError OnError, err error) error {
+	switch p.action(stepOnError) {
+	case OnErrorContinue:
+		return nil
+	case OnErrorAbort:
+		return &AbortError{Scenario: scenarioName, Err: err}
+	default:
+		return err
+	}
+}
+
+// action returns action for failed step.
+// Abort action is returned only when scenario has failed AbortAfter times, stop is returned before that.
+func (p *FailurePolicy) action(stepOnError OnError) OnError {
+	action := stepOnError
+	if action == "" {
+		if p == nil {
+			return OnErrorStop
+		}
+		action = p.OnError
+	}
+	if action != OnErrorAbort {
+		return action
+	}
+	if p == nil || p.failures == nil {
+		return OnErrorAbort
+	}
+	if p.failures.Add(1) < p.AbortAfter {
+		return OnErrorStop
+	}
+	return OnErrorAbort
+}
+
+// AbortError is returned by scenario gun, when shooting should be aborted.
+// Gun should pass it to core.GunDeps.Abort, to cancel shooting for all instances.
+type AbortError struct {
+	Scenario string
+	Err      error
+}
+
+func (e *AbortError) Error() string {
+	return fmt.Sprintf("scenario %s aborted shooting: %s", e.Scenario, e.Err)
+}
+
+func (e *AbortError) Unwrap() error {
+	return e.Err
+}
+
+// DefaultMaxBackoff limits delay between attempts, if Retry.MaxBackoff is not set.
+const DefaultMaxBackoff = time.Minute
+
+// Retry describes retries of failed step. Delay between attempts starts from Backoff
+// and is doubled after each attempt, but not more than MaxBackoff or DefaultMaxBackoff, if it is not set.
+type Retry struct {
+	Attempts   int
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// Delay returns delay before retry attempt. Attempts are counted from 1.
+func (r Retry) Delay(attempt int) time.Duration {
+	limit := r.MaxBackoff
+	if limit <= 0 {
+		limit = DefaultMaxBackoff
+	}
+	d := r.Backoff
+	for i := 1; i < attempt && d < limit; i++ {
+		d *= 2
+	}
+	return min(d, limit)
+}
+
+// RetryTag is an additional sample tag of retry attempt.
+func RetryTag(attempt int) string {
+	return fmt.Sprintf("retry_%d", attempt)
+}
@@ -0,0 +1,72 @@
+package scenario
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailurePolicy_Handle(t *testing.T) {
+	stepErr := errors.New("step failed")
+
+	t.Run("default stop", func(t *testing.T) {
+		p := NewFailurePolicy("", 0)
+		assert.Equal(t, stepErr, p.Handle("sc", "", stepErr))
+		assert.NoError(t, p.Handle("sc", OnErrorContinue, stepErr))
+	})
+
+	t.Run("continue", func(t *testing.T) {
+		p := NewFailurePolicy(OnErrorContinue, 0)
+		assert.NoError(t, p.Handle("sc", "", stepErr))
+		assert.Equal(t, stepErr, p.Handle("sc", OnErrorStop, stepErr))
+	})
+
+	t.Run("abort after", func(t *testing.T) {
+		p := NewFailurePolicy(OnErrorAbort, 3)
+		clone := *p
+		assert.Equal(t, stepErr, p.Handle("sc", "", stepErr))
+		assert.Equal(t, stepErr, clone.Handle("sc", "", stepErr))
+		err := p.Handle("sc", "", stepErr)
+		var abortErr *AbortError
+		require.ErrorAs(t, err, &abortErr)
+		assert.Equal(t, "sc", abortErr.Scenario)
+		assert.ErrorIs(t, err, stepErr)
+	})
+
+	t.Run("nil policy", func(t *testing.T) {
+		var p *FailurePolicy
+		assert.Equal(t, stepErr, p.Handle("sc", "", stepErr))
+	})
+}
+
+func TestParseOnError(t *testing.T) {
+	for _, s := range []string{"", "continue", "stop", "abort"} {
+		o, err := ParseOnError(s)
+		require.NoError(t, err)
+		assert.Equal(t, OnError(s), o)
+	}
+	_, err := ParseOnError("retry")
+	require.Error(t, err)
+}
+
+func TestRetry_Delay(t *testing.T) {
+	r := Retry{Attempts: 5, Backoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}
+	var got []time.Duration
+	for i := 1; i <= r.Attempts; i++ {
+		got = append(got, r.Delay(i))
+	}
+	assert.Equal(t, []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		300 * time.Millisecond,
+		300 * time.Millisecond,
+		300 * time.Millisecond,
+	}, got)
+
+	r = Retry{Attempts: 100, Backoff: time.Second}
+	assert.Equal(t, 2*time.Second, r.Delay(2))
+	assert.Equal(t, DefaultMaxBackoff, r.Delay(100))
+}
@@ -0,0 +1,61 @@
+package flow
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/yandex/pandora/components/providers/scenario/templater"
+)
+
+// Condition is a boolean expression written in Go template syntax and evaluated over scenario variables.
+// Expression may be passed with or without braces: "{{ eq .request.auth.status 200 }}"
+// and "eq .request.auth.status 200" are equal.
+type Condition struct {
+	expr string
+	tmpl *template.Template
+}
+
+// NewCondition parses condition expression with template functions funcs, e.g. returned by templater.Funcs
+// with provider sequences. templater.GetFuncs is used, if funcs is nil.
+func NewCondition(expr string, funcs template.FuncMap) (*Condition, error) {
+	body := strings.TrimSpace(expr)
+	if body == "" {
+		return nil, fmt.Errorf("empty condition")
+	}
+	if !strings.Contains(body, "{{") {
+		body = "{{ " + body + " }}"
+	}
+	if funcs == nil {
+		funcs = templater.GetFuncs()
+	}
+	tmpl, err := template.New("condition").Funcs(funcs).Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("condition `%s` parse: %w", expr, err)
+	}
+	return &Condition{expr: expr, tmpl: tmpl}, nil
+}
+
+// Eval executes condition template. Result must be one of the values accepted by strconv.ParseBool.
+func (c *Condition) Eval(vars map[string]any) (bool, error) {
+	var sb strings.Builder
+	err := c.tmpl.Execute(&sb, vars)
+	if err != nil {
+		return false, fmt.Errorf("condition `%s` execute: %w", c.expr, err)
+	}
+	result, err := strconv.ParseBool(strings.TrimSpace(sb.String()))
+	if err != nil {
+		return false, fmt.Errorf("condition `%s` result `%s` is not boolean", c.expr, sb.String())
+	}
+	return result, nil
+}
+
+// Template returns parsed condition template, e.g. to inspect variables it references.
+func (c *Condition) Template() *template.Template {
+	return c.tmpl
+}
+
+func (c *Condition) String() string {
+	return c.expr
+}
@@ -0,0 +1,150 @@
+// Package flow implements control constructs of scenario: conditional branches, loops and weighted choices.
+// Constructs are generic over scenario step type, so HTTP and gRPC scenario guns share them.
+package flow
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+)
+
+type Type int
+
+const (
+	TypeIf Type = iota
+	TypeLoop
+	TypeChoice
+)
+
+const DefaultMaxIterations = 10
+
+// ErrMaxIterations is returned by loop with while or until condition, that has run MaxIterations times,
+// but condition has not finished it. Loop without conditions just runs MaxIterations times.
+var ErrMaxIterations = errors.New("max iterations reached")
+
+// Control is scenario step that makes no request by itself, but chooses which nested steps to run.
+type Control[S any] struct {
+	Type Type
+	Name string
+
+	// TypeIf
+	Condition *Condition
+	Then      []S
+	Else      []S
+
+	// TypeLoop. While is checked before each iteration, Until after each iteration.
+	Body          []S
+	While         *Condition
+	Until         *Condition
+	MaxIterations int
+
+	// TypeChoice
+	Options []Option[S]
+}
+
+// Option is a step of choice. Option with zero weight is never chosen.
+type Option[S any] struct {
+	Weight int64
+	Steps  []S
+}
+
+func NewIf[S any](name string, condition *Condition, then, els []S) *Control[S] {
+	return &Control[S]{Type: TypeIf, Name: name, Condition: condition, Then: then, Else: els}
+}
+
+func NewLoop[S any](name string, body []S, while, until *Condition, maxIterations int) *Control[S] {
+	if maxIterations <= 0 {
+		maxIterations = DefaultMaxIterations
+	}
+	return &Control[S]{Type: TypeLoop, Name: name, Body: body, While: while, Until: until, MaxIterations: maxIterations}
+}
+
+func NewChoice[S any](name string, options []Option[S]) (*Control[S], error) {
+	if len(options) == 0 {
+		return nil, fmt.Errorf("choice %s has no options", name)
+	}
+	var total int64
+	for i := range options {
+		if options[i].Weight < 0 {
+			return nil, fmt.Errorf("choice %s option %d has negative weight", name, i)
+		}
+		total += options[i].Weight
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("choice %s has no options with positive weight", name)
+	}
+	return &Control[S]{Type: TypeChoice, Name: name, Options: options}, nil
+}
+
+// Exec evaluates control over vars and calls run for chosen steps.
+// vars are evaluated on each check, so run can change them.
+func (c *Control[S]) Exec(vars map[string]any, run func(steps []S) error) error {
+	switch c.Type {
+	case TypeIf:
+		ok, err := c.Condition.Eval(vars)
+		if err != nil {
+			return fmt.Errorf("if %s: %w", c.Name, err)
+		}
+		if ok {
+			return run(c.Then)
+		}
+		return run(c.Else)
+	case TypeLoop:
+		for i := 0; i < c.MaxIterations; i++ {
+			if c.While != nil {
+				ok, err := c.While.Eval(vars)
+				if err != nil {
+					return fmt.Errorf("loop %s: %w", c.Name, err)
+				}
+				if !ok {
+					return nil
+				}
+			}
+			err := run(c.Body)
+			if err != nil {
+				return err
+			}
+			if c.Until != nil {
+				ok, err := c.Until.Eval(vars)
+				if err != nil {
+					return fmt.Errorf("loop %s: %w", c.Name, err)
+				}
+				if ok {
+					return nil
+				}
+			}
+		}
+		if c.While == nil && c.Until == nil {
+			return nil
+		}
+		if c.While != nil {
+			// Last iteration may have finished the loop.
+			ok, err := c.While.Eval(vars)
+			if err != nil {
+				return fmt.Errorf("loop %s: %w", c.Name, err)
+			}
+			if !ok {
+				return nil
+			}
+		}
+		return fmt.Errorf("loop %s: %w (%d)", c.Name, ErrMaxIterations, c.MaxIterations)
+	case TypeChoice:
+		return run(c.choose().Steps)
+	}
+	return fmt.Errorf("unknown control type %d", c.Type)
+}
+
+func (c *Control[S]) choose() Option[S] {
+	var total int64
+	for _, o := range c.Options {
+		total += o.Weight
+	}
+	n := rand.Int63n(total)
+	for _, o := range c.Options {
+		if n < o.Weight {
+			return o
+		}
+		n -= o.Weight
+	}
+	return c.Options[len(c.Options)-1]
+}
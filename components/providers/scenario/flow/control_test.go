@@ -0,0 +1,159 @@
+package flow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yandex/pandora/components/providers/scenario/templater"
+)
+
+func TestCondition_Eval(t *testing.T) {
+	vars := map[string]any{
+		"request": map[string]any{
+			"auth": map[string]any{"status": 404, "postprocessor": map[string]any{"ok": true}},
+		},
+	}
+	tests := []struct {
+		name    string
+		expr    string
+		want    bool
+		wantErr bool
+	}{
+		{name: "without braces", expr: "eq .request.auth.status 404", want: true},
+		{name: "with braces", expr: "{{ ne .request.auth.status 404 }}", want: false},
+		{name: "bool variable", expr: ".request.auth.postprocessor.ok", want: true},
+		{name: "not boolean", expr: ".request.auth.status", wantErr: true},
+		{name: "missing variable", expr: ".request.auth.postprocessor.missing", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewCondition(tt.expr, nil)
+			require.NoError(t, err)
+			got, err := c.Eval(vars)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := NewCondition("{{ eq .a ", nil)
+	require.Error(t, err)
+	_, err = NewCondition(" ", nil)
+	require.Error(t, err)
+}
+
+func TestCondition_Funcs(t *testing.T) {
+	seqs := templater.NewSequences()
+	_, err := seqs.Seq("n")
+	require.NoError(t, err)
+
+	c, err := NewCondition(`eq (seq "n") "1"`, templater.Funcs(seqs))
+	require.NoError(t, err)
+	got, err := c.Eval(nil)
+	require.NoError(t, err)
+	assert.True(t, got, "condition must share provider seq counters")
+}
+
+func TestControl_Exec(t *testing.T) {
+	mustCondition := func(expr string) *Condition {
+		c, err := NewCondition(expr, nil)
+		require.NoError(t, err)
+		return c
+	}
+
+	t.Run("if", func(t *testing.T) {
+		c := NewIf("if", mustCondition("eq .x 1"), []string{"then"}, []string{"else"})
+		var got []string
+		run := func(steps []string) error {
+			got = append(got, steps...)
+			return nil
+		}
+		require.NoError(t, c.Exec(map[string]any{"x": 1}, run))
+		require.NoError(t, c.Exec(map[string]any{"x": 2}, run))
+		assert.Equal(t, []string{"then", "else"}, got)
+	})
+
+	t.Run("loop until", func(t *testing.T) {
+		vars := map[string]any{"n": 0}
+		c := NewLoop("loop", []string{"step"}, nil, mustCondition("eq .n 3"), 10)
+		err := c.Exec(vars, func(steps []string) error {
+			vars["n"] = vars["n"].(int) + 1
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 3, vars["n"])
+	})
+
+	t.Run("loop while", func(t *testing.T) {
+		vars := map[string]any{"n": 0}
+		c := NewLoop("loop", []string{"step"}, mustCondition("lt .n 2"), nil, 10)
+		err := c.Exec(vars, func(steps []string) error {
+			vars["n"] = vars["n"].(int) + 1
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2, vars["n"])
+	})
+
+	t.Run("loop while finished by last iteration", func(t *testing.T) {
+		vars := map[string]any{"n": 0}
+		c := NewLoop("loop", []string{"step"}, mustCondition("lt .n 2"), nil, 2)
+		err := c.Exec(vars, func(steps []string) error {
+			vars["n"] = vars["n"].(int) + 1
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2, vars["n"])
+	})
+
+	t.Run("loop condition max iterations", func(t *testing.T) {
+		n := 0
+		c := NewLoop("loop", []string{"step"}, nil, mustCondition("false"), 3)
+		err := c.Exec(nil, func(steps []string) error {
+			n++
+			return nil
+		})
+		require.ErrorIs(t, err, ErrMaxIterations)
+		assert.Equal(t, 3, n)
+	})
+
+	t.Run("loop max iterations", func(t *testing.T) {
+		n := 0
+		c := NewLoop("loop", []string{"step"}, nil, nil, 0)
+		err := c.Exec(nil, func(steps []string) error {
+			n++
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, DefaultMaxIterations, n)
+	})
+
+	t.Run("choice", func(t *testing.T) {
+		c, err := NewChoice("choice", []Option[string]{
+			{Weight: 1, Steps: []string{"a"}},
+			{Weight: 0, Steps: []string{"disabled"}},
+			{Weight: 3, Steps: []string{"b"}},
+		})
+		require.NoError(t, err)
+		got := map[string]int{}
+		for i := 0; i < 1000; i++ {
+			err = c.Exec(nil, func(steps []string) error {
+				got[steps[0]]++
+				return nil
+			})
+			require.NoError(t, err)
+		}
+		assert.InDelta(t, 250, got["a"], 75)
+		assert.InDelta(t, 750, got["b"], 75)
+		assert.Zero(t, got["disabled"])
+
+		_, err = NewChoice[string]("empty", nil)
+		require.Error(t, err)
+		_, err = NewChoice("zero", []Option[string]{{Weight: 0, Steps: []string{"a"}}})
+		require.Error(t, err)
+	})
+}
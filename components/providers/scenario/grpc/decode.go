@@ -5,7 +5,10 @@ import (
 	"time"
 
 	gun "github.com/yandex/pandora/components/guns/grpc/scenario"
+	"github.com/yandex/pandora/components/providers/scenario"
 	"github.com/yandex/pandora/components/providers/scenario/config"
+	"github.com/yandex/pandora/components/providers/scenario/flow"
+	"github.com/yandex/pandora/components/providers/scenario/templater"
 	"github.com/yandex/pandora/components/providers/scenario/vs"
 	"github.com/yandex/pandora/lib/mp"
 )
@@ -14,9 +17,16 @@ type IteratorIniter interface {
 	InitIterator(iter mp.Iterator)
 }
 
+type SequencesIniter interface {
+	InitSequences(seqs *templater.Sequences)
+}
+
 func decodeAmmo(cfg *config.AmmoConfig, storage *vs.SourceStorage) ([]*gun.Scenario, error) {
 	callRegistry := make(map[string]config.CallConfig, len(cfg.Calls))
 	for _, req := range cfg.Calls {
+		if _, err := scenario.ParseOnError(req.OnError); err != nil {
+			return nil, fmt.Errorf("call %s: %w", req.Name, err)
+		}
 		callRegistry[req.Name] = req
 	}
 
@@ -25,14 +35,16 @@ func decodeAmmo(cfg *config.AmmoConfig, storage *vs.SourceStorage) ([]*gun.Scena
 		scenarioRegistry[sc.Name] = sc
 	}
 
+	seqs := templater.NewSequences()
 	names, size := config.SpreadNames(cfg.Scenarios)
 	result := make([]*gun.Scenario, 0, size)
 	for _, sc := range cfg.Scenarios {
-		a, err := convertScenarioToAmmo(sc, callRegistry)
+		a, err := convertScenarioToAmmo(sc, callRegistry, seqs)
 		if err != nil {
 			return nil, fmt.Errorf("failed to convert scenario %s: %w", sc.Name, err)
 		}
 		a.VariableStorage = storage
+		a.Sequences = seqs
 		ns, ok := names[sc.Name]
 		if !ok {
 			return nil, fmt.Errorf("scenario %s is not found", sc.Name)
@@ -45,35 +57,43 @@ func decodeAmmo(cfg *config.AmmoConfig, storage *vs.SourceStorage) ([]*gun.Scena
 	return result, nil
 }
 
-func convertScenarioToAmmo(sc config.ScenarioConfig, reqs map[string]config.CallConfig) (*gun.Scenario, error) {
+func convertScenarioToAmmo(sc config.ScenarioConfig, reqs map[string]config.CallConfig, seqs *templater.Sequences) (*gun.Scenario, error) {
 	iter := mp.NewNextIterator(time.Now().UnixNano())
-	result := &gun.Scenario{Name: sc.Name, MinWaitingTime: time.Millisecond * time.Duration(sc.MinWaitingTime)}
-	for _, sh := range sc.Requests {
-		name, cnt, sleep, err := config.ParseShootName(sh)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse shoot %s: %w", sh, err)
-		}
-		if name == "sleep" {
-			result.Calls[len(result.Calls)-1].Sleep += time.Millisecond * time.Duration(cnt)
-			continue
-		}
-		req, ok := reqs[name]
-		if !ok {
-			return nil, fmt.Errorf("request %s not found", name)
-		}
-		r := convertConfigToStep(req, iter)
-		if sleep > 0 {
-			r.Sleep += time.Millisecond * time.Duration(sleep)
-		}
-		for i := 0; i < cnt; i++ {
-			result.Calls = append(result.Calls, r)
-		}
+	onError, err := scenario.ParseOnError(sc.OnError)
+	if err != nil {
+		return nil, err
+	}
+	result := &gun.Scenario{
+		Name:           sc.Name,
+		MinWaitingTime: time.Millisecond * time.Duration(sc.MinWaitingTime),
+		FailurePolicy:  scenario.NewFailurePolicy(onError, sc.AbortAfter),
+	}
+	builder := config.StepBuilder[gun.Call]{
+		Scenario: sc,
+		NewStep: func(name string) (gun.Call, bool) {
+			req, ok := reqs[name]
+			if !ok {
+				return gun.Call{}, false
+			}
+			return convertConfigToStep(req, iter, seqs), true
+		},
+		NewControl: func(control *flow.Control[gun.Call]) gun.Call {
+			return gun.Call{Name: control.Name, Control: control}
+		},
+		AddSleep: func(c *gun.Call, sleep time.Duration) {
+			c.Sleep += sleep
+		},
+		Funcs: templater.Funcs(seqs),
+	}
+	result.Calls, err = builder.Build(sc.Requests)
+	if err != nil {
+		return nil, err
 	}
 
 	return result, nil
 }
 
-func convertConfigToStep(req config.CallConfig, iter mp.Iterator) gun.Call {
+func convertConfigToStep(req config.CallConfig, iter mp.Iterator, seqs *templater.Sequences) gun.Call {
 	postprocessors := make([]gun.Postprocessor, len(req.Postprocessors))
 	copy(postprocessors, req.Postprocessors)
 	preprocessors := make([]gun.Preprocessor, len(req.Preprocessors))
@@ -82,6 +102,9 @@ func convertConfigToStep(req config.CallConfig, iter mp.Iterator) gun.Call {
 		if p, ok := preprocessors[i].(IteratorIniter); ok {
 			p.InitIterator(iter)
 		}
+		if p, ok := preprocessors[i].(SequencesIniter); ok {
+			p.InitSequences(seqs)
+		}
 	}
 	result := gun.Call{
 		Name:           req.Name,
@@ -91,6 +114,8 @@ func convertConfigToStep(req config.CallConfig, iter mp.Iterator) gun.Call {
 		Call:           req.Call,
 		Metadata:       req.Metadata,
 		Payload:        []byte(req.Payload),
+		OnError:        scenario.OnError(req.OnError),
+		Retry:          req.Retry.Retry(),
 	}
 
 	return result
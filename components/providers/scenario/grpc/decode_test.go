@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 	gun "github.com/yandex/pandora/components/guns/grpc/scenario"
+	"github.com/yandex/pandora/components/providers/scenario"
 	"github.com/yandex/pandora/components/providers/scenario/config"
 	"github.com/yandex/pandora/components/providers/scenario/grpc/postprocessor"
 	"github.com/yandex/pandora/components/providers/scenario/grpc/preprocessor"
@@ -104,6 +105,7 @@ func Test_decodeAmmo(t *testing.T) {
 					Name:            "sc1",
 					MinWaitingTime:  30 * time.Millisecond,
 					VariableStorage: storage,
+					FailurePolicy:   scenario.NewFailurePolicy("", 0),
 				},
 				{
 					Calls: []gun.Call{
@@ -141,6 +143,7 @@ func Test_decodeAmmo(t *testing.T) {
 					Name:            "sc2",
 					MinWaitingTime:  40 * time.Millisecond,
 					VariableStorage: storage,
+					FailurePolicy:   scenario.NewFailurePolicy("", 0),
 				},
 				{
 					Calls: []gun.Call{
@@ -178,6 +181,7 @@ func Test_decodeAmmo(t *testing.T) {
 					Name:            "sc2",
 					MinWaitingTime:  40 * time.Millisecond,
 					VariableStorage: storage,
+					FailurePolicy:   scenario.NewFailurePolicy("", 0),
 				},
 			},
 		},
@@ -189,12 +193,20 @@ func Test_decodeAmmo(t *testing.T) {
 				require.Error(t, err)
 				return
 			}
+			require.NotEmpty(t, got)
+			seqs := got[0].Sequences
+			require.NotNil(t, seqs)
 			for _, s := range got {
+				require.True(t, s.Sequences == nil || s.Sequences == seqs)
+				s.Sequences = nil
 				for _, c := range s.Calls {
 					for _, p := range c.Preprocessors {
 						if i, ok := p.(IteratorIniter); ok {
 							i.InitIterator(nil)
 						}
+						if i, ok := p.(SequencesIniter); ok {
+							i.InitSequences(nil)
+						}
 					}
 				}
 			}
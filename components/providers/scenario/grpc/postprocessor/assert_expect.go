@@ -0,0 +1,38 @@
+package postprocessor
+
+import (
+	"github.com/golang/protobuf/proto"
+	grpcgun "github.com/yandex/pandora/components/guns/grpc/scenario"
+	"github.com/yandex/pandora/components/providers/scenario"
+)
+
+// AssertExpectPostprocessor checks status code, response time and response message as JSON.
+// Failed check returns *scenario.AssertError.
+type AssertExpectPostprocessor struct {
+	assertion *scenario.Assertion
+}
+
+func NewAssertExpectPostprocessor(cfg scenario.AssertConfig) (*AssertExpectPostprocessor, error) {
+	assertion, err := scenario.NewAssertion(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &AssertExpectPostprocessor{assertion: assertion}, nil
+}
+
+func (p *AssertExpectPostprocessor) Process(out proto.Message, code int) (map[string]any, error) {
+	return p.ProcessResponse(&grpcgun.Response{Out: out, Code: code})
+}
+
+func (p *AssertExpectPostprocessor) ProcessResponse(resp *grpcgun.Response) (map[string]any, error) {
+	assertResp := scenario.AssertResponse{
+		Code:         resp.Code,
+		ResponseTime: resp.ResponseTime,
+	}
+	if resp.Out != nil {
+		assertResp.Body = func() ([]byte, error) {
+			return marshalJSON(resp.Out)
+		}
+	}
+	return nil, p.assertion.Check(assertResp)
+}
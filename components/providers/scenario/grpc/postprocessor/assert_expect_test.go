@@ -0,0 +1,32 @@
+package postprocessor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	grpcgun "github.com/yandex/pandora/components/guns/grpc/scenario"
+	"github.com/yandex/pandora/components/providers/scenario"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestAssertExpectPostprocessor_ProcessResponse(t *testing.T) {
+	p, err := NewAssertExpectPostprocessor(scenario.AssertConfig{
+		Status:       []string{"2xx"},
+		ResponseTime: time.Second,
+		JSONPath:     []scenario.JSONPathAssert{{Path: "$.count", Op: "gt", Value: 1}},
+	})
+	require.NoError(t, err)
+	out, err := structpb.NewStruct(map[string]any{"count": 2})
+	require.NoError(t, err)
+
+	_, err = p.ProcessResponse(&grpcgun.Response{Out: out, Code: 200, ResponseTime: time.Millisecond})
+	assert.NoError(t, err)
+	_, err = p.ProcessResponse(&grpcgun.Response{Out: out, Code: 200, ResponseTime: 2 * time.Second})
+	assert.True(t, scenario.IsAssertError(err))
+	_, err = p.ProcessResponse(&grpcgun.Response{Out: out, Code: 503, ResponseTime: time.Millisecond})
+	assert.True(t, scenario.IsAssertError(err))
+	_, err = p.ProcessResponse(&grpcgun.Response{Code: 200, ResponseTime: time.Millisecond})
+	assert.True(t, scenario.IsAssertError(err))
+}
@@ -1,6 +1,9 @@
 package postprocessor
 
 import (
+	"encoding/json"
+
+	"github.com/golang/protobuf/jsonpb"
 	"github.com/golang/protobuf/proto"
 )
 
@@ -11,3 +14,13 @@ type Config struct {
 type Postprocessor interface {
 	Process(out proto.Message, code int) (map[string]any, error)
 }
+
+// marshalJSON marshals dynamic messages returned by gun with their own marshaler,
+// and generated messages with jsonpb.
+func marshalJSON(out proto.Message) ([]byte, error) {
+	if m, ok := out.(json.Marshaler); ok {
+		return m.MarshalJSON()
+	}
+	s, err := (&jsonpb.Marshaler{}).MarshalToString(out)
+	return []byte(s), err
+}
@@ -0,0 +1,52 @@
+package postprocessor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/PaesslerAG/jsonpath"
+	"github.com/golang/protobuf/proto"
+	multierr "github.com/hashicorp/go-multierror"
+)
+
+// VarJsonpathPostprocessor saves values got by jsonpath from response message converted to JSON.
+type VarJsonpathPostprocessor struct {
+	Mapping map[string]string
+}
+
+func (p *VarJsonpathPostprocessor) ReturnedParams() []string {
+	result := make([]string, 0, len(p.Mapping))
+	for k := range p.Mapping {
+		result = append(result, k)
+	}
+	return result
+}
+
+func (p *VarJsonpathPostprocessor) Process(out proto.Message, _ int) (map[string]any, error) {
+	if len(p.Mapping) == 0 {
+		return nil, nil
+	}
+	if out == nil {
+		return nil, errors.New("response is nil")
+	}
+	b, err := marshalJSON(out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal response: %w", err)
+	}
+	var data any
+	err = json.Unmarshal(b, &data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal json: %w", err)
+	}
+	result := map[string]any{}
+	for k, path := range p.Mapping {
+		val, e := jsonpath.Get(path, data)
+		if e != nil {
+			err = multierr.Append(err, fmt.Errorf("failed to get value by jsonpath %s: %w", path, e))
+			continue
+		}
+		result[k] = val
+	}
+	return result, err
+}
@@ -0,0 +1,30 @@
+package postprocessor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestVarJsonpathPostprocessor_Process(t *testing.T) {
+	out, err := structpb.NewStruct(map[string]any{
+		"token": "abc",
+		"items": []any{map[string]any{"id": 1}, map[string]any{"id": 2}},
+	})
+	require.NoError(t, err)
+	p := &VarJsonpathPostprocessor{Mapping: map[string]string{"token": "$.token", "second": "$.items[1].id"}}
+
+	got, err := p.Process(out, 200)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"token": "abc", "second": float64(2)}, got)
+
+	p.Mapping["missing"] = "$.missing"
+	got, err = p.Process(out, 200)
+	assert.Error(t, err)
+	assert.Equal(t, "abc", got["token"])
+
+	_, err = p.Process(nil, 404)
+	assert.Error(t, err)
+}
@@ -0,0 +1,42 @@
+package postprocessor
+
+import (
+	"github.com/golang/protobuf/proto"
+	grpcgun "github.com/yandex/pandora/components/guns/grpc/scenario"
+)
+
+// VarMetadataPostprocessor saves values of response metadata to variables.
+// Mapping values are metadata keys, they are searched in headers, and then in trailers.
+// Keys absent in response are skipped. Only the first value of key is saved.
+type VarMetadataPostprocessor struct {
+	Mapping map[string]string
+}
+
+func (p *VarMetadataPostprocessor) ReturnedParams() []string {
+	result := make([]string, 0, len(p.Mapping))
+	for k := range p.Mapping {
+		result = append(result, k)
+	}
+	return result
+}
+
+func (p *VarMetadataPostprocessor) Process(out proto.Message, code int) (map[string]any, error) {
+	return p.ProcessResponse(&grpcgun.Response{Out: out, Code: code})
+}
+
+func (p *VarMetadataPostprocessor) ProcessResponse(resp *grpcgun.Response) (map[string]any, error) {
+	if len(p.Mapping) == 0 {
+		return nil, nil
+	}
+	result := make(map[string]any, len(p.Mapping))
+	for k, key := range p.Mapping {
+		vals := resp.Header.Get(key)
+		if len(vals) == 0 {
+			vals = resp.Trailer.Get(key)
+		}
+		if len(vals) > 0 {
+			result[k] = vals[0]
+		}
+	}
+	return result, nil
+}
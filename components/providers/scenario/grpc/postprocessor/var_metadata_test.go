@@ -0,0 +1,28 @@
+package postprocessor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	grpcgun "github.com/yandex/pandora/components/guns/grpc/scenario"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestVarMetadataPostprocessor_ProcessResponse(t *testing.T) {
+	p := &VarMetadataPostprocessor{Mapping: map[string]string{
+		"session": "x-session",
+		"cost":    "x-cost",
+		"absent":  "x-absent",
+	}}
+	got, err := p.ProcessResponse(&grpcgun.Response{
+		Header:  metadata.Pairs("x-session", "s1", "x-session", "s2"),
+		Trailer: metadata.Pairs("X-Cost", "10", "x-session", "t1"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"session": "s1", "cost": "10"}, got)
+
+	got, err = p.Process(nil, 200)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
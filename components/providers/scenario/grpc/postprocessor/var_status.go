@@ -0,0 +1,72 @@
+package postprocessor
+
+import (
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	grpcgun "github.com/yandex/pandora/components/guns/grpc/scenario"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	StatusCode     = "code"
+	StatusGRPCCode = "grpc_code"
+	StatusName     = "name"
+	StatusMessage  = "message"
+)
+
+// VarStatusPostprocessor saves call status to variables.
+// Mapping values are "code" for sample code (gRPC status converted to HTTP-like code), "grpc_code" for gRPC
+// status code, "name" for its name, e.g. "NotFound", and "message" for status message.
+type VarStatusPostprocessor struct {
+	Mapping map[string]string
+}
+
+func NewVarStatusPostprocessor(cfg Config) (*VarStatusPostprocessor, error) {
+	for k, v := range cfg.Mapping {
+		switch v {
+		case StatusCode, StatusGRPCCode, StatusName, StatusMessage:
+		default:
+			return nil, fmt.Errorf("invalid status value %q for %s: should be one of %s, %s, %s, %s",
+				v, k, StatusCode, StatusGRPCCode, StatusName, StatusMessage)
+		}
+	}
+	return &VarStatusPostprocessor{Mapping: cfg.Mapping}, nil
+}
+
+func (p *VarStatusPostprocessor) ReturnedParams() []string {
+	result := make([]string, 0, len(p.Mapping))
+	for k := range p.Mapping {
+		result = append(result, k)
+	}
+	return result
+}
+
+func (p *VarStatusPostprocessor) Process(out proto.Message, code int) (map[string]any, error) {
+	return p.ProcessResponse(&grpcgun.Response{Out: out, Code: code})
+}
+
+func (p *VarStatusPostprocessor) ProcessResponse(resp *grpcgun.Response) (map[string]any, error) {
+	if len(p.Mapping) == 0 {
+		return nil, nil
+	}
+	// Status is nil for successful call. Without status failed call has nil Out.
+	grpcCode, message := resp.Status.Code(), resp.Status.Message()
+	if resp.Status == nil && resp.Out == nil {
+		grpcCode = codes.Unknown
+	}
+	result := make(map[string]any, len(p.Mapping))
+	for k, v := range p.Mapping {
+		switch v {
+		case StatusCode:
+			result[k] = resp.Code
+		case StatusGRPCCode:
+			result[k] = int(grpcCode)
+		case StatusName:
+			result[k] = grpcCode.String()
+		case StatusMessage:
+			result[k] = message
+		}
+	}
+	return result, nil
+}
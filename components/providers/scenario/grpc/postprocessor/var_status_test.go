@@ -0,0 +1,40 @@
+package postprocessor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	grpcgun "github.com/yandex/pandora/components/guns/grpc/scenario"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestVarStatusPostprocessor_ProcessResponse(t *testing.T) {
+	p, err := NewVarStatusPostprocessor(Config{Mapping: map[string]string{
+		"code":    "code",
+		"grpc":    "grpc_code",
+		"name":    "name",
+		"message": "message",
+	}})
+	require.NoError(t, err)
+	got, err := p.ProcessResponse(&grpcgun.Response{Code: 404, Status: status.New(codes.NotFound, "no such user")})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"code": 404, "grpc": 5, "name": "NotFound", "message": "no such user"}, got)
+
+	got, err = p.ProcessResponse(&grpcgun.Response{Out: &emptypb.Empty{}, Code: 200, Status: status.Convert(nil)})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"code": 200, "grpc": 0, "name": "OK", "message": ""}, got)
+
+	got, err = p.Process(&emptypb.Empty{}, 200)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"code": 200, "grpc": 0, "name": "OK", "message": ""}, got)
+
+	got, err = p.Process(nil, 500)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"code": 500, "grpc": 2, "name": "Unknown", "message": ""}, got)
+
+	_, err = NewVarStatusPostprocessor(Config{Mapping: map[string]string{"code": "status"}})
+	assert.Error(t, err)
+}
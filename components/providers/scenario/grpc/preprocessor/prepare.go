@@ -16,12 +16,18 @@ type PreprocessorConfig struct {
 type PreparePreprocessor struct {
 	Mapping  map[string]string
 	iterator mp.Iterator
+	seqs     *templater.Sequences
 }
 
 func (p *PreparePreprocessor) InitIterator(iterator mp.Iterator) {
 	p.iterator = iterator
 }
 
+// InitSequences makes seq function of mapping use provider counters.
+func (p *PreparePreprocessor) InitSequences(seqs *templater.Sequences) {
+	p.seqs = seqs
+}
+
 func (p *PreparePreprocessor) Process(_ *scenario.Call, templateVars map[string]any) (map[string]any, error) {
 	if templateVars == nil {
 		return nil, errors.New("templateVars must not be nil")
@@ -31,8 +37,9 @@ func (p *PreparePreprocessor) Process(_ *scenario.Call, templateVars map[string]
 		val any
 		err error
 	)
+	funcs := templater.Funcs(p.seqs)
 	for k, v := range p.Mapping {
-		fun, args := templater.ParseFunc(v)
+		fun, args := templater.ParseFuncFrom(funcs, v)
 		if fun != nil {
 			val, err = templater.ExecTemplateFuncWithVariables(fun, args, templateVars, p.iterator)
 		} else {
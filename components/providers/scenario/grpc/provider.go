@@ -1,6 +1,7 @@
 package grpc
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/spf13/afero"
@@ -24,16 +25,20 @@ func NewProvider(fs afero.Fs, conf scenario.ProviderConfig) (core.Provider, erro
 	if err != nil {
 		return nil, fmt.Errorf("%s buildVariableStorage %w", op, err)
 	}
+	if err = config.ValidateAmmoConfig(ammoCfg, vs).Err(); err != nil {
+		return nil, errors.Join(fmt.Errorf("%s ValidateAmmoConfig %w", op, err), vs.Close())
+	}
 
 	ammos, err := decodeAmmo(ammoCfg, vs)
 	if err != nil {
-		return nil, fmt.Errorf("%s decodeAmmo %w", op, err)
+		return nil, errors.Join(fmt.Errorf("%s decodeAmmo %w", op, err), vs.Close())
 	}
 
 	p := &scenario.Provider[*gun.Scenario]{}
 	p.SetConfig(conf)
 	p.SetSink(make(chan *gun.Scenario, defaultSinkSize))
 	p.SetAmmos(ammos)
+	p.SetSourceStorage(vs)
 
 	return p, nil
 }
@@ -5,8 +5,12 @@ import (
 	"time"
 
 	gun "github.com/yandex/pandora/components/guns/http_scenario"
+	"github.com/yandex/pandora/components/providers/scenario"
 	"github.com/yandex/pandora/components/providers/scenario/config"
+	"github.com/yandex/pandora/components/providers/scenario/flow"
+	"github.com/yandex/pandora/components/providers/scenario/http/preprocessor"
 	"github.com/yandex/pandora/components/providers/scenario/http/templater"
+	tmpl "github.com/yandex/pandora/components/providers/scenario/templater"
 	"github.com/yandex/pandora/components/providers/scenario/vs"
 	"github.com/yandex/pandora/lib/mp"
 )
@@ -15,10 +19,17 @@ type IteratorIniter interface {
 	InitIterator(iter mp.Iterator)
 }
 
+type SequencesIniter interface {
+	InitSequences(seqs *tmpl.Sequences)
+}
+
 func decodeAmmo(cfg *config.AmmoConfig, storage *vs.SourceStorage) ([]*gun.Scenario, error) {
 	reqRegistry := make(map[string]config.RequestConfig, len(cfg.Requests))
 
 	for _, req := range cfg.Requests {
+		if _, err := scenario.ParseOnError(req.OnError); err != nil {
+			return nil, fmt.Errorf("request %s: %w", req.Name, err)
+		}
 		reqRegistry[req.Name] = req
 	}
 
@@ -27,10 +38,11 @@ func decodeAmmo(cfg *config.AmmoConfig, storage *vs.SourceStorage) ([]*gun.Scena
 		scenarioRegistry[sc.Name] = sc
 	}
 
+	seqs := tmpl.NewSequences()
 	names, size := config.SpreadNames(cfg.Scenarios)
 	result := make([]*gun.Scenario, 0, size)
 	for _, sc := range cfg.Scenarios {
-		a, err := convertScenarioToAmmo(sc, reqRegistry)
+		a, err := convertScenarioToAmmo(sc, reqRegistry, seqs)
 		if err != nil {
 			return nil, fmt.Errorf("failed to convert scenario %s: %w", sc.Name, err)
 		}
@@ -47,35 +59,48 @@ func decodeAmmo(cfg *config.AmmoConfig, storage *vs.SourceStorage) ([]*gun.Scena
 	return result, nil
 }
 
-func convertScenarioToAmmo(sc config.ScenarioConfig, reqs map[string]config.RequestConfig) (*gun.Scenario, error) {
+func convertScenarioToAmmo(sc config.ScenarioConfig, reqs map[string]config.RequestConfig, seqs *tmpl.Sequences) (*gun.Scenario, error) {
 	iter := mp.NewNextIterator(time.Now().UnixNano())
-	result := &gun.Scenario{Name: sc.Name, MinWaitingTime: time.Millisecond * time.Duration(sc.MinWaitingTime)}
-	for _, sh := range sc.Requests {
-		name, cnt, sleep, err := config.ParseShootName(sh)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse shoot %s: %w", sh, err)
-		}
-		if name == "sleep" {
-			result.Requests[len(result.Requests)-1].Sleep += time.Millisecond * time.Duration(cnt)
-			continue
-		}
-		req, ok := reqs[name]
-		if !ok {
-			return nil, fmt.Errorf("request %s not found", name)
-		}
-		r := convertConfigToRequest(req, iter)
-		if sleep > 0 {
-			r.Sleep += time.Millisecond * time.Duration(sleep)
-		}
-		for i := 0; i < cnt; i++ {
-			result.Requests = append(result.Requests, r)
-		}
+	onError, err := scenario.ParseOnError(sc.OnError)
+	if err != nil {
+		return nil, err
+	}
+	cookieJar, err := gun.ParseCookieJarScope(sc.CookieJar)
+	if err != nil {
+		return nil, err
+	}
+	result := &gun.Scenario{
+		Name:           sc.Name,
+		MinWaitingTime: time.Millisecond * time.Duration(sc.MinWaitingTime),
+		FailurePolicy:  scenario.NewFailurePolicy(onError, sc.AbortAfter),
+		CookieJar:      cookieJar,
+	}
+	builder := config.StepBuilder[gun.Request]{
+		Scenario: sc,
+		NewStep: func(name string) (gun.Request, bool) {
+			req, ok := reqs[name]
+			if !ok {
+				return gun.Request{}, false
+			}
+			return convertConfigToRequest(req, iter, seqs), true
+		},
+		NewControl: func(control *flow.Control[gun.Request]) gun.Request {
+			return gun.Request{Name: control.Name, Control: control}
+		},
+		AddSleep: func(r *gun.Request, sleep time.Duration) {
+			r.Sleep += sleep
+		},
+		Funcs: tmpl.Funcs(seqs),
+	}
+	result.Requests, err = builder.Build(sc.Requests)
+	if err != nil {
+		return nil, err
 	}
 
 	return result, nil
 }
 
-func convertConfigToRequest(req config.RequestConfig, iter mp.Iterator) gun.Request {
+func convertConfigToRequest(req config.RequestConfig, iter mp.Iterator, seqs *tmpl.Sequences) gun.Request {
 	templ := req.Templater
 	if templ == nil {
 		templ = templater.NewTextTemplater()
@@ -90,10 +115,24 @@ func convertConfigToRequest(req config.RequestConfig, iter mp.Iterator) gun.Requ
 		Preprocessor:   req.Preprocessor,
 		Postprocessors: req.Postprocessors,
 		Templater:      templ,
+		OnError:        scenario.OnError(req.OnError),
+		Retry:          req.Retry.Retry(),
 	}
 	if p, ok := result.Preprocessor.(IteratorIniter); ok {
 		p.InitIterator(iter)
 	}
+	if p, ok := result.Preprocessor.(SequencesIniter); ok {
+		p.InitSequences(seqs)
+	}
+	if t, ok := templ.(SequencesIniter); ok {
+		t.InitSequences(seqs)
+	}
+	if len(req.Session) > 0 {
+		session := &preprocessor.Preprocessor{Mapping: req.Session}
+		session.InitIterator(iter)
+		session.InitSequences(seqs)
+		result.Session = session
+	}
 
 	return result
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 	gun "github.com/yandex/pandora/components/guns/http_scenario"
+	"github.com/yandex/pandora/components/providers/scenario"
 	"github.com/yandex/pandora/components/providers/scenario/config"
 	"github.com/yandex/pandora/components/providers/scenario/http/postprocessor"
 	"github.com/yandex/pandora/components/providers/scenario/http/preprocessor"
@@ -116,6 +117,7 @@ func Test_decodeAmmo(t *testing.T) {
 					Name:            "sc1",
 					MinWaitingTime:  30 * time.Millisecond,
 					VariableStorage: storage,
+					FailurePolicy:   scenario.NewFailurePolicy("", 0),
 				},
 				{
 					Requests: []gun.Request{
@@ -160,6 +162,7 @@ func Test_decodeAmmo(t *testing.T) {
 					Name:            "sc2",
 					MinWaitingTime:  40 * time.Millisecond,
 					VariableStorage: storage,
+					FailurePolicy:   scenario.NewFailurePolicy("", 0),
 				},
 				{
 					Requests: []gun.Request{
@@ -204,6 +207,7 @@ func Test_decodeAmmo(t *testing.T) {
 					Name:            "sc2",
 					MinWaitingTime:  40 * time.Millisecond,
 					VariableStorage: storage,
+					FailurePolicy:   scenario.NewFailurePolicy("", 0),
 				},
 			},
 		},
@@ -220,6 +224,12 @@ func Test_decodeAmmo(t *testing.T) {
 					if p, ok := r.Preprocessor.(IteratorIniter); ok {
 						p.InitIterator(nil)
 					}
+					if p, ok := r.Preprocessor.(SequencesIniter); ok {
+						p.InitSequences(nil)
+					}
+					if t, ok := r.Templater.(SequencesIniter); ok {
+						t.InitSequences(nil)
+					}
 				}
 			}
 			require.NoError(t, err)
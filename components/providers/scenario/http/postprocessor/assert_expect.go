@@ -0,0 +1,42 @@
+package postprocessor
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/yandex/pandora/components/providers/scenario"
+)
+
+// AssertExpectPostprocessor checks status code, response time and JSON body of response.
+// Failed check returns *scenario.AssertError.
+type AssertExpectPostprocessor struct {
+	assertion *scenario.Assertion
+}
+
+func NewAssertExpectPostprocessor(cfg scenario.AssertConfig) (*AssertExpectPostprocessor, error) {
+	assertion, err := scenario.NewAssertion(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &AssertExpectPostprocessor{assertion: assertion}, nil
+}
+
+// ReturnedParams is empty: assertion sets no variables.
+func (p *AssertExpectPostprocessor) ReturnedParams() []string {
+	return nil
+}
+
+func (p *AssertExpectPostprocessor) Process(resp *http.Response, body io.Reader) (map[string]any, error) {
+	return p.ProcessTimed(resp, body, 0)
+}
+
+func (p *AssertExpectPostprocessor) ProcessTimed(resp *http.Response, body io.Reader, responseTime time.Duration) (map[string]any, error) {
+	return nil, p.assertion.Check(scenario.AssertResponse{
+		Code:         resp.StatusCode,
+		ResponseTime: responseTime,
+		Body: func() ([]byte, error) {
+			return io.ReadAll(body)
+		},
+	})
+}
@@ -0,0 +1,36 @@
+package postprocessor
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yandex/pandora/components/providers/scenario"
+)
+
+func TestAssertExpectPostprocessor_ProcessTimed(t *testing.T) {
+	p, err := NewAssertExpectPostprocessor(scenario.AssertConfig{
+		Status:       []string{"2xx"},
+		ResponseTime: time.Second,
+		JSONPath:     []scenario.JSONPathAssert{{Path: "$.status", Value: "done"}},
+	})
+	require.NoError(t, err)
+
+	_, err = p.ProcessTimed(&http.Response{StatusCode: 200}, strings.NewReader(`{"status": "done"}`), time.Millisecond)
+	assert.NoError(t, err)
+	_, err = p.Process(&http.Response{StatusCode: 200}, strings.NewReader(`{"status": "done"}`))
+	assert.NoError(t, err)
+
+	_, err = p.ProcessTimed(&http.Response{StatusCode: 200}, strings.NewReader(`{"status": "done"}`), 2*time.Second)
+	assert.True(t, scenario.IsAssertError(err))
+	_, err = p.ProcessTimed(&http.Response{StatusCode: 200}, strings.NewReader(`{"status": "wait"}`), time.Millisecond)
+	assert.True(t, scenario.IsAssertError(err))
+	_, err = p.ProcessTimed(&http.Response{StatusCode: 503}, strings.NewReader(`{"status": "done"}`), time.Millisecond)
+	assert.True(t, scenario.IsAssertError(err))
+
+	_, err = NewAssertExpectPostprocessor(scenario.AssertConfig{Status: []string{"two hundred"}})
+	assert.Error(t, err)
+}
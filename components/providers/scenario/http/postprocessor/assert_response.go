@@ -86,6 +86,11 @@ func (a AssertResponse) Process(resp *http.Response, body io.Reader) (map[string
 	return nil, nil
 }
 
+// ReturnedParams is empty: assertion sets no variables.
+func (a AssertResponse) ReturnedParams() []string {
+	return nil
+}
+
 func (a AssertResponse) Validate() error {
 	if a.Size != nil {
 		if a.Size.Val < 0 {
@@ -0,0 +1,61 @@
+package postprocessor
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+)
+
+const (
+	MatchRandom = "random"
+	MatchAll    = "all"
+)
+
+// matchSelector selects value of variable from all matches of extractor.
+type matchSelector struct {
+	index  int
+	random bool
+	all    bool
+}
+
+// parseMatch parses match option: index of match from 0 (negative index counts from the end, default is 0),
+// "random" for random match, or "all" for list of all matches.
+func parseMatch(s string) (matchSelector, error) {
+	switch s {
+	case "":
+		return matchSelector{}, nil
+	case MatchRandom:
+		return matchSelector{random: true}, nil
+	case MatchAll:
+		return matchSelector{all: true}, nil
+	}
+	index, err := strconv.Atoi(s)
+	if err != nil {
+		return matchSelector{}, fmt.Errorf("invalid match %q: should be index, %s or %s", s, MatchRandom, MatchAll)
+	}
+	return matchSelector{index: index}, nil
+}
+
+// selectMatch returns selected match, or false if there is no such match.
+// List of all matches is returned even if it is empty.
+func (m matchSelector) selectMatch(matches []any) (any, bool) {
+	switch {
+	case m.all:
+		if matches == nil {
+			matches = []any{}
+		}
+		return matches, true
+	case len(matches) == 0:
+		return nil, false
+	case m.random:
+		return matches[rand.Intn(len(matches))], true
+	}
+	index := m.index
+	if index < 0 {
+		index += len(matches)
+	}
+	if index < 0 || index >= len(matches) {
+		return nil, false
+	}
+	return matches[index], true
+}
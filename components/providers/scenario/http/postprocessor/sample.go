@@ -0,0 +1,102 @@
+package postprocessor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/PaesslerAG/jsonpath"
+	"github.com/yandex/pandora/core/aggregator/netsample"
+)
+
+type SampleConfig struct {
+	// Labels maps label keys to response header names.
+	Labels map[string]string
+	// Metrics maps metric names to JSONPath expressions in JSON response body.
+	Metrics map[string]string
+}
+
+// SamplePostprocessor sets custom labels and metrics of request sample.
+// Labels are taken from response headers, absent headers are skipped.
+// Metrics are taken from JSON body: numbers as is, booleans as 0 or 1, and arrays and objects as their length.
+// Absent values are skipped.
+type SamplePostprocessor struct {
+	Labels  map[string]string
+	Metrics map[string]string
+	// names and labels are sorted metric names and label keys, so they are set in the same order for every sample.
+	names  []string
+	labels []string
+}
+
+func NewSamplePostprocessor(cfg SampleConfig) (*SamplePostprocessor, error) {
+	p := &SamplePostprocessor{Labels: cfg.Labels, Metrics: cfg.Metrics}
+	for name, path := range cfg.Metrics {
+		if _, err := jsonpath.New(path); err != nil {
+			return nil, fmt.Errorf("invalid jsonpath %s of metric %s: %w", path, name, err)
+		}
+		p.names = append(p.names, name)
+	}
+	for key := range cfg.Labels {
+		p.labels = append(p.labels, key)
+	}
+	sort.Strings(p.names)
+	sort.Strings(p.labels)
+	return p, nil
+}
+
+// ReturnedParams is empty: postprocessor adds metrics and labels to sample and sets no variables.
+func (p *SamplePostprocessor) ReturnedParams() []string {
+	return nil
+}
+
+// Process does nothing: gun calls ProcessSample instead.
+func (p *SamplePostprocessor) Process(_ *http.Response, _ io.Reader) (map[string]any, error) {
+	return nil, nil
+}
+
+func (p *SamplePostprocessor) ProcessSample(sample *netsample.Sample, resp *http.Response, body io.Reader) (map[string]any, error) {
+	for _, key := range p.labels {
+		if val := resp.Header.Get(p.Labels[key]); val != "" {
+			sample.SetLabel(key, val)
+		}
+	}
+	if len(p.names) == 0 {
+		return nil, nil
+	}
+	var data any
+	if err := json.NewDecoder(body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal json: %w", err)
+	}
+	for _, name := range p.names {
+		val, err := jsonpath.Get(p.Metrics[name], data)
+		if err != nil {
+			// Value is absent.
+			continue
+		}
+		metric, ok := metricValue(val)
+		if !ok {
+			return nil, fmt.Errorf("value of metric %s by jsonpath %s is not a number: %v", name, p.Metrics[name], val)
+		}
+		sample.SetMetric(name, metric)
+	}
+	return nil, nil
+}
+
+func metricValue(val any) (float64, bool) {
+	switch v := val.(type) {
+	case float64:
+		return v, true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	case []any:
+		return float64(len(v)), true
+	case map[string]any:
+		return float64(len(v)), true
+	}
+	return 0, false
+}
@@ -0,0 +1,41 @@
+package postprocessor
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yandex/pandora/core/aggregator/netsample"
+)
+
+func TestSamplePostprocessor_ProcessSample(t *testing.T) {
+	p, err := NewSamplePostprocessor(SampleConfig{
+		Labels: map[string]string{"cache": "X-Cache", "region": "X-Region"},
+		Metrics: map[string]string{
+			"items":  "$.items",
+			"total":  "$.total",
+			"cached": "$.cached",
+			"absent": "$.absent",
+		},
+	})
+	require.NoError(t, err)
+	resp := &http.Response{Header: http.Header{"X-Cache": {"hit"}}}
+	body := `{"items": [1, 2, 3], "total": 42.5, "cached": true}`
+
+	sample := netsample.Acquire("tag")
+	vars, err := p.ProcessSample(sample, resp, strings.NewReader(body))
+	require.NoError(t, err)
+	assert.Empty(t, vars)
+	assert.Equal(t, []netsample.Label{{Key: "cache", Value: "hit"}}, sample.Labels())
+	assert.Equal(t, []netsample.Metric{{Name: "cached", Value: 1}, {Name: "items", Value: 3}, {Name: "total", Value: 42.5}}, sample.Metrics())
+
+	_, err = p.ProcessSample(netsample.Acquire("tag"), resp, strings.NewReader(`{"total": "many"}`))
+	assert.ErrorContains(t, err, "is not a number")
+	_, err = p.ProcessSample(netsample.Acquire("tag"), resp, strings.NewReader(`<html>`))
+	assert.Error(t, err)
+
+	_, err = NewSamplePostprocessor(SampleConfig{Metrics: map[string]string{"bad": "$.["}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,99 @@
+package postprocessor
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+
+	multierr "github.com/hashicorp/go-multierror"
+)
+
+type Boundary struct {
+	Left  string
+	Right string
+}
+
+type VarBoundaryConfig struct {
+	Boundaries map[string]Boundary
+	// Match is index of match from 0 (negative index counts from the end), "random" or "all".
+	Match string
+}
+
+// VarBoundaryPostprocessor extracts variables from response body between left and right boundaries.
+// Empty left boundary means body start, and empty right boundary means body end.
+// Text with open boundary is found only once, so "all" gives one match for it.
+type VarBoundaryPostprocessor struct {
+	Boundaries map[string]Boundary
+	match      matchSelector
+}
+
+func NewVarBoundaryPostprocessor(cfg VarBoundaryConfig) (*VarBoundaryPostprocessor, error) {
+	match, err := parseMatch(cfg.Match)
+	if err != nil {
+		return nil, err
+	}
+	for k, b := range cfg.Boundaries {
+		if b.Left == "" && b.Right == "" {
+			return nil, fmt.Errorf("left or right boundary should be set for %s", k)
+		}
+	}
+	return &VarBoundaryPostprocessor{Boundaries: cfg.Boundaries, match: match}, nil
+}
+
+func (p *VarBoundaryPostprocessor) ReturnedParams() []string {
+	result := make([]string, 0, len(p.Boundaries))
+	for k := range p.Boundaries {
+		result = append(result, k)
+	}
+	return result
+}
+
+func (p *VarBoundaryPostprocessor) Process(_ *http.Response, body io.Reader) (map[string]any, error) {
+	if len(p.Boundaries) == 0 {
+		return nil, nil
+	}
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("cant read body: %w", err)
+	}
+	result := make(map[string]any, len(p.Boundaries))
+	for k, boundary := range p.Boundaries {
+		val, ok := p.match.selectMatch(findBetween(b, []byte(boundary.Left), []byte(boundary.Right)))
+		if !ok {
+			err = multierr.Append(err, fmt.Errorf("no match between %q and %q for %s", boundary.Left, boundary.Right, k))
+			continue
+		}
+		result[k] = val
+	}
+	return result, err
+}
+
+func findBetween(b, left, right []byte) []any {
+	var matches []any
+	for len(b) > 0 {
+		start := 0
+		if len(left) > 0 {
+			start = bytes.Index(b, left)
+			if start < 0 {
+				break
+			}
+			start += len(left)
+		}
+		end := len(b)
+		if len(right) > 0 {
+			i := bytes.Index(b[start:], right)
+			if i < 0 {
+				break
+			}
+			end = start + i
+		}
+		matches = append(matches, string(b[start:end]))
+		if len(left) == 0 || len(right) == 0 {
+			// Only the first match starts at body start or ends at body end.
+			break
+		}
+		b = b[end+len(right):]
+	}
+	return matches
+}
@@ -0,0 +1,70 @@
+package postprocessor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVarBoundaryPostprocessor_Process(t *testing.T) {
+	const body = `id=1;id=2;id=3;`
+	tests := []struct {
+		name    string
+		cfg     VarBoundaryConfig
+		wantMap map[string]any
+		wantErr bool
+	}{
+		{
+			name:    "first",
+			cfg:     VarBoundaryConfig{Boundaries: map[string]Boundary{"id": {Left: "id=", Right: ";"}}},
+			wantMap: map[string]any{"id": "1"},
+		},
+		{
+			name:    "all",
+			cfg:     VarBoundaryConfig{Boundaries: map[string]Boundary{"ids": {Left: "id=", Right: ";"}}, Match: MatchAll},
+			wantMap: map[string]any{"ids": []any{"1", "2", "3"}},
+		},
+		{
+			name: "open boundaries",
+			cfg: VarBoundaryConfig{Boundaries: map[string]Boundary{
+				"head": {Right: ";"},
+				"tail": {Left: "id=3"},
+			}},
+			wantMap: map[string]any{"head": "id=1", "tail": ";"},
+		},
+		{
+			name: "open boundaries all",
+			cfg: VarBoundaryConfig{Boundaries: map[string]Boundary{
+				"head": {Right: ";"},
+				"tail": {Left: "id="},
+			}, Match: MatchAll},
+			wantMap: map[string]any{"head": []any{"id=1"}, "tail": []any{"1;id=2;id=3;"}},
+		},
+		{
+			name:    "no match",
+			cfg:     VarBoundaryConfig{Boundaries: map[string]Boundary{"id": {Left: "id=", Right: ";"}}, Match: "3"},
+			wantMap: map[string]any{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewVarBoundaryPostprocessor(tt.cfg)
+			require.NoError(t, err)
+			got, err := p.Process(nil, strings.NewReader(body))
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantMap, got)
+		})
+	}
+
+	t.Run("invalid config", func(t *testing.T) {
+		_, err := NewVarBoundaryPostprocessor(VarBoundaryConfig{Boundaries: map[string]Boundary{"id": {}}})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,36 @@
+package postprocessor
+
+import (
+	"io"
+	"net/http"
+)
+
+// VarCookiePostprocessor saves values of cookies set by response to variables.
+// Mapping values are cookie names. Cookies absent in response are skipped.
+type VarCookiePostprocessor struct {
+	Mapping map[string]string
+}
+
+func (p *VarCookiePostprocessor) ReturnedParams() []string {
+	result := make([]string, 0, len(p.Mapping))
+	for k := range p.Mapping {
+		result = append(result, k)
+	}
+	return result
+}
+
+func (p *VarCookiePostprocessor) Process(resp *http.Response, _ io.Reader) (map[string]any, error) {
+	if len(p.Mapping) == 0 {
+		return nil, nil
+	}
+	cookies := resp.Cookies()
+	result := make(map[string]any, len(p.Mapping))
+	for k, name := range p.Mapping {
+		for _, c := range cookies {
+			if c.Name == name {
+				result[k] = c.Value
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,19 @@
+package postprocessor
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVarCookiePostprocessor_Process(t *testing.T) {
+	resp := &http.Response{Header: http.Header{}}
+	resp.Header.Add("Set-Cookie", "session=abc; Path=/; HttpOnly")
+	resp.Header.Add("Set-Cookie", "lang=en")
+	p := &VarCookiePostprocessor{Mapping: map[string]string{"sid": "session", "lang": "lang", "missing": "missing"}}
+	got, err := p.Process(resp, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"sid": "abc", "lang": "en"}, got)
+}
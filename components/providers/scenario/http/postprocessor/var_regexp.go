@@ -0,0 +1,89 @@
+package postprocessor
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+
+	multierr "github.com/hashicorp/go-multierror"
+)
+
+type VarRegexpConfig struct {
+	Mapping map[string]string
+	// Match is index of match from 0 (negative index counts from the end), "random" or "all".
+	Match string
+}
+
+// VarRegexpPostprocessor extracts variables from response body by regular expressions.
+// Variable value is the first capturing group, or the whole match, if regexp has no groups.
+// If regexp has named groups, value is a map of group names to their values.
+type VarRegexpPostprocessor struct {
+	Mapping map[string]*regexp.Regexp
+	match   matchSelector
+}
+
+func NewVarRegexpPostprocessor(cfg VarRegexpConfig) (*VarRegexpPostprocessor, error) {
+	match, err := parseMatch(cfg.Match)
+	if err != nil {
+		return nil, err
+	}
+	mapping := make(map[string]*regexp.Regexp, len(cfg.Mapping))
+	for k, expr := range cfg.Mapping {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regexp for %s: %w", k, err)
+		}
+		mapping[k] = re
+	}
+	return &VarRegexpPostprocessor{Mapping: mapping, match: match}, nil
+}
+
+func (p *VarRegexpPostprocessor) ReturnedParams() []string {
+	result := make([]string, 0, len(p.Mapping))
+	for k := range p.Mapping {
+		result = append(result, k)
+	}
+	return result
+}
+
+func (p *VarRegexpPostprocessor) Process(_ *http.Response, body io.Reader) (map[string]any, error) {
+	if len(p.Mapping) == 0 {
+		return nil, nil
+	}
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("cant read body: %w", err)
+	}
+	result := make(map[string]any, len(p.Mapping))
+	for k, re := range p.Mapping {
+		var matches []any
+		for _, submatches := range re.FindAllSubmatch(b, -1) {
+			matches = append(matches, regexpValue(re, submatches))
+		}
+		val, ok := p.match.selectMatch(matches)
+		if !ok {
+			err = multierr.Append(err, fmt.Errorf("no match of regexp %s for %s", re, k))
+			continue
+		}
+		result[k] = val
+	}
+	return result, err
+}
+
+func regexpValue(re *regexp.Regexp, submatches [][]byte) any {
+	names := re.SubexpNames()
+	named := map[string]any{}
+	for i, name := range names {
+		if name != "" {
+			named[name] = string(submatches[i])
+		}
+	}
+	switch {
+	case len(named) > 0:
+		return named
+	case len(submatches) > 1:
+		return string(submatches[1])
+	}
+	return string(submatches[0])
+}
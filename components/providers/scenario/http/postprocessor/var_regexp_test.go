@@ -0,0 +1,76 @@
+package postprocessor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVarRegexpPostprocessor_Process(t *testing.T) {
+	const body = `<input name="csrf" value="abc"><input name="csrf" value="def"><a id=42>`
+	tests := []struct {
+		name    string
+		cfg     VarRegexpConfig
+		wantMap map[string]any
+		wantErr bool
+	}{
+		{
+			name:    "group",
+			cfg:     VarRegexpConfig{Mapping: map[string]string{"token": `name="csrf" value="(\w+)"`}},
+			wantMap: map[string]any{"token": "abc"},
+		},
+		{
+			name:    "whole match and last index",
+			cfg:     VarRegexpConfig{Mapping: map[string]string{"token": `value="\w+"`}, Match: "-1"},
+			wantMap: map[string]any{"token": `value="def"`},
+		},
+		{
+			name: "named groups",
+			cfg:  VarRegexpConfig{Mapping: map[string]string{"input": `name="(?P<name>\w+)" value="(?P<value>\w+)"`}, Match: "1"},
+			wantMap: map[string]any{
+				"input": map[string]any{"name": "csrf", "value": "def"},
+			},
+		},
+		{
+			name:    "all",
+			cfg:     VarRegexpConfig{Mapping: map[string]string{"tokens": `value="(\w+)"`, "none": `nothing`}, Match: MatchAll},
+			wantMap: map[string]any{"tokens": []any{"abc", "def"}, "none": []any{}},
+		},
+		{
+			name:    "no match",
+			cfg:     VarRegexpConfig{Mapping: map[string]string{"id": `id=(\d+)`, "token": `value="(\w+)"`}, Match: "2"},
+			wantMap: map[string]any{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewVarRegexpPostprocessor(tt.cfg)
+			require.NoError(t, err)
+			got, err := p.Process(nil, strings.NewReader(body))
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantMap, got)
+		})
+	}
+
+	t.Run("random", func(t *testing.T) {
+		p, err := NewVarRegexpPostprocessor(VarRegexpConfig{Mapping: map[string]string{"token": `value="(\w+)"`}, Match: MatchRandom})
+		require.NoError(t, err)
+		got, err := p.Process(nil, strings.NewReader(body))
+		require.NoError(t, err)
+		assert.Contains(t, []any{"abc", "def"}, got["token"])
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		_, err := NewVarRegexpPostprocessor(VarRegexpConfig{Mapping: map[string]string{"token": `(`}})
+		assert.Error(t, err)
+		_, err = NewVarRegexpPostprocessor(VarRegexpConfig{Match: "first"})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,56 @@
+package postprocessor
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const (
+	StatusCode = "code"
+	StatusText = "text"
+	StatusLine = "line"
+)
+
+// VarStatusPostprocessor saves response status to variables.
+// Mapping values are "code" for status code, "text" for its standard text, and "line" for status line, e.g. "200 OK".
+type VarStatusPostprocessor struct {
+	Mapping map[string]string
+}
+
+func NewVarStatusPostprocessor(cfg Config) (*VarStatusPostprocessor, error) {
+	for k, v := range cfg.Mapping {
+		switch v {
+		case StatusCode, StatusText, StatusLine:
+		default:
+			return nil, fmt.Errorf("invalid status value %q for %s: should be one of %s, %s, %s", v, k, StatusCode, StatusText, StatusLine)
+		}
+	}
+	return &VarStatusPostprocessor{Mapping: cfg.Mapping}, nil
+}
+
+func (p *VarStatusPostprocessor) ReturnedParams() []string {
+	result := make([]string, 0, len(p.Mapping))
+	for k := range p.Mapping {
+		result = append(result, k)
+	}
+	return result
+}
+
+func (p *VarStatusPostprocessor) Process(resp *http.Response, _ io.Reader) (map[string]any, error) {
+	if len(p.Mapping) == 0 {
+		return nil, nil
+	}
+	result := make(map[string]any, len(p.Mapping))
+	for k, v := range p.Mapping {
+		switch v {
+		case StatusCode:
+			result[k] = resp.StatusCode
+		case StatusText:
+			result[k] = http.StatusText(resp.StatusCode)
+		case StatusLine:
+			result[k] = resp.Status
+		}
+	}
+	return result, nil
+}
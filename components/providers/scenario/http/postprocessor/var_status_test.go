@@ -0,0 +1,20 @@
+package postprocessor
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVarStatusPostprocessor_Process(t *testing.T) {
+	p, err := NewVarStatusPostprocessor(Config{Mapping: map[string]string{"code": "code", "text": "text", "line": "line"}})
+	require.NoError(t, err)
+	got, err := p.Process(&http.Response{StatusCode: 404, Status: "404 Not Found"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"code": 404, "text": "Not Found", "line": "404 Not Found"}, got)
+
+	_, err = NewVarStatusPostprocessor(Config{Mapping: map[string]string{"code": "status"}})
+	assert.Error(t, err)
+}
@@ -11,6 +11,7 @@ import (
 type Preprocessor struct {
 	Mapping  map[string]string
 	iterator mp.Iterator
+	seqs     *templater.Sequences
 }
 
 func (p *Preprocessor) Process(templateVars map[string]any) (map[string]any, error) {
@@ -25,8 +26,9 @@ func (p *Preprocessor) Process(templateVars map[string]any) (map[string]any, err
 		val any
 		err error
 	)
+	funcs := templater.Funcs(p.seqs)
 	for k, v := range p.Mapping {
-		fun, args := templater.ParseFunc(v)
+		fun, args := templater.ParseFuncFrom(funcs, v)
 		if fun != nil {
 			val, err = templater.ExecTemplateFuncWithVariables(fun, args, templateVars, p.iterator)
 		} else {
@@ -45,3 +47,10 @@ func (p *Preprocessor) InitIterator(iter mp.Iterator) {
 		p.iterator = iter
 	}
 }
+
+// InitSequences makes seq function of mapping use provider counters.
+func (p *Preprocessor) InitSequences(seqs *templater.Sequences) {
+	if p != nil {
+		p.seqs = seqs
+	}
+}
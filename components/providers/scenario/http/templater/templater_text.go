@@ -16,6 +16,12 @@ func NewTextTemplater() Templater {
 
 type TextTemplater struct {
 	templatesCache sync.Map
+	seqs           *templater.Sequences
+}
+
+// InitSequences makes seq function of templates use provider counters. It must be called before Apply.
+func (t *TextTemplater) InitSequences(seqs *templater.Sequences) {
+	t.seqs = seqs
 }
 
 func (t *TextTemplater) Apply(parts *gun.RequestParts, vs map[string]any, scenarioName, stepName string) error {
@@ -65,7 +71,7 @@ func (t *TextTemplater) getTemplate(tmplBody, scenarioName, stepName, key string
 	tmpl, ok := t.templatesCache.Load(urlKey)
 	if !ok {
 		var err error
-		tmpl, err = template.New(urlKey).Funcs(templater.GetFuncs()).Parse(tmplBody)
+		tmpl, err = template.New(urlKey).Funcs(templater.Funcs(t.seqs)).Parse(tmplBody)
 		if err != nil {
 			return nil, fmt.Errorf("scenario/TextTemplater.Apply, template.New, %w", err)
 		}
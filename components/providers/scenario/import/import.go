@@ -28,6 +28,7 @@ func Import(fs afero.Fs) {
 		register.Provider("grpc/scenario", func(cfg scenario.ProviderConfig) (core.Provider, error) {
 			return grpc.NewProvider(fs, cfg)
 		})
+		register.CLICommand(lintCommand, "[flags] <scenario_file>...", runLint(fs))
 
 		RegisterVariableSource("file/csv", func(cfg vs.VariableSourceCsv) (vs.VariableSource, error) {
 			return vs.NewVSCSV(cfg, fs)
@@ -37,6 +38,16 @@ func Import(fs afero.Fs) {
 			return vs.NewVSJson(cfg, fs)
 		})
 
+		RegisterVariableSource("file/jsonl", func(cfg vs.VariableSourceJSONL) (vs.VariableSource, error) {
+			return vs.NewVSJSONL(cfg, fs)
+		})
+
+		RegisterVariableSource("file/lines", func(cfg vs.VariableSourceLines) (vs.VariableSource, error) {
+			return vs.NewVSLines(cfg, fs)
+		})
+
+		RegisterVariableSource("sqlite", vs.NewVSSQLite)
+
 		RegisterVariableSource("variables", func(cfg vs.VariableSourceVariables) vs.VariableSource {
 			return &cfg
 		})
@@ -44,7 +55,13 @@ func Import(fs afero.Fs) {
 		RegisterPostprocessor("var/jsonpath", NewVarJsonpathPostprocessor)
 		RegisterPostprocessor("var/xpath", NewVarXpathPostprocessor)
 		RegisterPostprocessor("var/header", NewVarHeaderPostprocessor)
+		RegisterPostprocessor("var/regexp", NewVarRegexpPostprocessor)
+		RegisterPostprocessor("var/boundary", NewVarBoundaryPostprocessor)
+		RegisterPostprocessor("var/status", NewVarStatusPostprocessor)
+		RegisterPostprocessor("var/cookie", NewVarCookiePostprocessor)
+		RegisterPostprocessor("sample", NewSamplePostprocessor)
 		RegisterPostprocessor("assert/response", NewAssertResponsePostprocessor)
+		RegisterPostprocessor("assert/expect", NewAssertExpectPostprocessor)
 
 		RegisterTemplater("text", func() gun.Templater {
 			return templater.NewTextTemplater()
@@ -56,6 +73,14 @@ func Import(fs afero.Fs) {
 		RegisterGRPCPostprocessor("assert/response", func(cfg grpcpostprocessor.AssertResponse) grpcgun.Postprocessor {
 			return &cfg
 		})
+		RegisterGRPCPostprocessor("assert/expect", NewGRPCAssertExpectPostprocessor)
+		RegisterGRPCPostprocessor("var/jsonpath", func(cfg grpcpostprocessor.Config) grpcgun.Postprocessor {
+			return &grpcpostprocessor.VarJsonpathPostprocessor{Mapping: cfg.Mapping}
+		})
+		RegisterGRPCPostprocessor("var/metadata", func(cfg grpcpostprocessor.Config) grpcgun.Postprocessor {
+			return &grpcpostprocessor.VarMetadataPostprocessor{Mapping: cfg.Mapping}
+		})
+		RegisterGRPCPostprocessor("var/status", NewGRPCVarStatusPostprocessor)
 		RegisterGRPCPreprocessor("prepare", func(cfg grpcpreprocessor.PreprocessorConfig) grpcgun.Preprocessor {
 			return &grpcpreprocessor.PreparePreprocessor{Mapping: cfg.Mapping}
 		})
@@ -95,6 +120,30 @@ func NewAssertResponsePostprocessor(cfg postprocessor.AssertResponse) (gun.Postp
 	return &cfg, nil
 }
 
+func NewAssertExpectPostprocessor(cfg scenario.AssertConfig) (gun.Postprocessor, error) {
+	p, err := postprocessor.NewAssertExpectPostprocessor(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func NewGRPCAssertExpectPostprocessor(cfg scenario.AssertConfig) (grpcgun.Postprocessor, error) {
+	p, err := grpcpostprocessor.NewAssertExpectPostprocessor(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func NewGRPCVarStatusPostprocessor(cfg grpcpostprocessor.Config) (grpcgun.Postprocessor, error) {
+	p, err := grpcpostprocessor.NewVarStatusPostprocessor(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
 func NewVarHeaderPostprocessor(cfg postprocessor.Config) gun.Postprocessor {
 	return &postprocessor.VarHeaderPostprocessor{
 		Mapping: cfg.Mapping,
@@ -112,3 +161,41 @@ func NewVarXpathPostprocessor(cfg postprocessor.Config) gun.Postprocessor {
 		Mapping: cfg.Mapping,
 	}
 }
+
+func NewVarRegexpPostprocessor(cfg postprocessor.VarRegexpConfig) (gun.Postprocessor, error) {
+	p, err := postprocessor.NewVarRegexpPostprocessor(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func NewVarBoundaryPostprocessor(cfg postprocessor.VarBoundaryConfig) (gun.Postprocessor, error) {
+	p, err := postprocessor.NewVarBoundaryPostprocessor(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func NewVarStatusPostprocessor(cfg postprocessor.Config) (gun.Postprocessor, error) {
+	p, err := postprocessor.NewVarStatusPostprocessor(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func NewSamplePostprocessor(cfg postprocessor.SampleConfig) (gun.Postprocessor, error) {
+	p, err := postprocessor.NewSamplePostprocessor(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func NewVarCookiePostprocessor(cfg postprocessor.Config) gun.Postprocessor {
+	return &postprocessor.VarCookiePostprocessor{
+		Mapping: cfg.Mapping,
+	}
+}
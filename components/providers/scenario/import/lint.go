@@ -0,0 +1,60 @@
+package scenario
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/spf13/afero"
+	"github.com/yandex/pandora/components/providers/scenario/config"
+)
+
+const lintCommand = "lint"
+
+// runLint checks scenario ammo files and prints found problems with their positions.
+func runLint(fs afero.Fs) func(args []string) error {
+	return func(args []string) error {
+		flags := flag.NewFlagSet(lintCommand, flag.ContinueOnError)
+		flags.Usage = func() {
+			fmt.Fprintf(flags.Output(), "Usage of Pandora: pandora %s [flags] <scenario_file>...\n"+
+				"Checks HCL and YAML scenario files of http/scenario and grpc/scenario providers without shooting.\n", lintCommand)
+			flags.PrintDefaults()
+		}
+		var strict bool
+		flags.BoolVar(&strict, "strict", false, "fail on warnings too")
+		if err := flags.Parse(args); err != nil {
+			return err
+		}
+		if flags.NArg() == 0 {
+			flags.Usage()
+			return fmt.Errorf("no scenario files")
+		}
+		return lint(fs, os.Stdout, flags.Args(), strict)
+	}
+}
+
+func lint(fs afero.Fs, out io.Writer, fileNames []string, strict bool) error {
+	var errs, warnings int
+	for _, fileName := range fileNames {
+		diags, files, err := config.Lint(fs, fileName)
+		if err != nil {
+			return err
+		}
+		if err := hcl.NewDiagnosticTextWriter(out, files, 0, false).WriteDiagnostics(diags); err != nil {
+			return err
+		}
+		for _, diag := range diags {
+			if diag.Severity == hcl.DiagError {
+				errs++
+			} else {
+				warnings++
+			}
+		}
+	}
+	if errs > 0 || strict && warnings > 0 {
+		return fmt.Errorf("%d errors and %d warnings found", errs, warnings)
+	}
+	return nil
+}
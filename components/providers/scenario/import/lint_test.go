@@ -0,0 +1,80 @@
+package scenario
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yandex/pandora/core/plugin/pluginconfig"
+)
+
+func TestLint(t *testing.T) {
+	fs := afero.NewOsFs()
+	Import(fs)
+	pluginconfig.AddHooks()
+
+	t.Run("valid", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		err := lint(fs, out, []string{"../testdata/http_flow.hcl", "../testdata/http_flow.yaml"}, true)
+		require.NoError(t, err)
+		assert.Empty(t, out.String())
+	})
+
+	tests := []struct {
+		file  string
+		lines []string
+	}{
+		{
+			file: "../testdata/lint_errors.hcl",
+			lines: []string{
+				"Error: variable source vars has no field hots\n\n  on ../testdata/lint_errors.hcl line 10, in request \"auth\":",
+				"Error: request auth postprocessor does not set variable tokn\n\n  on ../testdata/lint_errors.hcl line 22, in request \"list\":",
+				"Error: invalid template: template: body:1: unclosed action\n\n  on ../testdata/lint_errors.hcl line 24, in request \"list\":",
+				"Warning: request pay is referenced before it is executed in scenario main\n\n  on ../testdata/lint_errors.hcl line 29, in request \"order\":",
+				"Error: unknown variable source users in variable .source.users[next].id\n\n  on ../testdata/lint_errors.hcl line 32, in request \"order\":",
+				"Warning: request unused is not used in scenarios\n\n  on ../testdata/lint_errors.hcl line 49, in request \"unused\":",
+				"Error: negative weight -1\n\n  on ../testdata/lint_errors.hcl line 56, in scenario \"main\":",
+				"Error: sleep must follow a request\n\n  on ../testdata/lint_errors.hcl line 57, in scenario \"main\":",
+				"Error: unknown request delete\n\n  on ../testdata/lint_errors.hcl line 61, in scenario \"main\":",
+				"Error: negative weight -5\n\n  on ../testdata/lint_errors.hcl line 66, in scenario \"main\":",
+			},
+		},
+		{
+			file: "../testdata/lint_errors.yaml",
+			lines: []string{
+				"Error: variable source vars has no field hots\n\n  on ../testdata/lint_errors.yaml line 10:",
+				"Error: request auth postprocessor does not set variable tokn\n\n  on ../testdata/lint_errors.yaml line 18:",
+				"Error: invalid template: template: body:1: unclosed action\n\n  on ../testdata/lint_errors.yaml line 19:",
+				"Warning: request pay is referenced before it is executed in scenario main\n\n  on ../testdata/lint_errors.yaml line 22:",
+				"Error: unknown variable source users in variable .source.users[next].id\n\n  on ../testdata/lint_errors.yaml line 24:",
+				"Warning: request unused is not used in scenarios\n\n  on ../testdata/lint_errors.yaml line 33:",
+				"Error: negative weight -1\n\n  on ../testdata/lint_errors.yaml line 38:",
+				"Error: sleep must follow a request\n\n  on ../testdata/lint_errors.yaml line 39:",
+				"Error: unknown request delete\n\n  on ../testdata/lint_errors.yaml line 43:",
+				"Error: negative weight -5\n\n  on ../testdata/lint_errors.yaml line 47:",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			err := lint(fs, out, []string{tt.file}, false)
+			require.EqualError(t, err, "8 errors and 2 warnings found")
+			output := out.String()
+			for _, line := range tt.lines {
+				assert.Contains(t, output, line)
+			}
+		})
+	}
+
+	t.Run("syntax error", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fs, "bad.yaml", []byte("requests:\n  - name: a\n    uri: [\n"), 0644))
+		out := &bytes.Buffer{}
+		err := lint(fs, out, []string{"bad.yaml"}, false)
+		require.EqualError(t, err, "1 errors and 0 warnings found")
+		assert.Contains(t, out.String(), "Error: Invalid YAML\n\n  on bad.yaml line 3:")
+	})
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/yandex/pandora/components/providers/base"
 	"github.com/yandex/pandora/components/providers/http/decoders"
+	"github.com/yandex/pandora/components/providers/scenario/vs"
 	"github.com/yandex/pandora/core"
 )
 
@@ -27,8 +28,9 @@ type Provider[A ProvAmmo] struct {
 	base.ProviderBase
 	cfg ProviderConfig
 
-	sink  chan A
-	ammos []A
+	sink    chan A
+	ammos   []A
+	storage *vs.SourceStorage
 }
 
 func (p *Provider[A]) SetConfig(conf ProviderConfig) {
@@ -43,9 +45,22 @@ func (p *Provider[A]) SetAmmos(ammos []A) {
 	p.ammos = ammos
 }
 
-func (p *Provider[A]) Run(ctx context.Context, deps core.ProviderDeps) error {
+// SetSourceStorage sets variable sources of ammo. Provider finishes ammo, when any of them is exhausted.
+// Sources are closed, when Run returns.
+func (p *Provider[A]) SetSourceStorage(storage *vs.SourceStorage) {
+	p.storage = storage
+}
+
+func (p *Provider[A]) Run(ctx context.Context, deps core.ProviderDeps) (err error) {
 	const op = "scenario.Provider.Run"
 	p.Deps = deps
+	if p.storage != nil {
+		defer func() {
+			if closeErr := p.storage.Close(); closeErr != nil {
+				err = errors.Join(err, fmt.Errorf("%s close variable sources: %w", op, closeErr))
+			}
+		}()
+	}
 
 	length := uint(len(p.ammos))
 	if length == 0 {
@@ -54,7 +69,7 @@ func (p *Provider[A]) Run(ctx context.Context, deps core.ProviderDeps) error {
 	ammoNum := uint(0)
 	passNum := uint(0)
 	for {
-		err := ctx.Err()
+		err = ctx.Err()
 		if err != nil {
 			if !errors.Is(err, context.Canceled) {
 				err = fmt.Errorf("%s error from context: %w", op, err)
@@ -84,6 +99,9 @@ func (p *Provider[A]) Run(ctx context.Context, deps core.ProviderDeps) error {
 }
 
 func (p *Provider[A]) Acquire() (core.Ammo, bool) {
+	if p.storage != nil && p.storage.Exhausted() {
+		return nil, false
+	}
 	ammo, ok := <-p.sink
 	if !ok {
 		return nil, false
@@ -2,9 +2,12 @@ package templater
 
 import (
 	"fmt"
+	"math"
 	"math/rand"
+	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 
@@ -25,11 +28,37 @@ var _ = []templateFunc{
 	RandInt,
 	RandString,
 	UUID,
+	RandFloat,
+	RandChoice,
+	RandNorm,
+	RandZipf,
+	Now,
+	Base64Encode,
+	Base64Decode,
+	HexEncode,
+	HexDecode,
+	URLEncode,
+	URLDecode,
+	SHA256,
+	MD5,
+	HMAC,
+	ToJSON,
+	RandFirstName,
+	RandLastName,
+	RandName,
+	RandEmail,
+	RandPhone,
+	NewSequences().Seq,
 }
 
 func ParseFunc(v string) (f any, args []string) {
+	return ParseFuncFrom(GetFuncs(), v)
+}
+
+// ParseFuncFrom is ParseFunc that looks function up in funcs, e.g. returned by Funcs with provider sequences.
+func ParseFuncFrom(funcs template.FuncMap, v string) (f any, args []string) {
 	name, args := parseStr(v)
-	if f, ok := GetFuncs()[name]; ok {
+	if f, ok := funcs[name]; ok {
 		return f, args
 	}
 	return nil, nil
@@ -56,11 +85,41 @@ func parseStr(v string) (string, []string) {
 	return name, args
 }
 
+// GetFuncs returns template functions. Each call creates new seq counters, use Funcs to share them.
 func GetFuncs() template.FuncMap {
+	return Funcs(nil)
+}
+
+// Funcs returns template functions with seq counters kept in seqs. New counters are created, if seqs is nil.
+func Funcs(seqs *Sequences) template.FuncMap {
+	if seqs == nil {
+		seqs = NewSequences()
+	}
 	return map[string]any{
-		"randInt":    RandInt,
-		"randString": RandString,
-		"uuid":       UUID,
+		"randInt":       RandInt,
+		"randString":    RandString,
+		"uuid":          UUID,
+		"randFloat":     RandFloat,
+		"randChoice":    RandChoice,
+		"randNorm":      RandNorm,
+		"randZipf":      RandZipf,
+		"now":           Now,
+		"base64Encode":  Base64Encode,
+		"base64Decode":  Base64Decode,
+		"hexEncode":     HexEncode,
+		"hexDecode":     HexDecode,
+		"urlEncode":     URLEncode,
+		"urlDecode":     URLDecode,
+		"sha256":        SHA256,
+		"md5":           MD5,
+		"hmac":          HMAC,
+		"toJSON":        ToJSON,
+		"randFirstName": RandFirstName,
+		"randLastName":  RandLastName,
+		"randName":      RandName,
+		"randEmail":     RandEmail,
+		"randPhone":     RandPhone,
+		"seq":           seqs.Seq,
 	}
 }
 
@@ -135,3 +194,140 @@ func UUID(args ...any) (string, error) {
 	}
 	return v.String(), nil
 }
+
+func RandFloat(args ...any) (string, error) {
+	switch len(args) {
+	case 0:
+		return randFloat(0, 1)
+	case 1:
+		t, err := numbers.ParseFloat(args[0])
+		if err != nil {
+			return "", err
+		}
+		return randFloat(0, t)
+	case 2:
+		f, err := numbers.ParseFloat(args[0])
+		if err != nil {
+			return "", err
+		}
+		t, err := numbers.ParseFloat(args[1])
+		if err != nil {
+			return "", err
+		}
+		return randFloat(f, t)
+	default:
+		return "", fmt.Errorf("maximum 2 arguments expected but got %d", len(args))
+	}
+}
+
+func randFloat(f, t float64) (string, error) {
+	if t < f {
+		t, f = f, t
+	}
+	n := f + rand.Float64()*(t-f)
+	return strconv.FormatFloat(n, 'f', -1, 64), nil
+}
+
+// RandChoice returns one of passed arguments. If the only argument is a slice
+// (e.g. variable source array), random element of the slice is returned.
+func RandChoice(args ...any) (string, error) {
+	if len(args) == 1 {
+		v := reflect.ValueOf(args[0])
+		if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+			if v.Len() == 0 {
+				return "", fmt.Errorf("empty slice passed")
+			}
+			return str.FormatString(v.Index(rand.Intn(v.Len())).Interface()), nil
+		}
+	}
+	if len(args) == 0 {
+		return "", fmt.Errorf("at least 1 argument expected")
+	}
+	return str.FormatString(args[rand.Intn(len(args))]), nil
+}
+
+// RandNorm returns normally distributed integer with mean and stddev passed as arguments.
+func RandNorm(args ...any) (string, error) {
+	if len(args) != 2 {
+		return "", fmt.Errorf("2 arguments expected but got %d", len(args))
+	}
+	mean, err := numbers.ParseFloat(args[0])
+	if err != nil {
+		return "", err
+	}
+	stddev, err := numbers.ParseFloat(args[1])
+	if err != nil {
+		return "", err
+	}
+	n := rand.NormFloat64()*stddev + mean
+	return strconv.FormatInt(int64(math.Round(n)), 10), nil
+}
+
+const (
+	defaultZipfS = 1.1
+	defaultZipfV = 1
+)
+
+// RandZipf returns Zipf distributed integer in range [0, imax].
+// Optional arguments are s > 1 and v >= 1 distribution parameters.
+func RandZipf(args ...any) (string, error) {
+	if len(args) == 0 || len(args) > 3 {
+		return "", fmt.Errorf("from 1 to 3 arguments expected but got %d", len(args))
+	}
+	imax, err := numbers.ParseInt(args[0])
+	if err != nil {
+		return "", err
+	}
+	s, v := defaultZipfS, float64(defaultZipfV)
+	if len(args) > 1 {
+		s, err = numbers.ParseFloat(args[1])
+		if err != nil {
+			return "", err
+		}
+	}
+	if len(args) > 2 {
+		v, err = numbers.ParseFloat(args[2])
+		if err != nil {
+			return "", err
+		}
+	}
+	if imax < 0 || s <= 1 || v < 1 {
+		return "", fmt.Errorf("invalid zipf parameters: imax=%d, s=%v, v=%v", imax, s, v)
+	}
+	g := zipfPool.Get().(*zipfGenerator)
+	defer zipfPool.Put(g)
+	return strconv.FormatUint(g.zipf(zipfParams{s: s, v: v, imax: uint64(imax)}).Uint64(), 10), nil
+}
+
+// math/rand.Rand is not goroutine safe, so each generator owns one
+// and caches Zipf distributions built over it.
+var zipfPool = sync.Pool{New: func() any {
+	return &zipfGenerator{
+		rand:  rand.New(rand.NewSource(rand.Int63())),
+		zipfs: map[zipfParams]*rand.Zipf{},
+	}
+}}
+
+const maxCachedZipfs = 16
+
+type zipfParams struct {
+	s, v float64
+	imax uint64
+}
+
+type zipfGenerator struct {
+	rand  *rand.Rand
+	zipfs map[zipfParams]*rand.Zipf
+}
+
+func (g *zipfGenerator) zipf(p zipfParams) *rand.Zipf {
+	z, ok := g.zipfs[p]
+	if !ok {
+		if len(g.zipfs) >= maxCachedZipfs {
+			clear(g.zipfs)
+		}
+		z = rand.NewZipf(g.rand, p.s, p.v, p.imax)
+		g.zipfs[p] = z
+	}
+	return z
+}
@@ -0,0 +1,145 @@
+package templater
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"net/url"
+
+	"github.com/yandex/pandora/lib/str"
+)
+
+func Base64Encode(args ...any) (string, error) {
+	v, err := singleArg(args)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString([]byte(v)), nil
+}
+
+func Base64Decode(args ...any) (string, error) {
+	v, err := singleArg(args)
+	if err != nil {
+		return "", err
+	}
+	b, err := base64.StdEncoding.DecodeString(v)
+	if err != nil {
+		return "", fmt.Errorf("base64 decode: %w", err)
+	}
+	return string(b), nil
+}
+
+func HexEncode(args ...any) (string, error) {
+	v, err := singleArg(args)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString([]byte(v)), nil
+}
+
+func HexDecode(args ...any) (string, error) {
+	v, err := singleArg(args)
+	if err != nil {
+		return "", err
+	}
+	b, err := hex.DecodeString(v)
+	if err != nil {
+		return "", fmt.Errorf("hex decode: %w", err)
+	}
+	return string(b), nil
+}
+
+func URLEncode(args ...any) (string, error) {
+	v, err := singleArg(args)
+	if err != nil {
+		return "", err
+	}
+	return url.QueryEscape(v), nil
+}
+
+func URLDecode(args ...any) (string, error) {
+	v, err := singleArg(args)
+	if err != nil {
+		return "", err
+	}
+	res, err := url.QueryUnescape(v)
+	if err != nil {
+		return "", fmt.Errorf("url decode: %w", err)
+	}
+	return res, nil
+}
+
+// SHA256 returns hex encoded sha256 sum of argument.
+func SHA256(args ...any) (string, error) {
+	v, err := singleArg(args)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(v))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// MD5 returns hex encoded md5 sum of argument.
+func MD5(args ...any) (string, error) {
+	v, err := singleArg(args)
+	if err != nil {
+		return "", err
+	}
+	sum := md5.Sum([]byte(v))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// HMAC returns hex encoded HMAC of message. Arguments: key, message and optional
+// hash algorithm - one of md5, sha1, sha256 (default), sha512.
+func HMAC(args ...any) (string, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return "", fmt.Errorf("from 2 to 3 arguments expected but got %d", len(args))
+	}
+	algo := "sha256"
+	if len(args) == 3 {
+		algo = str.FormatString(args[2])
+	}
+	var h func() hash.Hash
+	switch algo {
+	case "md5":
+		h = md5.New
+	case "sha1":
+		h = sha1.New
+	case "sha256":
+		h = sha256.New
+	case "sha512":
+		h = sha512.New
+	default:
+		return "", fmt.Errorf("unsupported hmac algorithm %s", algo)
+	}
+	mac := hmac.New(h, []byte(str.FormatString(args[0])))
+	mac.Write([]byte(str.FormatString(args[1])))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// ToJSON returns JSON representation of argument. Useful to insert variable source
+// objects and arrays into request body.
+func ToJSON(args ...any) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("1 argument expected but got %d", len(args))
+	}
+	b, err := json.Marshal(args[0])
+	if err != nil {
+		return "", fmt.Errorf("json marshal: %w", err)
+	}
+	return string(b), nil
+}
+
+func singleArg(args []any) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("1 argument expected but got %d", len(args))
+	}
+	return str.FormatString(args[0]), nil
+}
@@ -0,0 +1,83 @@
+package templater
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/yandex/pandora/lib/str"
+)
+
+var (
+	fakeFirstNames = []string{
+		"James", "Mary", "John", "Patricia", "Robert", "Jennifer", "Michael", "Linda",
+		"William", "Elizabeth", "David", "Barbara", "Richard", "Susan", "Joseph", "Jessica",
+		"Thomas", "Sarah", "Charles", "Karen", "Daniel", "Nancy", "Matthew", "Lisa",
+		"Anthony", "Betty", "Mark", "Margaret", "Donald", "Sandra", "Steven", "Ashley",
+		"Ivan", "Olga", "Dmitry", "Anna", "Sergey", "Elena", "Alexey", "Natalia",
+	}
+	fakeLastNames = []string{
+		"Smith", "Johnson", "Williams", "Brown", "Jones", "Garcia", "Miller", "Davis",
+		"Rodriguez", "Martinez", "Hernandez", "Lopez", "Gonzalez", "Wilson", "Anderson", "Thomas",
+		"Taylor", "Moore", "Jackson", "Martin", "Lee", "Perez", "Thompson", "White",
+		"Harris", "Sanchez", "Clark", "Ramirez", "Lewis", "Robinson", "Walker", "Young",
+		"Ivanov", "Petrov", "Sidorov", "Smirnov", "Kuznetsov", "Popov", "Volkov", "Sokolov",
+	}
+	fakeEmailDomains = []string{
+		"example.com", "example.org", "example.net", "mail.test", "test.local",
+	}
+)
+
+const defaultPhonePattern = "+1##########"
+
+func RandFirstName(args ...any) (string, error) {
+	return fakeFirstNames[rand.Intn(len(fakeFirstNames))], nil
+}
+
+func RandLastName(args ...any) (string, error) {
+	return fakeLastNames[rand.Intn(len(fakeLastNames))], nil
+}
+
+// RandName returns random full name: first and last name separated by space.
+func RandName(args ...any) (string, error) {
+	first, _ := RandFirstName()
+	last, _ := RandLastName()
+	return first + " " + last, nil
+}
+
+// RandEmail returns random email. Optional argument is email domain.
+func RandEmail(args ...any) (string, error) {
+	var domain string
+	switch len(args) {
+	case 0:
+		domain = fakeEmailDomains[rand.Intn(len(fakeEmailDomains))]
+	case 1:
+		domain = str.FormatString(args[0])
+	default:
+		return "", fmt.Errorf("maximum 1 argument expected but got %d", len(args))
+	}
+	first, _ := RandFirstName()
+	last, _ := RandLastName()
+	return fmt.Sprintf("%s.%s%d@%s", strings.ToLower(first), strings.ToLower(last), rand.Intn(1000), domain), nil
+}
+
+// RandPhone returns random phone number. Optional argument is pattern,
+// where each '#' is replaced with random digit.
+func RandPhone(args ...any) (string, error) {
+	var pattern string
+	switch len(args) {
+	case 0:
+		pattern = defaultPhonePattern
+	case 1:
+		pattern = str.FormatString(args[0])
+	default:
+		return "", fmt.Errorf("maximum 1 argument expected but got %d", len(args))
+	}
+	b := []byte(pattern)
+	for i := range b {
+		if b[i] == '#' {
+			b[i] = byte('0' + rand.Intn(10))
+		}
+	}
+	return string(b), nil
+}
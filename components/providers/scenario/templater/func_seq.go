@@ -0,0 +1,39 @@
+package templater
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/yandex/pandora/lib/str"
+)
+
+// Sequences are named counters of seq function. Provider creates its own sequences and passes them
+// to templaters and preprocessors, so counters are shared by all provider instances and start from 0 in each run.
+type Sequences struct {
+	counters sync.Map // map[string]*atomic.Int64
+}
+
+func NewSequences() *Sequences {
+	return &Sequences{}
+}
+
+// Seq returns next value of named counter starting from 0.
+// Optional second argument is counter scope. Counters with the same name but different
+// scopes are independent, so passing instance id (.instance.id in scenarios) makes
+// counter unique per instance. Without scope, counter is shared by all instances.
+func (s *Sequences) Seq(args ...any) (string, error) {
+	var key string
+	switch len(args) {
+	case 1:
+		key = str.FormatString(args[0])
+	case 2:
+		key = str.FormatString(args[0]) + "/" + str.FormatString(args[1])
+	default:
+		return "", fmt.Errorf("from 1 to 2 arguments expected but got %d", len(args))
+	}
+	counter, _ := s.counters.LoadOrStore(key, &atomic.Int64{})
+	n := counter.(*atomic.Int64).Add(1) - 1
+	return strconv.FormatInt(n, 10), nil
+}
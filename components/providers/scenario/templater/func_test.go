@@ -2,7 +2,9 @@ package templater
 
 import (
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -222,3 +224,159 @@ func TestParseFunc(t *testing.T) {
 		})
 	}
 }
+
+func TestRandFloat(t *testing.T) {
+	got, err := RandFloat(10, 20)
+	require.NoError(t, err)
+	f, err := strconv.ParseFloat(got, 64)
+	require.NoError(t, err)
+	require.InDelta(t, 15, f, 5)
+
+	_, err = RandFloat("invalid")
+	require.Error(t, err)
+}
+
+func TestRandChoice(t *testing.T) {
+	got, err := RandChoice("a", "b", "c")
+	require.NoError(t, err)
+	require.Contains(t, []string{"a", "b", "c"}, got)
+
+	got, err = RandChoice([]any{1, 2})
+	require.NoError(t, err)
+	require.Contains(t, []string{"1", "2"}, got)
+
+	_, err = RandChoice()
+	require.Error(t, err)
+	_, err = RandChoice([]string{})
+	require.Error(t, err)
+}
+
+func TestRandZipf(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		got, err := RandZipf(10)
+		require.NoError(t, err)
+		n, err := strconv.Atoi(got)
+		require.NoError(t, err)
+		require.True(t, n >= 0 && n <= 10)
+	}
+	for i := 0; i < 2*maxCachedZipfs; i++ {
+		got, err := RandZipf(i, 1.5, 2)
+		require.NoError(t, err)
+		n, err := strconv.Atoi(got)
+		require.NoError(t, err)
+		require.True(t, n >= 0 && n <= i)
+	}
+	_, err := RandZipf(10, 0.5)
+	require.Error(t, err)
+}
+
+func TestNow(t *testing.T) {
+	nowFunc = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+	defer func() { nowFunc = time.Now }()
+
+	tests := []struct {
+		name string
+		args []any
+		want string
+	}{
+		{name: "default", want: "2024-01-02T03:04:05Z"},
+		{name: "unix", args: []any{"unix"}, want: "1704164645"},
+		{name: "unixMilli with offset", args: []any{"unixMilli", "1s"}, want: "1704164646000"},
+		{name: "layout with offset", args: []any{"2006-01-02", "-24h"}, want: "2024-01-01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Now(tt.args...)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+	_, err := Now("unix", "invalid")
+	require.Error(t, err)
+}
+
+func TestEncodeFuncs(t *testing.T) {
+	tests := []struct {
+		name string
+		fun  func(args ...any) (string, error)
+		arg  any
+		want string
+	}{
+		{name: "base64Encode", fun: Base64Encode, arg: "hello", want: "aGVsbG8="},
+		{name: "base64Decode", fun: Base64Decode, arg: "aGVsbG8=", want: "hello"},
+		{name: "hexEncode", fun: HexEncode, arg: "hi", want: "6869"},
+		{name: "hexDecode", fun: HexDecode, arg: "6869", want: "hi"},
+		{name: "urlEncode", fun: URLEncode, arg: "a b&c", want: "a+b%26c"},
+		{name: "urlDecode", fun: URLDecode, arg: "a+b%26c", want: "a b&c"},
+		{name: "sha256", fun: SHA256, arg: "abc", want: "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{name: "md5", fun: MD5, arg: "abc", want: "900150983cd24fb0d6963f7d28e17f72"},
+		{name: "toJSON", fun: ToJSON, arg: map[string]any{"a": []int{1, 2}}, want: `{"a":[1,2]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.fun(tt.arg)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestHMAC(t *testing.T) {
+	got, err := HMAC("key", "The quick brown fox jumps over the lazy dog")
+	require.NoError(t, err)
+	require.Equal(t, "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8", got)
+
+	got, err = HMAC("key", "The quick brown fox jumps over the lazy dog", "md5")
+	require.NoError(t, err)
+	require.Equal(t, "80070713463e7749b90c2dc24911e275", got)
+
+	_, err = HMAC("key", "msg", "unknown")
+	require.Error(t, err)
+}
+
+func TestFakeFuncs(t *testing.T) {
+	got, err := RandName()
+	require.NoError(t, err)
+	require.Len(t, strings.Split(got, " "), 2)
+
+	got, err = RandEmail("example.com")
+	require.NoError(t, err)
+	require.True(t, strings.HasSuffix(got, "@example.com"))
+
+	got, err = RandPhone("+7 (###) ###")
+	require.NoError(t, err)
+	require.Regexp(t, `^\+7 \(\d{3}\) \d{3}$`, got)
+}
+
+func TestSeq(t *testing.T) {
+	seqs := NewSequences()
+	for i := 0; i < 3; i++ {
+		got, err := seqs.Seq("TestSeq", 1)
+		require.NoError(t, err)
+		require.Equal(t, strconv.Itoa(i), got)
+	}
+	got, err := seqs.Seq("TestSeq", 2)
+	require.NoError(t, err)
+	require.Equal(t, "0", got)
+
+	got, err = NewSequences().Seq("TestSeq", 1)
+	require.NoError(t, err)
+	require.Equal(t, "0", got)
+
+	fun, args := ParseFuncFrom(Funcs(seqs), "seq(TestSeq, 1)")
+	require.NotNil(t, fun)
+	got, err = ExecTemplateFunc(fun, args)
+	require.NoError(t, err)
+	require.Equal(t, "3", got)
+
+	_, err = seqs.Seq()
+	require.Error(t, err)
+}
+
+func TestParseFuncNewFuncs(t *testing.T) {
+	fun, args := ParseFunc("hmac(key, msg, sha1)")
+	require.NotNil(t, fun)
+	got, err := ExecTemplateFunc(fun, args)
+	require.NoError(t, err)
+	require.Len(t, got, 40)
+}
@@ -0,0 +1,56 @@
+package templater
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/yandex/pandora/lib/str"
+)
+
+const defaultTimeFormat = "rfc3339"
+
+var nowFunc = time.Now
+
+// Now returns current time. First optional argument is format: one of
+// rfc3339, rfc3339nano, rfc1123, unix, unixMilli, unixMicro, unixNano or Go time layout.
+// Second optional argument is offset in time.ParseDuration format, e.g. "-1h30m".
+func Now(args ...any) (string, error) {
+	format := defaultTimeFormat
+	var offset time.Duration
+	switch len(args) {
+	case 0:
+	case 2:
+		var err error
+		offset, err = time.ParseDuration(str.FormatString(args[1]))
+		if err != nil {
+			return "", fmt.Errorf("invalid time offset: %w", err)
+		}
+		fallthrough
+	case 1:
+		format = str.FormatString(args[0])
+	default:
+		return "", fmt.Errorf("maximum 2 arguments expected but got %d", len(args))
+	}
+	return formatTime(nowFunc().Add(offset), format), nil
+}
+
+func formatTime(t time.Time, format string) string {
+	switch format {
+	case "", "rfc3339":
+		return t.Format(time.RFC3339)
+	case "rfc3339nano":
+		return t.Format(time.RFC3339Nano)
+	case "rfc1123":
+		return t.Format(time.RFC1123)
+	case "unix":
+		return strconv.FormatInt(t.Unix(), 10)
+	case "unixMilli":
+		return strconv.FormatInt(t.UnixMilli(), 10)
+	case "unixMicro":
+		return strconv.FormatInt(t.UnixMicro(), 10)
+	case "unixNano":
+		return strconv.FormatInt(t.UnixNano(), 10)
+	}
+	return t.Format(format)
+}
@@ -1,13 +1,18 @@
 package test
 
 import (
+	"net/http"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/yandex/pandora/components/providers/scenario"
 	"github.com/yandex/pandora/components/providers/scenario/config"
+	"github.com/yandex/pandora/components/providers/scenario/http/postprocessor"
 	_import "github.com/yandex/pandora/components/providers/scenario/import"
 	"github.com/yandex/pandora/core/plugin/pluginconfig"
 )
@@ -158,3 +163,70 @@ scenarios:
 		})
 	}
 }
+
+func Test_ReadConfig_FlowYamlAndHclSameResult(t *testing.T) {
+	_import.Import(testFS)
+	testOnce.Do(func() {
+		pluginconfig.AddHooks()
+	})
+
+	fromHCL, err := config.ReadAmmoConfig(testFS, "../testdata/http_flow.hcl")
+	require.NoError(t, err)
+
+	fromYaml, err := config.ReadAmmoConfig(testFS, "../testdata/http_flow.yaml")
+	require.NoError(t, err)
+
+	require.Equal(t, fromHCL.Requests, fromYaml.Requests)
+	require.Equal(t, fromHCL.Scenarios, fromYaml.Scenarios)
+	require.Len(t, fromHCL.Scenarios, 1)
+	sc := fromHCL.Scenarios[0]
+	assert.Equal(t, []config.ConditionConfig{{
+		Name: "save_item",
+		If:   "eq .request.get_item.status 404",
+		Then: []string{"create_item"},
+		Else: []string{"update_item"},
+	}}, sc.Conditions)
+	assert.Equal(t, []config.LoopConfig{{
+		Name:     "wait_ready",
+		Requests: []string{"status_req", "sleep(50)"},
+		Until:    "{{ .request.status_req.postprocessor.ready }}",
+		Max:      5,
+	}}, sc.Loops)
+	weights := []int64{70, 30}
+	assert.Equal(t, []config.ChoiceConfig{{
+		Name: "browse",
+		Options: []config.ChoiceOptionConfig{
+			{Weight: &weights[0], Requests: []string{"list_req"}},
+			{Weight: &weights[1], Requests: []string{"search_req(2)"}},
+		},
+	}}, sc.Choices)
+	assert.Equal(t, "abort", sc.OnError)
+	assert.Equal(t, int64(10), sc.AbortAfter)
+	assert.Equal(t, &config.RetryConfig{Attempts: 2, Backoff: 100, MaxBackoff: 150}, fromHCL.Requests[0].Retry)
+	assert.Equal(t, "continue", fromHCL.Requests[4].OnError)
+	assert.Equal(t, "instance", sc.CookieJar)
+	assert.Equal(t, map[string]string{"ready": "request.status_req.postprocessor.ready"}, fromHCL.Requests[3].Session)
+
+	listPostprocessors := fromHCL.Requests[4].Postprocessors
+	require.Len(t, listPostprocessors, 2)
+	regexpPostprocessor, ok := listPostprocessors[0].(*postprocessor.VarRegexpPostprocessor)
+	require.True(t, ok)
+	assert.Equal(t, `name="csrf" value="(\w+)"`, regexpPostprocessor.Mapping["csrf"].String())
+	assert.Equal(t, map[string]postprocessor.Boundary{"item": {Left: "<li>", Right: "</li>"}},
+		listPostprocessors[1].(*postprocessor.VarBoundaryPostprocessor).Boundaries)
+	searchPostprocessors := fromHCL.Requests[5].Postprocessors
+	require.Len(t, searchPostprocessors, 4)
+	assert.IsType(t, &postprocessor.VarStatusPostprocessor{}, searchPostprocessors[0])
+	assert.Equal(t, &postprocessor.VarCookiePostprocessor{Mapping: map[string]string{"sid": "session"}}, searchPostprocessors[1])
+	samplePostprocessor, ok := searchPostprocessors[2].(*postprocessor.SamplePostprocessor)
+	require.True(t, ok)
+	assert.Equal(t, map[string]string{"cache": "X-Cache"}, samplePostprocessor.Labels)
+	assert.Equal(t, map[string]string{"items": "$.items"}, samplePostprocessor.Metrics)
+	expect, ok := searchPostprocessors[3].(*postprocessor.AssertExpectPostprocessor)
+	require.True(t, ok)
+	searchResp := &http.Response{StatusCode: 200}
+	_, err = expect.ProcessTimed(searchResp, strings.NewReader(`{"items": [], "total": 2}`), 100*time.Millisecond)
+	assert.NoError(t, err)
+	_, err = expect.ProcessTimed(searchResp, strings.NewReader(`{"items": [], "total": 0}`), time.Second)
+	assert.True(t, scenario.IsAssertError(err))
+}
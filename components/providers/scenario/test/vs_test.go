@@ -74,3 +74,103 @@ src:
 	require.True(t, ok)
 	require.Equal(t, "json_src", jsonVS.GetName())
 }
+
+func Test_decode_parseVariableSourceUnique(t *testing.T) {
+	const exampleVariableSourceYAML = `
+csv:
+ type: "file/csv"
+ name: "users_src"
+ file: "_files/users.csv"
+ unique: true
+ on_exhausted: recycle
+lines:
+ type: "file/lines"
+ name: "words"
+ file: "_files/words.txt"
+ skip_empty: true
+ unique: true
+sqlite:
+ type: "sqlite"
+ name: "db_users"
+ file: "_files/users.db"
+ query: "SELECT id, login FROM users"
+`
+
+	_import.Import(nil)
+	testOnce.Do(func() {
+		pluginconfig.AddHooks()
+	})
+
+	data := make(map[string]any)
+	err := yaml.Unmarshal([]byte(exampleVariableSourceYAML), &data)
+	require.NoError(t, err)
+
+	out := struct {
+		CSV    vs.VariableSource `yaml:"csv"`
+		Lines  vs.VariableSource `yaml:"lines"`
+		SQLite vs.VariableSource `yaml:"sqlite"`
+	}{}
+
+	err = config.DecodeAndValidate(data, &out)
+	require.NoError(t, err)
+
+	csvVS, ok := out.CSV.(*vs.VariableSourceCsv)
+	require.True(t, ok)
+	require.Equal(t, vs.UniqueConfig{Unique: true, OnExhausted: vs.OnExhaustedRecycle}, csvVS.UniqueConfig)
+
+	linesVS, ok := out.Lines.(*vs.VariableSourceLines)
+	require.True(t, ok)
+	require.Equal(t, "_files/words.txt", linesVS.File)
+	require.True(t, linesVS.SkipEmpty)
+	require.True(t, linesVS.Unique)
+
+	sqliteVS, ok := out.SQLite.(*vs.VariableSourceSQLite)
+	require.True(t, ok)
+	require.Equal(t, "_files/users.db", sqliteVS.File)
+	require.Equal(t, "SELECT id, login FROM users", sqliteVS.Query)
+}
+
+func Test_decode_parseVariableSourceStream(t *testing.T) {
+	const exampleVariableSourceYAML = `
+csv:
+ type: "file/csv"
+ name: "users_src"
+ file: "_files/users.csv"
+ stream: true
+ buffer: 100
+ shuffle_window: 10
+jsonl:
+ type: "file/jsonl"
+ name: "events"
+ file: "_files/events.jsonl"
+ stream: true
+ on_exhausted: recycle
+`
+
+	_import.Import(nil)
+	testOnce.Do(func() {
+		pluginconfig.AddHooks()
+	})
+
+	data := make(map[string]any)
+	err := yaml.Unmarshal([]byte(exampleVariableSourceYAML), &data)
+	require.NoError(t, err)
+
+	out := struct {
+		CSV   vs.VariableSource `yaml:"csv"`
+		JSONL vs.VariableSource `yaml:"jsonl"`
+	}{}
+
+	err = config.DecodeAndValidate(data, &out)
+	require.NoError(t, err)
+
+	csvVS, ok := out.CSV.(*vs.VariableSourceCsv)
+	require.True(t, ok)
+	require.Equal(t, vs.StreamConfig{Stream: true, Buffer: 100, ShuffleWindow: 10}, csvVS.StreamConfig)
+
+	jsonlVS, ok := out.JSONL.(*vs.VariableSourceJSONL)
+	require.True(t, ok)
+	require.Equal(t, "_files/events.jsonl", jsonlVS.File)
+	require.True(t, jsonlVS.Stream)
+	require.Equal(t, vs.OnExhaustedRecycle, jsonlVS.OnExhausted)
+}
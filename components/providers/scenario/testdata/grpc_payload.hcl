@@ -32,6 +32,16 @@ EOF
     payload     = ["token"]
     status_code = 200
   }
+  postprocessor "var/jsonpath" {
+    mapping = {
+      auth_token = "$.token"
+    }
+  }
+  postprocessor "var/status" {
+    mapping = {
+      auth_status = "name"
+    }
+  }
 }
 
 call "list_req" {
@@ -41,7 +51,7 @@ call "list_req" {
     "metadata" = "server.proto"
   }
   payload = <<EOF
-{"user_id": {{.request.auth_req.postprocessor.userId}}, "token": "{{.request.auth_req.postprocessor.token}}"}
+{"user_id": {{.request.auth_req.postprocessor.userId}}, "token": "{{.request.auth_req.postprocessor.auth_token}}"}
 EOF
 }
 
@@ -0,0 +1,148 @@
+request "get_item" {
+  method = "GET"
+  uri    = "/item"
+  headers = {
+    Useragent = "Yandex"
+  }
+  tag    = "get_item"
+  retry {
+    attempts    = 2
+    backoff     = 100
+    max_backoff = 150
+  }
+}
+request "create_item" {
+  method = "POST"
+  uri    = "/item"
+  headers = {
+    Useragent = "Yandex"
+  }
+  tag    = "create_item"
+  body   = "{}"
+}
+request "update_item" {
+  method = "PUT"
+  uri    = "/item"
+  headers = {
+    Useragent = "Yandex"
+  }
+  tag    = "update_item"
+  body   = "{}"
+}
+request "status_req" {
+  method = "GET"
+  uri    = "/status"
+  headers = {
+    Useragent = "Yandex"
+  }
+  tag    = "status"
+  postprocessor "var/jsonpath" {
+    mapping = {
+      ready = "$.ready"
+    }
+  }
+  session = {
+    ready = "request.status_req.postprocessor.ready"
+  }
+}
+request "list_req" {
+  method = "GET"
+  uri    = "/list"
+  headers = {
+    Useragent = "Yandex"
+  }
+  tag    = "list"
+  on_error = "continue"
+  postprocessor "var/regexp" {
+    mapping = {
+      csrf = "name=\"csrf\" value=\"(\\w+)\""
+    }
+    match = "random"
+  }
+  postprocessor "var/boundary" {
+    boundaries = {
+      item = {
+        left  = "<li>"
+        right = "</li>"
+      }
+    }
+    match = -1
+  }
+}
+request "search_req" {
+  method = "GET"
+  uri    = "/search"
+  headers = {
+    Useragent = "Yandex"
+  }
+  tag    = "search"
+  postprocessor "var/status" {
+    mapping = {
+      code = "code"
+    }
+  }
+  postprocessor "var/cookie" {
+    mapping = {
+      sid = "session"
+    }
+  }
+  postprocessor "sample" {
+    labels = {
+      cache = "X-Cache"
+    }
+    metrics = {
+      items = "$.items"
+    }
+  }
+  postprocessor "assert/expect" {
+    status        = ["2xx", "!204"]
+    response_time = "500ms"
+    schema        = <<EOF
+{"type": "object", "required": ["items"]}
+EOF
+    jsonpath "$.total" {
+      op    = "ge"
+      value = 1
+    }
+    jsonpath "$.error" {
+      op  = "exists"
+      not = true
+    }
+  }
+}
+
+scenario "checkout" {
+  on_error    = "abort"
+  abort_after = 10
+  cookie_jar  = "instance"
+  requests = [
+    "get_item",
+    "save_item",
+    "wait_ready",
+    "sleep(100)",
+    "browse(2)",
+  ]
+
+  condition "save_item" {
+    if   = "eq .request.get_item.status 404"
+    then = ["create_item"]
+    else = ["update_item"]
+  }
+
+  loop "wait_ready" {
+    requests = ["status_req", "sleep(50)"]
+    until    = "{{ .request.status_req.postprocessor.ready }}"
+    max      = 5
+  }
+
+  choice "browse" {
+    option {
+      weight   = 70
+      requests = ["list_req"]
+    }
+    option {
+      weight   = 30
+      requests = ["search_req(2)"]
+    }
+  }
+}
@@ -0,0 +1,73 @@
+variable_source "vars" "variables" {
+  variables = {
+    host = "example.com"
+  }
+}
+
+request "auth" {
+  method  = "POST"
+  uri     = "/auth"
+  headers = {
+    Host = "{{ .source.vars.hots }}"
+  }
+  postprocessor "var/jsonpath" {
+    mapping = {
+      token = "$.token"
+    }
+  }
+}
+
+request "list" {
+  method  = "GET"
+  uri     = "/list?token={{ .request.auth.postprocessor.tokn }}"
+  headers = {}
+  body    = "{{ .request.list.status"
+}
+
+request "order" {
+  method  = "POST"
+  uri     = "/order/{{ .request.pay.postprocessor.id }}"
+  headers = {}
+  preprocessor {
+    mapping = {
+      user = "source.users[next].id"
+    }
+  }
+}
+
+request "pay" {
+  method  = "POST"
+  uri     = "/pay"
+  headers = {}
+  postprocessor "var/jsonpath" {
+    mapping = {
+      id = "$.id"
+    }
+  }
+}
+
+request "unused" {
+  method  = "GET"
+  uri     = "/"
+  headers = {}
+}
+
+scenario "main" {
+  weight   = -1
+  requests = ["sleep(10)", "auth", "order", "pay", "checkout", "browse"]
+
+  condition "checkout" {
+    if   = "eq .request.pay.status 200"
+    then = ["delete"]
+  }
+
+  choice "browse" {
+    option {
+      weight   = -5
+      requests = ["list"]
+    }
+    option {
+      requests = ["list"]
+    }
+  }
+}
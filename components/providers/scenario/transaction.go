@@ -0,0 +1,90 @@
+package scenario
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/yandex/pandora/core/aggregator/netsample"
+)
+
+// Transaction measures one scenario execution, so it can be reported as a single sample
+// in addition to samples of scenario steps.
+// Transaction sample is tagged by scenario name. Duration of transaction excludes sleeps made via Sleep:
+// step sleeps and retry delays.
+// Successful transaction has proto code of successful step of scenario protocol, e.g. 200 for HTTP.
+// Failed transaction has zero proto code, error of the failed step and name of the failed step as
+// additional tag.
+type Transaction struct {
+	ctx        context.Context
+	sample     *netsample.Sample
+	okCode     int
+	start      time.Time
+	slept      time.Duration
+	failedStep string
+	err        error
+}
+
+// ErrShootingStopped is returned by Transaction.Sleep, when shooting is stopped during sleep.
+// Scenario should be finished without reporting of transaction then.
+var ErrShootingStopped = errors.New("shooting stopped")
+
+// StartTransaction starts transaction of scenario. okCode is a proto code of successful transaction.
+// ctx interrupts sleeps, when shooting is stopped. It may be nil, if gun runs out of engine.
+func StartTransaction(ctx context.Context, scenarioName string, okCode int) *Transaction {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return &Transaction{
+		ctx:    ctx,
+		sample: netsample.Acquire(scenarioName),
+		okCode: okCode,
+		start:  time.Now(),
+	}
+}
+
+// Sleep sleeps for d. Sleep time is not included into transaction duration.
+// ErrShootingStopped is returned, if shooting is stopped before d passes.
+func (t *Transaction) Sleep(d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	start := time.Now()
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		t.slept += time.Since(start)
+		return nil
+	case <-t.ctx.Done():
+		t.slept += time.Since(start)
+		return ErrShootingStopped
+	}
+}
+
+// Fail marks transaction as failed on step. Only the first failure is kept.
+func (t *Transaction) Fail(step string, err error) {
+	if t.err != nil || err == nil {
+		return
+	}
+	t.failedStep = step
+	t.err = err
+}
+
+// Err returns error of the failed step.
+func (t *Transaction) Err() error {
+	return t.err
+}
+
+// Finish returns transaction sample. Caller should report it to aggregator.
+func (t *Transaction) Finish() *netsample.Sample {
+	if t.err == nil {
+		t.sample.SetUserProto(t.okCode)
+	} else {
+		t.sample.AddTag(t.failedStep)
+		t.sample.SetUserProto(0)
+		t.sample.SetErr(t.err)
+	}
+	t.sample.SetUserDuration(time.Since(t.start) - t.slept)
+	return t.sample
+}
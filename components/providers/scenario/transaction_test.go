@@ -0,0 +1,54 @@
+package scenario
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransaction(t *testing.T) {
+	rttMicro := func(t *testing.T, sample interface{ String() string }) time.Duration {
+		fields := strings.Split(sample.String(), "\t")
+		require.Greater(t, len(fields), 2)
+		rtt, err := strconv.Atoi(fields[2])
+		require.NoError(t, err)
+		return time.Duration(rtt) * time.Microsecond
+	}
+
+	t.Run("success excludes sleeps", func(t *testing.T) {
+		tx := StartTransaction(nil, "scenario", 200)
+		require.NoError(t, tx.Sleep(50*time.Millisecond))
+		sample := tx.Finish()
+
+		assert.Equal(t, "scenario", sample.Tags())
+		assert.Equal(t, 200, sample.ProtoCode())
+		assert.NoError(t, sample.Err())
+		assert.Less(t, rttMicro(t, sample), 50*time.Millisecond)
+	})
+
+	t.Run("failure keeps first failed step", func(t *testing.T) {
+		tx := StartTransaction(nil, "scenario", 200)
+		tx.Fail("login", errors.New("assert failed"))
+		tx.Fail("loop", errors.New("loop failed"))
+		sample := tx.Finish()
+
+		assert.Equal(t, "scenario|login", sample.Tags())
+		assert.Equal(t, 0, sample.ProtoCode())
+		assert.EqualError(t, sample.Err(), "assert failed")
+	})
+
+	t.Run("sleep is interrupted", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		tx := StartTransaction(ctx, "scenario", 200)
+		time.AfterFunc(10*time.Millisecond, cancel)
+		start := time.Now()
+		assert.ErrorIs(t, tx.Sleep(time.Minute), ErrShootingStopped)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
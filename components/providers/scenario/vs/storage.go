@@ -1,5 +1,10 @@
 package vs
 
+import (
+	"errors"
+	"io"
+)
+
 func NewVariableStorage() *SourceStorage {
 	return &SourceStorage{
 		sources: make(map[string]any),
@@ -17,3 +22,24 @@ func (s *SourceStorage) AddSource(name string, variables any) {
 func (s *SourceStorage) Variables() map[string]any {
 	return s.sources
 }
+
+// Exhausted returns true, if any source has given all its unique rows, and shooting should be stopped.
+func (s *SourceStorage) Exhausted() bool {
+	for _, v := range s.sources {
+		if e, ok := v.(interface{ Exhausted() bool }); ok && e.Exhausted() {
+			return true
+		}
+	}
+	return false
+}
+
+// Close stops sources, that read rows while shooting, e.g. StreamRows.
+func (s *SourceStorage) Close() error {
+	var err error
+	for _, v := range s.sources {
+		if c, ok := v.(io.Closer); ok {
+			err = errors.Join(err, c.Close())
+		}
+	}
+	return err
+}
@@ -0,0 +1,191 @@
+package vs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+
+	"github.com/yandex/pandora/lib/mp"
+)
+
+const defaultStreamBuffer = 1000
+
+// StreamConfig is embedded into configs of sources, that can read rows from disk while shooting
+// instead of loading the whole file into memory.
+type StreamConfig struct {
+	// Stream makes `[next]` index give rows read from file by shared reader.
+	// Every row is given only once for all instances, as with Unique, and other indexes are not supported.
+	Stream bool
+	// Buffer is a number of rows read ahead. Default is 1000.
+	Buffer int `validate:"min=0"`
+	// ShuffleWindow shuffles rows within window of this size. Zero or one means file order.
+	ShuffleWindow int `config:"shuffle_window" validate:"min=0"`
+}
+
+// rowReader reads rows of opened file until io.EOF.
+type rowReader interface {
+	Read() (any, error)
+	Close() error
+}
+
+// StreamRows reads rows by rowReader in background and gives them to `[next]` index.
+// Memory is bounded by buffer and shuffle window sizes.
+// After the end of file it reopens file, if recycle is set, or returns mp.ErrExhausted and reports Exhausted otherwise.
+type StreamRows struct {
+	open    func() (rowReader, error)
+	recycle bool
+	window  int
+	rnd     *rand.Rand
+
+	rows      chan any
+	done      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+	finished  atomic.Bool
+	// err is set before rows is closed, so Next reads it after that.
+	err error
+}
+
+// NewStreamRows opens file by open and starts reading it.
+func NewStreamRows(open func() (rowReader, error), cfg StreamConfig, recycle bool) (*StreamRows, error) {
+	reader, err := open()
+	if err != nil {
+		return nil, err
+	}
+	buffer := cfg.Buffer
+	if buffer == 0 {
+		buffer = defaultStreamBuffer
+	}
+	s := &StreamRows{
+		open:    open,
+		recycle: recycle,
+		window:  cfg.ShuffleWindow,
+		rnd:     rand.New(rand.NewSource(rand.Int63())),
+		rows:    make(chan any, buffer),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go s.run(reader)
+	return s, nil
+}
+
+var _ mp.Stream = (*StreamRows)(nil)
+
+func (s *StreamRows) Next() (any, error) {
+	select {
+	case row, ok := <-s.rows:
+		if ok {
+			return row, nil
+		}
+	case <-s.done:
+		return nil, mp.ErrExhausted
+	}
+	if s.err != nil {
+		return nil, fmt.Errorf("%w: %w", mp.ErrExhausted, s.err)
+	}
+	return nil, mp.ErrExhausted
+}
+
+// Exhausted returns true, when all rows are consumed and recycle is not set, or file can't be read.
+func (s *StreamRows) Exhausted() bool {
+	return s.finished.Load() && len(s.rows) == 0
+}
+
+// Close stops reading and waits until file is closed.
+func (s *StreamRows) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+	<-s.stopped
+	return nil
+}
+
+func (s *StreamRows) run(reader rowReader) {
+	defer close(s.stopped)
+	defer close(s.rows)
+	defer s.finished.Store(true)
+	window := make([]any, 0, s.window)
+	for {
+		read, err := s.readAll(reader, &window)
+		err = errors.Join(err, reader.Close())
+		if err != nil {
+			s.err = err
+			return
+		}
+		if s.closed() {
+			return
+		}
+		if !s.recycle || read == 0 {
+			break
+		}
+		reader, err = s.open()
+		if err != nil {
+			s.err = err
+			return
+		}
+	}
+	for len(window) > 0 {
+		if !s.send(s.pick(&window)) {
+			return
+		}
+	}
+}
+
+// readAll sends rows of reader and returns number of read rows.
+// Rows are shuffled in window, and rows left in window are sent by the next call or by run.
+func (s *StreamRows) readAll(reader rowReader, window *[]any) (int, error) {
+	var read int
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return read, nil
+		}
+		if err != nil {
+			return read, err
+		}
+		read++
+		if s.window <= 1 {
+			if !s.send(row) {
+				return read, nil
+			}
+			continue
+		}
+		*window = append(*window, row)
+		if len(*window) == s.window {
+			if !s.send(s.pick(window)) {
+				return read, nil
+			}
+		}
+	}
+}
+
+// pick removes random row from window.
+func (s *StreamRows) pick(window *[]any) any {
+	w := *window
+	i := s.rnd.Intn(len(w))
+	row := w[i]
+	w[i] = w[len(w)-1]
+	*window = w[:len(w)-1]
+	return row
+}
+
+func (s *StreamRows) closed() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *StreamRows) send(row any) bool {
+	select {
+	case s.rows <- row:
+		return true
+	case <-s.done:
+		return false
+	}
+}
@@ -0,0 +1,161 @@
+package vs
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yandex/pandora/lib/mp"
+)
+
+func TestVariableSourceCsv_Stream(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	var content strings.Builder
+	content.WriteString("id,login\n")
+	for i := 0; i < 100; i++ {
+		content.WriteString(strings.Repeat("1", i+1) + ",user\n")
+	}
+	require.NoError(t, afero.WriteFile(fs, "users.csv", []byte(content.String()), 0644))
+
+	t.Run("every row once", func(t *testing.T) {
+		src, err := NewVSCSV(VariableSourceCsv{File: "users.csv", IgnoreFirstLine: true, StreamConfig: StreamConfig{Stream: true, Buffer: 3, ShuffleWindow: 10}}, fs)
+		require.NoError(t, err)
+		require.NoError(t, src.Init())
+		stream, ok := src.GetVariables().(*StreamRows)
+		require.True(t, ok)
+		defer stream.Close()
+
+		var mu sync.Mutex
+		var ids []string
+		var wg sync.WaitGroup
+		for w := 0; w < 4; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					row, err := stream.Next()
+					if err != nil {
+						assert.ErrorIs(t, err, mp.ErrExhausted)
+						return
+					}
+					mu.Lock()
+					ids = append(ids, row.(map[string]any)["id"].(string))
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+		require.Len(t, ids, 100)
+		sort.Slice(ids, func(i, j int) bool { return len(ids[i]) < len(ids[j]) })
+		for i, id := range ids {
+			require.Len(t, id, i+1)
+		}
+		assert.True(t, stream.Exhausted())
+	})
+
+	t.Run("file order", func(t *testing.T) {
+		src, err := NewVSCSV(VariableSourceCsv{File: "users.csv", IgnoreFirstLine: true, StreamConfig: StreamConfig{Stream: true}}, fs)
+		require.NoError(t, err)
+		require.NoError(t, src.Init())
+		vars := map[string]any{"source": map[string]any{"users": src.GetVariables()}}
+		iter := mp.NewNextIterator(0)
+		for i := 0; i < 3; i++ {
+			got, err := mp.GetMapValue(vars, "source.users[next].id", iter)
+			require.NoError(t, err)
+			assert.Equal(t, strings.Repeat("1", i+1), got)
+		}
+		_, err = mp.GetMapValue(vars, "source.users[0].id", iter)
+		assert.Error(t, err)
+		require.NoError(t, src.GetVariables().(*StreamRows).Close())
+	})
+
+	t.Run("recycle", func(t *testing.T) {
+		src, err := NewVSCSV(VariableSourceCsv{File: "users.csv", IgnoreFirstLine: true, UniqueConfig: UniqueConfig{OnExhausted: OnExhaustedRecycle}, StreamConfig: StreamConfig{Stream: true, Buffer: 1}}, fs)
+		require.NoError(t, err)
+		require.NoError(t, src.Init())
+		stream := src.GetVariables().(*StreamRows)
+		defer stream.Close()
+		for i := 0; i < 250; i++ {
+			row, err := stream.Next()
+			require.NoError(t, err)
+			assert.Len(t, row.(map[string]any)["id"], i%100+1)
+		}
+		assert.False(t, stream.Exhausted())
+	})
+
+	t.Run("closed with storage", func(t *testing.T) {
+		src, err := NewVSCSV(VariableSourceCsv{File: "users.csv", IgnoreFirstLine: true, UniqueConfig: UniqueConfig{OnExhausted: OnExhaustedRecycle}, StreamConfig: StreamConfig{Stream: true, Buffer: 1}}, fs)
+		require.NoError(t, err)
+		require.NoError(t, src.Init())
+		storage := NewVariableStorage()
+		storage.AddSource("users", src.GetVariables())
+		require.NoError(t, storage.Close())
+		stream := src.GetVariables().(*StreamRows)
+		assert.True(t, stream.finished.Load())
+		for {
+			if _, err := stream.Next(); err != nil {
+				assert.ErrorIs(t, err, mp.ErrExhausted)
+				break
+			}
+		}
+	})
+
+	t.Run("no file", func(t *testing.T) {
+		src, err := NewVSCSV(VariableSourceCsv{File: "missing.csv", StreamConfig: StreamConfig{Stream: true}}, fs)
+		require.NoError(t, err)
+		assert.Error(t, src.Init())
+	})
+}
+
+func TestVariableSourceJSONL_Init(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "events.jsonl", []byte("{\"id\":1}\n\n{\"id\":2,\"tags\":[\"a\"]}\n"), 0644))
+	require.NoError(t, afero.WriteFile(fs, "broken.jsonl", []byte("{\"id\":1}\n{\"id\":\n"), 0644))
+
+	t.Run("in memory", func(t *testing.T) {
+		src, err := NewVSJSONL(VariableSourceJSONL{Name: "events", File: "events.jsonl"}, fs)
+		require.NoError(t, err)
+		require.NoError(t, src.Init())
+		assert.Equal(t, []any{
+			map[string]any{"id": float64(1)},
+			map[string]any{"id": float64(2), "tags": []any{"a"}},
+		}, src.GetVariables())
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		src, err := NewVSJSONL(VariableSourceJSONL{Name: "events", File: "events.jsonl", StreamConfig: StreamConfig{Stream: true}}, fs)
+		require.NoError(t, err)
+		require.NoError(t, src.Init())
+		stream := src.GetVariables().(*StreamRows)
+		defer stream.Close()
+		row, err := stream.Next()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"id": float64(1)}, row)
+		_, err = stream.Next()
+		require.NoError(t, err)
+		_, err = stream.Next()
+		assert.ErrorIs(t, err, mp.ErrExhausted)
+		assert.True(t, stream.Exhausted())
+	})
+
+	t.Run("broken", func(t *testing.T) {
+		src, err := NewVSJSONL(VariableSourceJSONL{Name: "events", File: "broken.jsonl"}, fs)
+		require.NoError(t, err)
+		assert.Error(t, src.Init())
+
+		src, err = NewVSJSONL(VariableSourceJSONL{Name: "events", File: "broken.jsonl", StreamConfig: StreamConfig{Stream: true}}, fs)
+		require.NoError(t, err)
+		require.NoError(t, src.Init())
+		stream := src.GetVariables().(*StreamRows)
+		_, err = stream.Next()
+		require.NoError(t, err)
+		_, err = stream.Next()
+		assert.ErrorIs(t, err, mp.ErrExhausted)
+		assert.ErrorContains(t, err, "value 2")
+		assert.True(t, stream.Exhausted())
+	})
+}
@@ -0,0 +1,90 @@
+package vs
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/yandex/pandora/lib/mp"
+)
+
+const (
+	// OnExhaustedStop stops shooting, when all rows of unique source are consumed. Default.
+	OnExhaustedStop = "stop"
+	// OnExhaustedRecycle starts consuming rows of unique source from the beginning.
+	OnExhaustedRecycle = "recycle"
+)
+
+// UniqueConfig is embedded into configs of sources, that can give their rows to `[next]` index only once.
+type UniqueConfig struct {
+	// Unique makes `[next]` index give every row only once for all instances.
+	Unique bool
+	// OnExhausted is stop or recycle.
+	OnExhausted string `config:"on_exhausted"`
+}
+
+func (c UniqueConfig) validate() error {
+	switch c.OnExhausted {
+	case "", OnExhaustedStop, OnExhaustedRecycle:
+		return nil
+	}
+	return fmt.Errorf("invalid on_exhausted %q: should be one of %s, %s", c.OnExhausted, OnExhaustedStop, OnExhaustedRecycle)
+}
+
+// wrap returns rows as is, or as *UniqueRows if source is unique.
+func (c UniqueConfig) wrap(rows []any) any {
+	if !c.Unique {
+		return rows
+	}
+	return NewUniqueRows(rows, c.OnExhausted == OnExhaustedRecycle)
+}
+
+// UniqueRows gives every row to `[next]` index only once. Other indexes work as for slice.
+// Templates can't index it by index function and should use At and Len methods instead.
+// After all rows are consumed, it starts from the beginning, if recycle is set,
+// or returns mp.ErrExhausted and reports Exhausted otherwise.
+type UniqueRows struct {
+	rows    []any
+	recycle bool
+
+	mu        sync.Mutex
+	next      int
+	exhausted atomic.Bool
+}
+
+func NewUniqueRows(rows []any, recycle bool) *UniqueRows {
+	return &UniqueRows{rows: rows, recycle: recycle}
+}
+
+var _ mp.Sequence = (*UniqueRows)(nil)
+
+func (u *UniqueRows) Len() int {
+	return len(u.rows)
+}
+
+func (u *UniqueRows) At(i int) any {
+	return u.rows[i]
+}
+
+func (u *UniqueRows) Next() (any, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.next >= len(u.rows) {
+		if !u.recycle || len(u.rows) == 0 {
+			u.exhausted.Store(true)
+			return nil, mp.ErrExhausted
+		}
+		u.next = 0
+	}
+	row := u.rows[u.next]
+	u.next++
+	if !u.recycle && u.next == len(u.rows) {
+		u.exhausted.Store(true)
+	}
+	return row, nil
+}
+
+// Exhausted returns true, when all rows are consumed and recycle is not set.
+func (u *UniqueRows) Exhausted() bool {
+	return u.exhausted.Load()
+}
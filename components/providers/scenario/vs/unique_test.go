@@ -0,0 +1,96 @@
+package vs
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yandex/pandora/lib/mp"
+)
+
+func TestUniqueRows_Next(t *testing.T) {
+	t.Run("stop", func(t *testing.T) {
+		u := NewUniqueRows([]any{"a", "b"}, false)
+		got, err := u.Next()
+		require.NoError(t, err)
+		assert.Equal(t, "a", got)
+		assert.False(t, u.Exhausted())
+		got, err = u.Next()
+		require.NoError(t, err)
+		assert.Equal(t, "b", got)
+		assert.True(t, u.Exhausted())
+		_, err = u.Next()
+		assert.ErrorIs(t, err, mp.ErrExhausted)
+	})
+
+	t.Run("recycle", func(t *testing.T) {
+		u := NewUniqueRows([]any{"a", "b"}, true)
+		var got []any
+		for i := 0; i < 5; i++ {
+			row, err := u.Next()
+			require.NoError(t, err)
+			got = append(got, row)
+		}
+		assert.Equal(t, []any{"a", "b", "a", "b", "a"}, got)
+		assert.False(t, u.Exhausted())
+	})
+
+	t.Run("every row once", func(t *testing.T) {
+		const n = 1000
+		rows := make([]any, n)
+		for i := range rows {
+			rows[i] = i
+		}
+		u := NewUniqueRows(rows, false)
+		seen := make([]int, n)
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for w := 0; w < 8; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					row, err := u.Next()
+					if err != nil {
+						return
+					}
+					mu.Lock()
+					seen[row.(int)]++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+		for i, cnt := range seen {
+			require.Equal(t, 1, cnt, "row %d", i)
+		}
+	})
+
+	t.Run("map path", func(t *testing.T) {
+		u := NewUniqueRows([]any{map[string]any{"login": "john"}, map[string]any{"login": "jack"}}, false)
+		storage := NewVariableStorage()
+		storage.AddSource("users", u)
+		vars := map[string]any{"source": storage.Variables()}
+		iter := mp.NewNextIterator(0)
+
+		got, err := mp.GetMapValue(vars, "source.users[next].login", iter)
+		require.NoError(t, err)
+		assert.Equal(t, "john", got)
+		got, err = mp.GetMapValue(vars, "source.users[last].login", iter)
+		require.NoError(t, err)
+		assert.Equal(t, "jack", got)
+		got, err = mp.GetMapValue(vars, "source.users[next].login", iter)
+		require.NoError(t, err)
+		assert.Equal(t, "jack", got)
+		assert.True(t, storage.Exhausted())
+		_, err = mp.GetMapValue(vars, "source.users[next].login", iter)
+		assert.ErrorIs(t, err, mp.ErrExhausted)
+	})
+}
+
+func TestUniqueConfig_validate(t *testing.T) {
+	assert.NoError(t, UniqueConfig{}.validate())
+	assert.NoError(t, UniqueConfig{Unique: true, OnExhausted: OnExhaustedRecycle}.validate())
+	assert.Error(t, UniqueConfig{Unique: true, OnExhausted: "wrap"}.validate())
+}
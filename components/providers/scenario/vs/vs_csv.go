@@ -16,8 +16,11 @@ type VariableSourceCsv struct {
 	Fields          []string
 	IgnoreFirstLine bool `config:"ignore_first_line"`
 	Delimiter       string
+	UniqueConfig    `config:",squash"`
+	StreamConfig    `config:",squash"`
 	fs              afero.Fs
 	store           []map[string]string
+	shared          any
 }
 
 func (v *VariableSourceCsv) GetName() string {
@@ -25,11 +28,22 @@ func (v *VariableSourceCsv) GetName() string {
 }
 
 func (v *VariableSourceCsv) GetVariables() any {
+	if v.shared != nil {
+		return v.shared
+	}
 	return v.store
 }
 
 func (v *VariableSourceCsv) Init() (err error) {
 	const op = "VariableSourceCsv.Init"
+	if v.Stream {
+		stream, err := NewStreamRows(v.openStream, v.StreamConfig, v.OnExhausted == OnExhaustedRecycle)
+		if err != nil {
+			return fmt.Errorf("%s fs.Open %w", op, err)
+		}
+		v.shared = stream
+		return nil
+	}
 	var file afero.File
 	file, err = v.fs.Open(v.File)
 	if err != nil {
@@ -51,40 +65,74 @@ func (v *VariableSourceCsv) Init() (err error) {
 		return fmt.Errorf("%s readCsv %w", op, err)
 	}
 	v.store = store
+	if v.Unique {
+		rows := make([]any, len(store))
+		for i, row := range store {
+			rows[i] = csvRowToAny(row)
+		}
+		v.shared = v.wrap(rows)
+	}
 
 	return nil
 }
 
 func readCsv(file afero.File, ignoreFirstLine bool, delimiter string, fields []string) ([]map[string]string, error) {
-	const op = "readCsv"
+	reader := newCsvReader(file, ignoreFirstLine, delimiter, fields)
+	result := make([]map[string]string, 0)
+	for {
+		row, err := reader.read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, row)
+	}
+	return result, nil
+}
+
+type csvReader struct {
+	file            afero.File
+	reader          *csv.Reader
+	ignoreFirstLine bool
+	fields          []string
+}
+
+func newCsvReader(file afero.File, ignoreFirstLine bool, delimiter string, fields []string) *csvReader {
 	reader := csv.NewReader(file)
 	if delimiter != "" {
 		reader.Comma = rune(delimiter[0])
 	}
+	fields = append([]string(nil), fields...)
 	for i := range fields {
 		fields[i] = strings.Replace(fields[i], " ", "_", -1)
 	}
-	result := make([]map[string]string, 0)
+	return &csvReader{file: file, reader: reader, ignoreFirstLine: ignoreFirstLine, fields: fields}
+}
+
+func (r *csvReader) read() (map[string]string, error) {
+	const op = "readCsv"
 	for {
-		record, err := reader.Read()
+		record, err := r.reader.Read()
 		if err == io.EOF {
-			break
+			return nil, err
 		}
 		if err != nil {
 			return nil, fmt.Errorf("%s csv.Read %w", op, err)
 		}
-		if len(fields) == 0 {
-			fields = make([]string, len(record))
+		if len(r.fields) == 0 {
+			r.fields = make([]string, len(record))
 			for i := range record {
-				fields[i] = strings.Replace(record[i], " ", "_", -1)
+				r.fields[i] = strings.Replace(record[i], " ", "_", -1)
 			}
 		}
-		if ignoreFirstLine {
-			ignoreFirstLine = false
+		if r.ignoreFirstLine {
+			r.ignoreFirstLine = false
 			continue
 		}
 		row := make(map[string]string)
-		for i, field := range fields {
+		for i, field := range r.fields {
 			if field == "" {
 				field = strconv.Itoa(i)
 			}
@@ -94,12 +142,43 @@ func readCsv(file afero.File, ignoreFirstLine bool, delimiter string, fields []s
 				row[field] = record[i]
 			}
 		}
-		result = append(result, row)
+		return row, nil
 	}
-	return result, nil
+}
+
+// Read returns row as map[string]any, as rows of unique and stream sources are.
+func (r *csvReader) Read() (any, error) {
+	row, err := r.read()
+	if err != nil {
+		return nil, err
+	}
+	return csvRowToAny(row), nil
+}
+
+func (r *csvReader) Close() error {
+	return r.file.Close()
+}
+
+func csvRowToAny(row map[string]string) map[string]any {
+	m := make(map[string]any, len(row))
+	for k, val := range row {
+		m[k] = val
+	}
+	return m
+}
+
+func (v *VariableSourceCsv) openStream() (rowReader, error) {
+	file, err := v.fs.Open(v.File)
+	if err != nil {
+		return nil, err
+	}
+	return newCsvReader(file, v.IgnoreFirstLine, v.Delimiter, v.Fields), nil
 }
 
 func NewVSCSV(cfg VariableSourceCsv, fs afero.Fs) (VariableSource, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
 	cfg.fs = fs
 	return &cfg, nil
 }
@@ -0,0 +1,108 @@
+package vs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/spf13/afero"
+)
+
+// VariableSourceJSONL is a list of json values of file, written one per line.
+type VariableSourceJSONL struct {
+	Name         string
+	File         string
+	UniqueConfig `config:",squash"`
+	StreamConfig `config:",squash"`
+	fs           afero.Fs
+	store        any
+}
+
+func (v *VariableSourceJSONL) GetName() string {
+	return v.Name
+}
+
+func (v *VariableSourceJSONL) GetVariables() any {
+	return v.store
+}
+
+func (v *VariableSourceJSONL) Init() (err error) {
+	const op = "VariableSourceJSONL.Init"
+	if v.Stream {
+		stream, err := NewStreamRows(v.openStream, v.StreamConfig, v.OnExhausted == OnExhaustedRecycle)
+		if err != nil {
+			return fmt.Errorf("%s fs.Open %w", op, err)
+		}
+		v.store = stream
+		return nil
+	}
+	reader, err := v.openStream()
+	if err != nil {
+		return fmt.Errorf("%s fs.Open %w", op, err)
+	}
+	defer func() {
+		closeErr := reader.Close()
+		if closeErr != nil {
+			if err != nil {
+				err = fmt.Errorf("%s multiple errors faced: %w, with close err: %s", op, err, closeErr)
+			} else {
+				err = fmt.Errorf("%s, %w", op, closeErr)
+			}
+		}
+	}()
+
+	rows := make([]any, 0)
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%s %w", op, err)
+		}
+		rows = append(rows, row)
+	}
+	v.store = v.wrap(rows)
+	return nil
+}
+
+func (v *VariableSourceJSONL) openStream() (rowReader, error) {
+	file, err := v.fs.Open(v.File)
+	if err != nil {
+		return nil, err
+	}
+	return &jsonlReader{file: file, decoder: json.NewDecoder(file)}, nil
+}
+
+type jsonlReader struct {
+	file    afero.File
+	decoder *json.Decoder
+	line    int
+}
+
+func (r *jsonlReader) Read() (any, error) {
+	var row any
+	err := r.decoder.Decode(&row)
+	if err == io.EOF {
+		return nil, err
+	}
+	r.line++
+	if err != nil {
+		return nil, fmt.Errorf("decoder.Decode value %d %w", r.line, err)
+	}
+	return row, nil
+}
+
+func (r *jsonlReader) Close() error {
+	return r.file.Close()
+}
+
+func NewVSJSONL(cfg VariableSourceJSONL, fs afero.Fs) (VariableSource, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	cfg.fs = fs
+	return &cfg, nil
+}
+
+var _ VariableSource = (*VariableSourceJSONL)(nil)
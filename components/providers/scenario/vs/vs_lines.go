@@ -0,0 +1,81 @@
+package vs
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// VariableSourceLines is a list of lines of text file.
+type VariableSourceLines struct {
+	Name string
+	File string
+	// SkipEmpty skips empty and whitespace only lines.
+	SkipEmpty    bool `config:"skip_empty"`
+	UniqueConfig `config:",squash"`
+	fs           afero.Fs
+	store        any
+}
+
+func (v *VariableSourceLines) GetName() string {
+	return v.Name
+}
+
+func (v *VariableSourceLines) GetVariables() any {
+	return v.store
+}
+
+func (v *VariableSourceLines) Init() (err error) {
+	const op = "VariableSourceLines.Init"
+	var file afero.File
+	file, err = v.fs.Open(v.File)
+	if err != nil {
+		return fmt.Errorf("%s fs.Open %w", op, err)
+	}
+	defer func() {
+		closeErr := file.Close()
+		if closeErr != nil {
+			if err != nil {
+				err = fmt.Errorf("%s multiple errors faced: %w, with close err: %s", op, err, closeErr)
+			} else {
+				err = fmt.Errorf("%s, %w", op, closeErr)
+			}
+		}
+	}()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if v.SkipEmpty && strings.TrimSpace(line) == "" {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err = scanner.Err(); err != nil {
+		return fmt.Errorf("%s scanner.Scan %w", op, err)
+	}
+	if !v.Unique {
+		v.store = lines
+		return nil
+	}
+	rows := make([]any, len(lines))
+	for i, line := range lines {
+		rows[i] = line
+	}
+	v.store = v.wrap(rows)
+	return nil
+}
+
+func NewVSLines(cfg VariableSourceLines, fs afero.Fs) (VariableSource, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	cfg.fs = fs
+	return &cfg, nil
+}
+
+var _ VariableSource = (*VariableSourceLines)(nil)
@@ -0,0 +1,37 @@
+package vs
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVariableSourceLines_Init(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "words.txt", []byte("alpha\r\n\n  \nbeta gamma\n"), 0644))
+
+	t.Run("all lines", func(t *testing.T) {
+		src, err := NewVSLines(VariableSourceLines{Name: "words", File: "words.txt"}, fs)
+		require.NoError(t, err)
+		require.NoError(t, src.Init())
+		assert.Equal(t, []string{"alpha", "", "  ", "beta gamma"}, src.GetVariables())
+	})
+
+	t.Run("skip empty unique", func(t *testing.T) {
+		src, err := NewVSLines(VariableSourceLines{Name: "words", File: "words.txt", SkipEmpty: true, UniqueConfig: UniqueConfig{Unique: true}}, fs)
+		require.NoError(t, err)
+		require.NoError(t, src.Init())
+		u, ok := src.GetVariables().(*UniqueRows)
+		require.True(t, ok)
+		assert.Equal(t, 2, u.Len())
+		assert.Equal(t, "beta gamma", u.At(1))
+	})
+
+	t.Run("no file", func(t *testing.T) {
+		src, err := NewVSLines(VariableSourceLines{Name: "words", File: "missing.txt"}, fs)
+		require.NoError(t, err)
+		assert.Error(t, src.Init())
+	})
+}
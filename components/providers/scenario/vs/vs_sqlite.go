@@ -0,0 +1,95 @@
+package vs
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+
+	_ "modernc.org/sqlite" // registers sqlite driver
+)
+
+// VariableSourceSQLite is a list of rows returned by query to SQLite database file.
+// Every row is a map of column names to values.
+// Database is opened read only from OS file system.
+type VariableSourceSQLite struct {
+	Name         string
+	File         string `validate:"required"`
+	Query        string `validate:"required"`
+	UniqueConfig `config:",squash"`
+	store        any
+}
+
+func (v *VariableSourceSQLite) GetName() string {
+	return v.Name
+}
+
+func (v *VariableSourceSQLite) GetVariables() any {
+	return v.store
+}
+
+func (v *VariableSourceSQLite) Init() (err error) {
+	const op = "VariableSourceSQLite.Init"
+	dsn := (&url.URL{Scheme: "file", Opaque: v.File, RawQuery: "mode=ro"}).String()
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return fmt.Errorf("%s sql.Open %w", op, err)
+	}
+	defer func() {
+		err = errors.Join(err, db.Close())
+	}()
+
+	rows, err := db.Query(v.Query)
+	if err != nil {
+		return fmt.Errorf("%s db.Query %w", op, err)
+	}
+	defer func() {
+		err = errors.Join(err, rows.Close())
+	}()
+	columns, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("%s rows.Columns %w", op, err)
+	}
+	var store []map[string]any
+	values := make([]any, len(columns))
+	ptrs := make([]any, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if err = rows.Scan(ptrs...); err != nil {
+			return fmt.Errorf("%s rows.Scan %w", op, err)
+		}
+		row := make(map[string]any, len(columns))
+		for i, c := range columns {
+			if b, ok := values[i].([]byte); ok {
+				row[c] = string(b)
+			} else {
+				row[c] = values[i]
+			}
+		}
+		store = append(store, row)
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("%s rows.Next %w", op, err)
+	}
+	if !v.Unique {
+		v.store = store
+		return nil
+	}
+	uniqueRows := make([]any, len(store))
+	for i, row := range store {
+		uniqueRows[i] = row
+	}
+	v.store = v.wrap(uniqueRows)
+	return nil
+}
+
+func NewVSSQLite(cfg VariableSourceSQLite) (VariableSource, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+var _ VariableSource = (*VariableSourceSQLite)(nil)
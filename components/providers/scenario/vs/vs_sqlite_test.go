@@ -0,0 +1,51 @@
+package vs
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVariableSourceSQLite_Init(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "users.db")
+	db, err := sql.Open("sqlite", file)
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE users (id INTEGER, login TEXT, score REAL, note BLOB);
+INSERT INTO users VALUES (1, 'john', 1.5, 'a'), (2, 'jack', NULL, NULL);`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	t.Run("rows", func(t *testing.T) {
+		src, err := NewVSSQLite(VariableSourceSQLite{Name: "users", File: file, Query: "SELECT * FROM users ORDER BY id"})
+		require.NoError(t, err)
+		require.NoError(t, src.Init())
+		assert.Equal(t, []map[string]any{
+			{"id": int64(1), "login": "john", "score": 1.5, "note": "a"},
+			{"id": int64(2), "login": "jack", "score": nil, "note": nil},
+		}, src.GetVariables())
+	})
+
+	t.Run("unique", func(t *testing.T) {
+		src, err := NewVSSQLite(VariableSourceSQLite{Name: "users", File: file, Query: "SELECT login FROM users", UniqueConfig: UniqueConfig{Unique: true}})
+		require.NoError(t, err)
+		require.NoError(t, src.Init())
+		u, ok := src.GetVariables().(*UniqueRows)
+		require.True(t, ok)
+		assert.Equal(t, 2, u.Len())
+	})
+
+	t.Run("invalid query", func(t *testing.T) {
+		src, err := NewVSSQLite(VariableSourceSQLite{Name: "users", File: file, Query: "SELECT * FROM orders"})
+		require.NoError(t, err)
+		assert.Error(t, src.Init())
+	})
+
+	t.Run("no file", func(t *testing.T) {
+		src, err := NewVSSQLite(VariableSourceSQLite{Name: "users", File: filepath.Join(t.TempDir(), "missing.db"), Query: "SELECT 1"})
+		require.NoError(t, err)
+		assert.Error(t, src.Init())
+	})
+}
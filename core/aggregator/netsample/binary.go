@@ -0,0 +1,301 @@
+package netsample
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/yandex/pandora/core"
+	"github.com/yandex/pandora/core/aggregator"
+	"github.com/yandex/pandora/core/config"
+	"github.com/yandex/pandora/core/coreutil"
+	"github.com/yandex/pandora/core/datasink"
+	"github.com/yandex/pandora/lib/ioutil2"
+)
+
+// Binary sample format is compact alternative to phout for high RPS tests.
+//
+// File starts with 8 byte header: "PNDRSMP" and format version byte, that is 1 now.
+// Header is followed by records. Every record is uvarint length of record body and body:
+//
+//	varint  timestamp, Unix nanoseconds
+//	uvarint ammo ID
+//	uvarint tags length, tags
+//	uvarint fields number N, N varint fields in phout order:
+//	        rtt, connect, send, latency, receive, interval event (microseconds),
+//	        request bytes, response bytes, net code, proto code
+//	uvarint error length, error message
+//	uvarint labels number N, N pairs of uvarint key length, key, uvarint value length, value
+//	uvarint metrics number N, N pairs of uvarint name length, name, 8 byte little endian float64 value
+//
+// Labels and metrics are optional: records without them are valid.
+// Decoder ignores unknown trailing fields and bytes of record body, so fields could be
+// appended in the next versions without breaking old readers.
+// Varints are encoded as in encoding/binary.
+const (
+	BinaryMagic   = "PNDRSMP"
+	BinaryVersion = 1
+
+	binaryHeaderLen = len(BinaryMagic) + 1
+	// binaryMaxRecordLen protects decoder from huge allocation on corrupted input.
+	binaryMaxRecordLen = 16 * 1024 * 1024
+)
+
+type BinaryAggregatorConfig struct {
+	aggregator.EncoderAggregatorConfig `config:",squash"`
+	coreutil.BufferSizeConfig          `config:",squash"`
+}
+
+var _ = config.RegisterCustom(func(h config.ValidateHandle) {
+	conf := h.Value().(BinaryAggregatorConfig)
+	if datasink.Rotated(conf.Sink) {
+		h.ReportError("sink", "rotation is not supported by binary format: file is split only at new line characters")
+	}
+}, BinaryAggregatorConfig{})
+
+func DefaultBinaryAggregatorConfig() BinaryAggregatorConfig {
+	return BinaryAggregatorConfig{
+		EncoderAggregatorConfig: aggregator.DefaultEncoderAggregatorConfig(),
+	}
+}
+
+// NewBinaryAggregator returns aggregator, that writes samples in binary sample format.
+// Sink MUST NOT rotate files, because it splits files only at the new line characters. Config validation rejects rotated file sink.
+func NewBinaryAggregator(conf BinaryAggregatorConfig) core.Aggregator {
+	var newEncoder aggregator.NewSampleEncoder = func(w io.Writer, onFlush func()) aggregator.SampleEncoder {
+		w = ioutil2.NewCallbackWriter(w, onFlush)
+		return NewBinaryEncoder(w, conf.BufferSizeConfig)
+	}
+	return aggregator.NewEncoderAggregator(newEncoder, conf.EncoderAggregatorConfig)
+}
+
+// NewBinaryEncoder returns encoder, that writes header and then *Sample records.
+func NewBinaryEncoder(w io.Writer, conf coreutil.BufferSizeConfig) *BinaryEncoder {
+	buf := bufio.NewWriterSize(w, conf.BufferSizeOrDefault())
+	_, _ = buf.WriteString(BinaryMagic)
+	_ = buf.WriteByte(BinaryVersion)
+	return &BinaryEncoder{buf: buf}
+}
+
+type BinaryEncoder struct {
+	buf    *bufio.Writer
+	record []byte
+	length [binary.MaxVarintLen64]byte
+}
+
+var _ aggregator.SampleEncoder = (*BinaryEncoder)(nil)
+
+// Encode panics, if s is not *Sample.
+func (e *BinaryEncoder) Encode(s core.Sample) error {
+	e.record = appendBinary(e.record[:0], s.(*Sample))
+	n := binary.PutUvarint(e.length[:], uint64(len(e.record)))
+	if _, err := e.buf.Write(e.length[:n]); err != nil {
+		return err
+	}
+	_, err := e.buf.Write(e.record)
+	return err
+}
+
+func (e *BinaryEncoder) Flush() error {
+	return e.buf.Flush()
+}
+
+func appendBinary(dst []byte, s *Sample) []byte {
+	dst = binary.AppendVarint(dst, s.timeStamp.UnixNano())
+	dst = binary.AppendUvarint(dst, s.id)
+	dst = binary.AppendUvarint(dst, uint64(len(s.tags)))
+	dst = append(dst, s.tags...)
+	dst = binary.AppendUvarint(dst, fieldsNum)
+	for _, v := range s.fields {
+		dst = binary.AppendVarint(dst, int64(v))
+	}
+	var errMsg string
+	if s.err != nil {
+		errMsg = s.err.Error()
+	}
+	dst = binary.AppendUvarint(dst, uint64(len(errMsg)))
+	dst = append(dst, errMsg...)
+	if len(s.labels) == 0 && len(s.metrics) == 0 {
+		return dst
+	}
+	dst = binary.AppendUvarint(dst, uint64(len(s.labels)))
+	for _, l := range s.labels {
+		dst = appendBinaryString(dst, l.Key)
+		dst = appendBinaryString(dst, l.Value)
+	}
+	dst = binary.AppendUvarint(dst, uint64(len(s.metrics)))
+	for _, m := range s.metrics {
+		dst = appendBinaryString(dst, m.Name)
+		dst = binary.LittleEndian.AppendUint64(dst, math.Float64bits(m.Value))
+	}
+	return dst
+}
+
+func appendBinaryString(dst []byte, str string) []byte {
+	dst = binary.AppendUvarint(dst, uint64(len(str)))
+	return append(dst, str...)
+}
+
+// NewBinaryDecoder returns decoder of binary sample format. Header is checked on the first Decode.
+func NewBinaryDecoder(r io.Reader) *BinaryDecoder {
+	return &BinaryDecoder{r: bufio.NewReader(r)}
+}
+
+type BinaryDecoder struct {
+	r          *bufio.Reader
+	headerRead bool
+	record     []byte
+	records    int
+}
+
+// Decode returns the next sample, or io.EOF, when input is finished.
+// Returned samples are not owned by decoder.
+func (d *BinaryDecoder) Decode() (*Sample, error) {
+	if !d.headerRead {
+		if err := d.readHeader(); err != nil {
+			return nil, err
+		}
+		d.headerRead = true
+	}
+	length, err := binary.ReadUvarint(d.r)
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, d.wrap(err)
+	}
+	if length > binaryMaxRecordLen {
+		return nil, d.wrap(errors.Errorf("too long record: %v bytes", length))
+	}
+	if cap(d.record) < int(length) {
+		d.record = make([]byte, length)
+	}
+	d.record = d.record[:length]
+	if _, err := io.ReadFull(d.r, d.record); err != nil {
+		return nil, d.wrap(err)
+	}
+	s, err := parseBinary(d.record)
+	if err != nil {
+		return nil, d.wrap(err)
+	}
+	d.records++
+	return s, nil
+}
+
+func (d *BinaryDecoder) readHeader() error {
+	header := make([]byte, binaryHeaderLen)
+	_, err := io.ReadFull(d.r, header)
+	if err == io.EOF {
+		return errors.New("empty input: no binary sample header")
+	}
+	if err != nil || string(header[:len(BinaryMagic)]) != BinaryMagic {
+		return errors.New("invalid binary sample header: input is not in binary sample format")
+	}
+	if version := header[len(BinaryMagic)]; version != BinaryVersion {
+		return errors.Errorf("unsupported binary sample format version %v", version)
+	}
+	return nil
+}
+
+func (d *BinaryDecoder) wrap(err error) error {
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return errors.WithMessagef(err, "record %v decode failed", d.records)
+}
+
+func parseBinary(record []byte) (*Sample, error) {
+	r := bytes.NewReader(record)
+	ts, err := binary.ReadVarint(r)
+	if err != nil {
+		return nil, err
+	}
+	s := &Sample{timeStamp: time.Unix(0, ts)}
+	if s.id, err = binary.ReadUvarint(r); err != nil {
+		return nil, err
+	}
+	tags, err := readBinaryString(r)
+	if err != nil {
+		return nil, err
+	}
+	s.tags = tags
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < n; i++ {
+		v, err := binary.ReadVarint(r)
+		if err != nil {
+			return nil, err
+		}
+		if i < fieldsNum {
+			s.fields[i] = int(v)
+		}
+	}
+	errMsg, err := readBinaryString(r)
+	if err != nil {
+		return nil, err
+	}
+	if errMsg != "" {
+		s.err = errors.New(errMsg)
+	}
+	if r.Len() == 0 {
+		return s, nil
+	}
+	if err := parseBinaryCustom(r, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func parseBinaryCustom(r *bytes.Reader, s *Sample) error {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < n; i++ {
+		key, err := readBinaryString(r)
+		if err != nil {
+			return err
+		}
+		value, err := readBinaryString(r)
+		if err != nil {
+			return err
+		}
+		s.labels = append(s.labels, Label{key, value})
+	}
+	if n, err = binary.ReadUvarint(r); err != nil {
+		return err
+	}
+	var value [8]byte
+	for i := uint64(0); i < n; i++ {
+		name, err := readBinaryString(r)
+		if err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(r, value[:]); err != nil {
+			return err
+		}
+		s.metrics = append(s.metrics, Metric{name, math.Float64frombits(binary.LittleEndian.Uint64(value[:]))})
+	}
+	return nil
+}
+
+func readBinaryString(r *bytes.Reader) (string, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return "", err
+	}
+	if n > uint64(r.Len()) {
+		return "", errors.Errorf("string length %v is out of record", n)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
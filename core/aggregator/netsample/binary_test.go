@@ -0,0 +1,202 @@
+package netsample
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/c2h5oh/datasize"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yandex/pandora/core"
+	"github.com/yandex/pandora/core/config"
+	"github.com/yandex/pandora/core/coreutil"
+	"github.com/yandex/pandora/core/datasink"
+	"go.uber.org/zap"
+)
+
+func newFullTestSample() *Sample {
+	s := newTestSample()
+	s.SetErr(syscall.ECONNRESET)
+	s.SetConnectTime(time.Millisecond)
+	s.SetSendTime(2 * time.Millisecond)
+	s.SetLatency(3 * time.Millisecond)
+	s.SetReceiveTime(4 * time.Millisecond)
+	s.SetRequestBytes(100)
+	s.SetResponseBytes(-1)
+	s.SetLabel("cache", "hit")
+	s.SetLabel("region", "eu")
+	s.SetMetric("items", 12)
+	s.SetMetric("score", -0.5)
+	return s
+}
+
+func encodeBinary(t *testing.T, samples ...*Sample) []byte {
+	buf := &bytes.Buffer{}
+	enc := NewBinaryEncoder(buf, coreutil.BufferSizeConfig{})
+	for _, s := range samples {
+		require.NoError(t, enc.Encode(s))
+	}
+	require.NoError(t, enc.Flush())
+	return buf.Bytes()
+}
+
+func TestBinary_RoundTrip(t *testing.T) {
+	samples := []*Sample{newFullTestSample(), newTestSample(), {timeStamp: time.Unix(1, 1)}}
+	data := encodeBinary(t, samples...)
+	assert.Equal(t, BinaryMagic+"\x01", string(data[:binaryHeaderLen]))
+
+	dec := NewBinaryDecoder(bytes.NewReader(data))
+	for _, expected := range samples {
+		actual, err := dec.Decode()
+		require.NoError(t, err)
+		assert.Equal(t, expected.timeStamp.UnixNano(), actual.timeStamp.UnixNano())
+		assert.Equal(t, expected.tags, actual.tags)
+		assert.Equal(t, expected.id, actual.id)
+		assert.Equal(t, expected.fields, actual.fields)
+		assert.Equal(t, expected.labels, actual.labels)
+		assert.Equal(t, expected.metrics, actual.metrics)
+		if expected.err == nil {
+			assert.NoError(t, actual.err)
+		} else {
+			assert.EqualError(t, actual.err, expected.err.Error())
+		}
+		assert.Equal(t, expected.String(), actual.String())
+	}
+	_, err := dec.Decode()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestBinary_HeaderOnly(t *testing.T) {
+	_, err := NewBinaryDecoder(bytes.NewReader(encodeBinary(t))).Decode()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestBinaryDecoder_Errors(t *testing.T) {
+	data := encodeBinary(t, newFullTestSample(), newFullTestSample())
+	tests := []struct {
+		name  string
+		input []byte
+		err   string
+	}{
+		{"empty", nil, "empty input"},
+		{"phout", []byte(testSamplePhout), "invalid binary sample header"},
+		{"version", append([]byte(BinaryMagic), 2), "unsupported binary sample format version 2"},
+		{"truncated", data[:len(data)-1], "record 1 decode failed: unexpected EOF"},
+		{"too long", append([]byte(BinaryMagic+"\x01"), 0xff, 0xff, 0xff, 0xff, 0x0f), "too long record"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec := NewBinaryDecoder(bytes.NewReader(tt.input))
+			var err error
+			for err == nil {
+				_, err = dec.Decode()
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestBinaryDecoder_IgnoresUnknownFields(t *testing.T) {
+	// Record, that could be written by the next format version: with additional field and trailing bytes.
+	var record []byte
+	record = binary.AppendVarint(record, time.Unix(1484660999, 0).UnixNano())
+	record = binary.AppendUvarint(record, 42)
+	record = binary.AppendUvarint(record, 3)
+	record = append(record, "tag"...)
+	record = binary.AppendUvarint(record, fieldsNum+1)
+	for i := 0; i <= fieldsNum; i++ {
+		record = binary.AppendVarint(record, int64(i+1))
+	}
+	record = binary.AppendUvarint(record, 3)
+	record = append(record, "err"...)
+	record = binary.AppendUvarint(record, 1)
+	record = appendBinaryString(record, "key")
+	record = appendBinaryString(record, "value")
+	record = binary.AppendUvarint(record, 0)
+	record = append(record, 0x42, 0x42)
+
+	actual, err := parseBinary(record)
+	require.NoError(t, err)
+	assert.Equal(t, "tag", actual.tags)
+	assert.Equal(t, uint64(42), actual.id)
+	assert.Equal(t, [fieldsNum]int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, actual.fields)
+	assert.EqualError(t, actual.err, "err")
+	assert.Equal(t, []Label{{"key", "value"}}, actual.labels)
+}
+
+func TestBinaryAggregator(t *testing.T) {
+	sink := datasink.NewBuffer()
+	conf := DefaultBinaryAggregatorConfig()
+	conf.Sink = sink
+	testee := NewBinaryAggregator(conf)
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error)
+	go func() {
+		runErr <- testee.Run(ctx, core.AggregatorDeps{Log: zap.L()})
+	}()
+	for i := 0; i < 3; i++ {
+		testee.Report(newTestSample())
+	}
+	cancel()
+	require.NoError(t, <-runErr)
+
+	out := &bytes.Buffer{}
+	err := ConvertBinary(out, sink, ConvertConfig{Format: FormatPhout, ID: true})
+	require.NoError(t, err)
+	assert.Equal(t, strings.Repeat(testSamplePhout+"\n", 3), out.String())
+}
+
+func TestBinaryAggregatorConfig_Rotation(t *testing.T) {
+	conf := DefaultBinaryAggregatorConfig()
+	conf.Sink = datasink.NewFile(afero.NewMemMapFs(), datasink.FileConfig{Path: "samples.bin", Compression: datasink.CompressionGzip})
+	assert.NoError(t, config.Validate(conf))
+	conf.Sink = datasink.NewFile(afero.NewMemMapFs(), datasink.FileConfig{Path: "samples.bin", Rotation: datasink.RotationConfig{MaxSize: datasize.MB}})
+	assert.ErrorContains(t, config.Validate(conf), "rotation is not supported by binary format")
+}
+
+func TestConvertBinary(t *testing.T) {
+	data := encodeBinary(t, newTestSample(), newFullTestSample())
+
+	t.Run("phout", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		require.NoError(t, ConvertBinary(out, bytes.NewReader(data), ConvertConfig{Format: FormatPhout}))
+		lines := strings.Split(out.String(), "\n")
+		require.Len(t, lines, 3)
+		assert.Equal(t, testSampleNoIDPhout, lines[0])
+		assert.Equal(t, string(appendPhout(newFullTestSample(), nil, false)), lines[1])
+	})
+
+	t.Run("jsonlines", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		require.NoError(t, ConvertBinary(out, bytes.NewReader(data), ConvertConfig{Format: FormatJSONLines}))
+		dec := json.NewDecoder(out)
+		var first, second sampleJSON
+		require.NoError(t, dec.Decode(&first))
+		require.NoError(t, dec.Decode(&second))
+		assert.Equal(t, sampleJSON{
+			Timestamp: 1484660999002000, Tag: "tag1|tag2", ID: 42,
+			RTTMicro: 333333, NetCode: 13, ProtoCode: ProtoCodeError,
+		}, first)
+		assert.Equal(t, syscall.ECONNRESET.Error(), second.Error)
+		assert.Equal(t, 1000, second.ConnectMicro)
+		assert.Equal(t, 100, second.RequestBytes)
+		assert.Equal(t, int(syscall.ECONNRESET), second.NetCode)
+		assert.Equal(t, map[string]string{"cache": "hit", "region": "eu"}, second.Labels)
+		assert.Equal(t, map[string]float64{"items": 12, "score": -0.5}, second.Metrics)
+		assert.False(t, dec.More())
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		err := ConvertBinary(io.Discard, bytes.NewReader(data), ConvertConfig{Format: "csv"})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,172 @@
+package netsample
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	FormatPhout     = "phout"
+	FormatJSONLines = "jsonlines"
+)
+
+type ConvertConfig struct {
+	// Format of output: phout or jsonlines.
+	Format string
+	// ID adds ammo ids to phout tags, as phout aggregator id option.
+	ID bool
+}
+
+// ConvertBinary reads samples in binary sample format from r, and writes them to w in conf.Format.
+func ConvertBinary(w io.Writer, r io.Reader, conf ConvertConfig) error {
+	bw := bufio.NewWriter(w)
+	var write func(s *Sample) error
+	switch conf.Format {
+	case FormatPhout:
+		var buf []byte
+		write = func(s *Sample) error {
+			buf = appendPhout(s, buf[:0], conf.ID)
+			buf = append(buf, '\n')
+			_, err := bw.Write(buf)
+			return err
+		}
+	case FormatJSONLines:
+		enc := json.NewEncoder(bw)
+		write = func(s *Sample) error {
+			return enc.Encode(s)
+		}
+	default:
+		return errors.Errorf("unknown output format %q: should be %s or %s", conf.Format, FormatPhout, FormatJSONLines)
+	}
+
+	decoder := NewBinaryDecoder(r)
+	for {
+		s, err := decoder.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := write(s); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// sampleJSON is JSON representation of Sample in jsonlines output.
+type sampleJSON struct {
+	// Timestamp in Unix microseconds.
+	Timestamp     int64  `json:"ts"`
+	Tag           string `json:"tag"`
+	ID            uint64 `json:"id"`
+	RTTMicro      int    `json:"rtt"`
+	ConnectMicro  int    `json:"connect"`
+	SendMicro     int    `json:"send"`
+	LatencyMicro  int    `json:"latency"`
+	ReceiveMicro  int    `json:"receive"`
+	IntervalEvent int    `json:"interval_event"`
+	RequestBytes  int    `json:"request_bytes"`
+	ResponseBytes int    `json:"response_bytes"`
+	NetCode       int    `json:"net_code"`
+	ProtoCode     int    `json:"proto_code"`
+	Error         string `json:"error,omitempty"`
+	// Labels and Metrics are custom values of sample. They are not kept in order on decode.
+	Labels  map[string]string  `json:"labels,omitempty"`
+	Metrics map[string]float64 `json:"metrics,omitempty"`
+}
+
+func newSampleJSON(s *Sample) sampleJSON {
+	j := sampleJSON{
+		Timestamp:     s.timeStamp.UnixMicro(),
+		Tag:           s.tags,
+		ID:            s.id,
+		RTTMicro:      s.get(keyRTTMicro),
+		ConnectMicro:  s.get(keyConnectMicro),
+		SendMicro:     s.get(keySendMicro),
+		LatencyMicro:  s.get(keyLatencyMicro),
+		ReceiveMicro:  s.get(keyReceiveMicro),
+		IntervalEvent: s.get(keyIntervalEventMicro),
+		RequestBytes:  s.get(keyRequestBytes),
+		ResponseBytes: s.get(keyResponseBytes),
+		NetCode:       s.get(keyErrno),
+		ProtoCode:     s.get(keyProtoCode),
+	}
+	if s.err != nil {
+		j.Error = s.err.Error()
+	}
+	if len(s.labels) > 0 {
+		j.Labels = make(map[string]string, len(s.labels))
+		for _, l := range s.labels {
+			j.Labels[l.Key] = l.Value
+		}
+	}
+	if len(s.metrics) > 0 {
+		j.Metrics = make(map[string]float64, len(s.metrics))
+		for _, m := range s.metrics {
+			// JSON has no NaN and infinities.
+			if !math.IsNaN(m.Value) && !math.IsInf(m.Value, 0) {
+				j.Metrics[m.Name] = m.Value
+			}
+		}
+	}
+	return j
+}
+
+func (j sampleJSON) sample() *Sample {
+	s := &Sample{
+		timeStamp: time.UnixMicro(j.Timestamp),
+		tags:      j.Tag,
+		id:        j.ID,
+	}
+	s.set(keyRTTMicro, j.RTTMicro)
+	s.set(keyConnectMicro, j.ConnectMicro)
+	s.set(keySendMicro, j.SendMicro)
+	s.set(keyLatencyMicro, j.LatencyMicro)
+	s.set(keyReceiveMicro, j.ReceiveMicro)
+	s.set(keyIntervalEventMicro, j.IntervalEvent)
+	s.set(keyRequestBytes, j.RequestBytes)
+	s.set(keyResponseBytes, j.ResponseBytes)
+	s.set(keyErrno, j.NetCode)
+	s.set(keyProtoCode, j.ProtoCode)
+	if j.Error != "" {
+		s.err = errors.New(j.Error)
+	}
+	for _, key := range sortedKeys(j.Labels) {
+		s.SetLabel(key, j.Labels[key])
+	}
+	for _, name := range sortedKeys(j.Metrics) {
+		s.SetMetric(name, j.Metrics[name])
+	}
+	return s
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// MarshalJSON makes jsonlines aggregator output of samples the same, as converted from binary format.
+func (s *Sample) MarshalJSON() ([]byte, error) {
+	return json.Marshal(newSampleJSON(s))
+}
+
+func (s *Sample) UnmarshalJSON(data []byte) error {
+	var j sampleJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	*s = *j.sample()
+	return nil
+}
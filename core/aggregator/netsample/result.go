@@ -0,0 +1,126 @@
+package netsample
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ResultDecoder reads samples from results file.
+type ResultDecoder interface {
+	// Decode returns the next sample, or io.EOF, when input is finished.
+	Decode() (*Sample, error)
+}
+
+// NewResultDecoder returns decoder of phout, jsonlines or binary results.
+// Format is detected by the beginning of input.
+func NewResultDecoder(r io.Reader) ResultDecoder {
+	br := bufio.NewReader(r)
+	prefix, _ := br.Peek(len(BinaryMagic))
+	switch {
+	case string(prefix) == BinaryMagic:
+		return NewBinaryDecoder(br)
+	case bytes.HasPrefix(bytes.TrimLeft(prefix, " \t\r\n"), []byte("{")):
+		return &lineDecoder{r: br, parse: parseJSONLine}
+	default:
+		return &lineDecoder{r: br, parse: ParsePhout}
+	}
+}
+
+type lineDecoder struct {
+	r     *bufio.Reader
+	parse func(line []byte) (*Sample, error)
+	line  int
+}
+
+func (d *lineDecoder) Decode() (*Sample, error) {
+	for {
+		line, err := d.r.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			// Rare long line.
+			rest, restErr := d.r.ReadBytes('\n')
+			line, err = append(append([]byte(nil), line...), rest...), restErr
+		}
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		d.line++
+		trimmed := bytes.TrimSpace(line)
+		if len(trimmed) == 0 {
+			if err == io.EOF {
+				return nil, io.EOF
+			}
+			continue
+		}
+		s, parseErr := d.parse(trimmed)
+		if parseErr != nil {
+			return nil, errors.WithMessagef(parseErr, "line %v", d.line)
+		}
+		return s, nil
+	}
+}
+
+func parseJSONLine(line []byte) (*Sample, error) {
+	var j sampleJSON
+	if err := json.Unmarshal(line, &j); err != nil {
+		return nil, err
+	}
+	return j.sample(), nil
+}
+
+// ParsePhout parses line written by phout aggregator.
+// Ammo id is parsed from tag, if tag ends with '#' and number.
+func ParsePhout(line []byte) (*Sample, error) {
+	columns := bytes.Split(line, []byte{phoutDelimiter})
+	if len(columns) != 2+fieldsNum {
+		return nil, errors.Errorf("phout line should have %v columns, but has %v", 2+fieldsNum, len(columns))
+	}
+	ts, err := parsePhoutTimestamp(string(columns[0]))
+	if err != nil {
+		return nil, err
+	}
+	s := &Sample{timeStamp: ts, tags: string(columns[1])}
+	if i := bytes.LastIndexByte(columns[1], '#'); i >= 0 {
+		if id, err := strconv.ParseUint(string(columns[1][i+1:]), 10, 64); err == nil {
+			s.tags, s.id = string(columns[1][:i]), id
+		}
+	}
+	for i, column := range columns[2:] {
+		v, err := strconv.Atoi(string(column))
+		if err != nil {
+			return nil, errors.Wrapf(err, "column %v", i+3)
+		}
+		s.fields[i] = v
+	}
+	return s, nil
+}
+
+// parsePhoutTimestamp parses time stamp like 1335524833.562.
+func parsePhoutTimestamp(str string) (time.Time, error) {
+	sec, frac, _ := strings.Cut(str, ".")
+	secs, err := strconv.ParseInt(sec, 10, 64)
+	if err != nil {
+		return time.Time{}, errors.Errorf("invalid timestamp %q", str)
+	}
+	var nanos int64
+	if frac != "" {
+		if len(frac) > 9 {
+			frac = frac[:9]
+		}
+		n, err := strconv.ParseUint(frac, 10, 64)
+		if err != nil {
+			return time.Time{}, errors.Errorf("invalid timestamp %q", str)
+		}
+		nanos = int64(n)
+		for i := len(frac); i < 9; i++ {
+			nanos *= 10
+		}
+	}
+	return time.Unix(secs, nanos), nil
+}
@@ -0,0 +1,95 @@
+package netsample
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yandex/pandora/core"
+	"github.com/yandex/pandora/core/aggregator"
+	"github.com/yandex/pandora/core/datasink"
+	"go.uber.org/zap"
+)
+
+func decodeAll(t *testing.T, r io.Reader) []*Sample {
+	dec := NewResultDecoder(r)
+	var samples []*Sample
+	for {
+		s, err := dec.Decode()
+		if err == io.EOF {
+			return samples
+		}
+		require.NoError(t, err)
+		samples = append(samples, s)
+	}
+}
+
+func TestParsePhout(t *testing.T) {
+	s, err := ParsePhout([]byte(testSamplePhout))
+	require.NoError(t, err)
+	assert.Equal(t, testSamplePhout, string(appendPhout(s, nil, true)))
+	assert.Equal(t, uint64(42), s.ID())
+	assert.Equal(t, "tag1|tag2", s.Tags())
+
+	s, err = ParsePhout([]byte(testSampleNoIDPhout))
+	require.NoError(t, err)
+	assert.Equal(t, testSampleNoIDPhout, string(appendPhout(s, nil, false)))
+	assert.Zero(t, s.ID())
+
+	s, err = ParsePhout([]byte("1484660999.5\ttag#x\t1\t0\t0\t0\t0\t0\t0\t0\t0\t200"))
+	require.NoError(t, err)
+	assert.Equal(t, "tag#x", s.Tags())
+	assert.Equal(t, int64(1484660999500), s.Timestamp().UnixMilli())
+
+	_, err = ParsePhout([]byte("1484660999.002\ttag\t1\t2"))
+	assert.Error(t, err)
+	_, err = ParsePhout([]byte(strings.Replace(testSamplePhout, "333333", "x", 1)))
+	assert.Error(t, err)
+}
+
+func TestResultDecoder_Phout(t *testing.T) {
+	input := testSamplePhout + "\n\n" + testSampleNoIDPhout
+	samples := decodeAll(t, strings.NewReader(input))
+	require.Len(t, samples, 2)
+	assert.Equal(t, uint64(42), samples[0].ID())
+
+	dec := NewResultDecoder(strings.NewReader(testSamplePhout + "\nbad\n"))
+	_, err := dec.Decode()
+	require.NoError(t, err)
+	_, err = dec.Decode()
+	assert.ErrorContains(t, err, "line 2")
+}
+
+func TestResultDecoder_JSONLines(t *testing.T) {
+	sink := datasink.NewBuffer()
+	conf := aggregator.DefaultJSONLinesAggregatorConfig()
+	conf.Sink = sink
+	testee := aggregator.NewJSONLinesAggregator(conf)
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error)
+	go func() {
+		runErr <- testee.Run(ctx, core.AggregatorDeps{Log: zap.L()})
+	}()
+	testee.Report(newTestSample())
+	testee.Report(newFullTestSample())
+	cancel()
+	require.NoError(t, <-runErr)
+
+	samples := decodeAll(t, sink)
+	require.Len(t, samples, 2)
+	assert.Equal(t, testSamplePhout, samples[0].String())
+	assert.Equal(t, newFullTestSample().String(), samples[1].String())
+	assert.EqualError(t, samples[1].Err(), newFullTestSample().Err().Error())
+	assert.Equal(t, newFullTestSample().Labels(), samples[1].Labels())
+	assert.Equal(t, newFullTestSample().Metrics(), samples[1].Metrics())
+}
+
+func TestResultDecoder_Binary(t *testing.T) {
+	samples := decodeAll(t, bytes.NewReader(encodeBinary(t, newTestSample())))
+	require.Len(t, samples, 1)
+	assert.Equal(t, testSamplePhout, samples[0].String())
+}
@@ -50,6 +50,22 @@ type Sample struct {
 	id        uint64
 	fields    [fieldsNum]int
 	err       error
+	// labels and metrics are set by custom guns and postprocessors. They are kept in order of
+	// the first set, and are not written in phout.
+	labels  []Label
+	metrics []Metric
+}
+
+// Label is a custom key value property of sample, like cache status or user segment.
+type Label struct {
+	Key   string
+	Value string
+}
+
+// Metric is a custom numeric value of sample, like number of returned items.
+type Metric struct {
+	Name  string
+	Value float64
 }
 
 func (s *Sample) Tags() string { return s.tags }
@@ -61,6 +77,16 @@ func (s *Sample) AddTag(tag string) {
 	s.tags += "|" + tag
 }
 
+func (s *Sample) Timestamp() time.Time { return s.timeStamp }
+
+// RTT returns round trip time of sample, or user duration, if it was set.
+func (s *Sample) RTT() time.Duration {
+	return time.Duration(s.get(keyRTTMicro)) * time.Microsecond
+}
+
+// NetCode returns errno of sample error, or user net code. Zero means no error.
+func (s *Sample) NetCode() int { return s.get(keyErrno) }
+
 func (s *Sample) ID() uint64      { return s.id }
 func (s *Sample) SetID(id uint64) { s.id = id }
 
@@ -77,6 +103,54 @@ func (s *Sample) SetErr(err error) {
 	s.setRTT()
 }
 
+// Labels returns custom labels of sample. Returned slice should not be modified.
+func (s *Sample) Labels() []Label { return s.labels }
+
+// Label returns value of label with key, and false, if it is not set.
+func (s *Sample) Label(key string) (string, bool) {
+	for _, l := range s.labels {
+		if l.Key == key {
+			return l.Value, true
+		}
+	}
+	return "", false
+}
+
+// SetLabel sets custom label, replacing value of the label with the same key.
+func (s *Sample) SetLabel(key, value string) {
+	for i := range s.labels {
+		if s.labels[i].Key == key {
+			s.labels[i].Value = value
+			return
+		}
+	}
+	s.labels = append(s.labels, Label{key, value})
+}
+
+// Metrics returns custom metrics of sample. Returned slice should not be modified.
+func (s *Sample) Metrics() []Metric { return s.metrics }
+
+// Metric returns value of metric with name, and false, if it is not set.
+func (s *Sample) Metric(name string) (float64, bool) {
+	for _, m := range s.metrics {
+		if m.Name == name {
+			return m.Value, true
+		}
+	}
+	return 0, false
+}
+
+// SetMetric sets custom metric, replacing value of the metric with the same name.
+func (s *Sample) SetMetric(name string, value float64) {
+	for i := range s.metrics {
+		if s.metrics[i].Name == name {
+			s.metrics[i].Value = value
+			return
+		}
+	}
+	s.metrics = append(s.metrics, Metric{name, value})
+}
+
 func (s *Sample) get(k int) int                      { return s.fields[k] }
 func (s *Sample) set(k, v int)                       { s.fields[k] = v }
 func (s *Sample) setDuration(k int, d time.Duration) { s.set(k, int(d.Nanoseconds()/1000)) }
@@ -46,6 +46,34 @@ func TestSampleBehaviour(t *testing.T) {
 	assert.Equal(t, expected, sample.String())
 }
 
+func TestSampleLabelsAndMetrics(t *testing.T) {
+	s := Acquire("tag")
+	s.SetLabel("cache", "miss")
+	s.SetLabel("region", "eu")
+	s.SetLabel("cache", "hit")
+	s.SetMetric("items", 3)
+	s.SetMetric("items", 5)
+
+	assert.Equal(t, []Label{{"cache", "hit"}, {"region", "eu"}}, s.Labels())
+	assert.Equal(t, []Metric{{"items", 5}}, s.Metrics())
+	value, ok := s.Label("region")
+	assert.True(t, ok)
+	assert.Equal(t, "eu", value)
+	_, ok = s.Label("absent")
+	assert.False(t, ok)
+	metric, ok := s.Metric("items")
+	assert.True(t, ok)
+	assert.Equal(t, 5.0, metric)
+	_, ok = s.Metric("absent")
+	assert.False(t, ok)
+	assert.NotContains(t, s.String(), "hit", "phout is not changed")
+
+	releaseSample(s)
+	s = Acquire("tag")
+	assert.Empty(t, s.Labels())
+	assert.Empty(t, s.Metrics())
+}
+
 func TestCustomSets(t *testing.T) {
 	const tag = "UserDefine"
 	s := Acquire(tag)
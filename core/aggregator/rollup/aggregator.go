@@ -0,0 +1,166 @@
+package rollup
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/yandex/pandora/core"
+	"github.com/yandex/pandora/core/aggregator"
+	"github.com/yandex/pandora/core/aggregator/netsample"
+	"github.com/yandex/pandora/core/coreutil"
+	"github.com/yandex/pandora/lib/errutil"
+	"go.uber.org/zap"
+)
+
+type Config struct {
+	// Interval of roll up and export.
+	Interval time.Duration `config:"interval" validate:"min-time=1ms"`
+	// Quantiles of RTT.
+	Quantiles []float64 `config:"quantiles" validate:"dive,min=0,max=1"`
+	// Labels are added to every point, e.g. to distinguish tests.
+	Labels map[string]string `config:"labels"`
+	// Timeout of export request.
+	Timeout        time.Duration             `config:"timeout" validate:"min-time=1ms"`
+	ReporterConfig aggregator.ReporterConfig `config:",squash"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Interval:       time.Second,
+		Quantiles:      []float64{0.5, 0.9, 0.95, 0.99},
+		Timeout:        5 * time.Second,
+		ReporterConfig: aggregator.DefaultReporterConfig(),
+	}
+}
+
+// encoder appends encoded points to buf.
+type encoder func(buf []byte, points []Point) ([]byte, error)
+
+// newAggregator returns aggregator, that rolls up netsample.Sample every interval, encodes points by encode
+// and sends them by sender opened on Run. Other samples are ignored.
+// Samples are rolled up by the time they are reported, that is at the end of request.
+func newAggregator(conf Config, encode encoder, open func() (sender, error)) core.Aggregator {
+	return &rollupAggregator{
+		Reporter: *aggregator.NewReporter(conf.ReporterConfig),
+		conf:     conf,
+		encode:   encode,
+		open:     open,
+	}
+}
+
+type rollupAggregator struct {
+	aggregator.Reporter
+	conf   Config
+	encode encoder
+	open   func() (sender, error)
+	buf    []byte
+}
+
+// maxPendingFlushes limits points of flushes, that wait for slow export. Older flushes are dropped.
+const maxPendingFlushes = 60
+
+// exportBatch is merged points of flushes, that were not exported yet. Final batch is sent on finish.
+type exportBatch struct {
+	points []Point
+	final  bool
+}
+
+func (a *rollupAggregator) Run(ctx context.Context, deps core.AggregatorDeps) (err error) {
+	sender, err := a.open()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errutil.Join(err, sender.Close())
+		err = errutil.Join(err, a.DroppedErr())
+	}()
+
+	// Export runs in its own goroutine, so slow export doesn't block intake of samples.
+	// Flushes are merged, while export is busy.
+	exports := make(chan exportBatch)
+	finalErr := make(chan error, 1)
+	go func() {
+		var final error
+		for batch := range exports {
+			exportErr := a.export(sender, batch.points)
+			if batch.final {
+				final = exportErr
+			} else if exportErr != nil {
+				deps.Log.Warn("Roll up export failed", zap.Error(exportErr))
+			}
+		}
+		finalErr <- final
+	}()
+	var (
+		pending [][]Point
+		dropped int
+	)
+	merged := func() []Point {
+		var points []Point
+		for _, p := range pending {
+			points = append(points, p...)
+		}
+		return points
+	}
+
+	rollup := NewRollup(a.conf.Quantiles, time.Now())
+	ticker := time.NewTicker(a.conf.Interval)
+	defer ticker.Stop()
+HandleLoop:
+	for {
+		select {
+		case sample := <-a.Incomming:
+			a.add(rollup, sample)
+		case now := <-ticker.C:
+			if points := rollup.Flush(now); len(points) > 0 {
+				pending = append(pending, points)
+			}
+			if len(pending) > maxPendingFlushes {
+				pending = pending[1:]
+				dropped++
+				deps.Log.Warn("Roll up export is too slow. Oldest points are dropped.", zap.Int("dropped_flushes", dropped))
+			}
+			if len(pending) == 0 {
+				continue
+			}
+			select {
+			case exports <- exportBatch{points: merged()}:
+				pending = nil
+			default:
+			}
+		case <-ctx.Done():
+			break HandleLoop // Still need to handle all queued samples.
+		}
+	}
+	for {
+		select {
+		case sample := <-a.Incomming:
+			a.add(rollup, sample)
+		default:
+			pending = append(pending, rollup.Flush(time.Now()))
+			exports <- exportBatch{points: merged(), final: true}
+			close(exports)
+			return errors.WithMessage(<-finalErr, "final roll up export failed")
+		}
+	}
+}
+
+func (a *rollupAggregator) add(rollup *Rollup, sample core.Sample) {
+	if s, ok := sample.(*netsample.Sample); ok {
+		rollup.Add(s)
+	}
+	coreutil.ReturnSampleIfBorrowed(sample)
+}
+
+func (a *rollupAggregator) export(sender sender, points []Point) error {
+	if len(points) == 0 {
+		return nil
+	}
+	var err error
+	a.buf, err = a.encode(a.buf[:0], points)
+	if err != nil {
+		return err
+	}
+	return sender.Send(a.buf)
+}
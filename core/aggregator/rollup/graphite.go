@@ -0,0 +1,100 @@
+package rollup
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/yandex/pandora/core"
+)
+
+type GraphiteConfig struct {
+	Config `config:",squash"`
+	// Address of Graphite plaintext protocol TCP receiver, e.g. localhost:2003.
+	Address string `config:"address"`
+	// Sink is used instead of Address, e.g. to write metrics to file.
+	Sink   core.DataSink `config:"sink"`
+	Prefix string        `config:"prefix" validate:"required"`
+}
+
+func DefaultGraphiteConfig() GraphiteConfig {
+	return GraphiteConfig{
+		Config: DefaultConfig(),
+		Prefix: "pandora",
+	}
+}
+
+// NewGraphite returns aggregator, that writes roll ups in Graphite plaintext protocol
+// as <prefix>.<tag>.<proto_code>.<net_code>.<metric>. Labels are written as Graphite tags.
+// RTT is in microseconds.
+func NewGraphite(conf GraphiteConfig) (core.Aggregator, error) {
+	if (conf.Address == "") == (conf.Sink == nil) {
+		return nil, errors.New("one of address or sink should be set")
+	}
+	tags := graphiteLabels(conf.Labels)
+	encode := func(buf []byte, points []Point) ([]byte, error) {
+		return appendGraphite(buf, conf.Prefix, tags, conf.Quantiles, points), nil
+	}
+	return newAggregator(conf.Config, encode, func() (sender, error) {
+		return openSender(conf.Sink, func() sender {
+			return &tcpSender{address: conf.Address, timeout: conf.Timeout}
+		})
+	}), nil
+}
+
+var graphiteInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_\-]+`)
+
+func graphiteNode(s string) string {
+	if s == "" {
+		return "untagged"
+	}
+	return graphiteInvalidChars.ReplaceAllString(s, "_")
+}
+
+// graphiteLabels returns labels as ";k=v" sorted by key.
+func graphiteLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	for _, k := range keys {
+		if labels[k] == "" {
+			continue
+		}
+		sb.WriteByte(';')
+		sb.WriteString(graphiteNode(k))
+		sb.WriteByte('=')
+		sb.WriteString(strings.NewReplacer(";", "_", " ", "_", "~", "_").Replace(labels[k]))
+	}
+	return sb.String()
+}
+
+func appendGraphite(buf []byte, prefix, labels string, quantiles []float64, points []Point) []byte {
+	for _, p := range points {
+		path := prefix + "." + graphiteNode(p.Tag) + "." + strconv.Itoa(p.ProtoCode) + "." + strconv.Itoa(p.NetCode) + "."
+		ts := p.Time.Unix()
+		buf = appendGraphiteLine(buf, path+"count", labels, strconv.Itoa(p.Count), ts)
+		buf = appendGraphiteLine(buf, path+"rps", labels, strconv.FormatFloat(p.RPS, 'f', -1, 64), ts)
+		buf = appendGraphiteLine(buf, path+"rtt_min", labels, strconv.FormatInt(p.RTTMin.Microseconds(), 10), ts)
+		buf = appendGraphiteLine(buf, path+"rtt_avg", labels, strconv.FormatInt(p.RTTAvg.Microseconds(), 10), ts)
+		buf = appendGraphiteLine(buf, path+"rtt_max", labels, strconv.FormatInt(p.RTTMax.Microseconds(), 10), ts)
+		for i, q := range quantiles {
+			buf = appendGraphiteLine(buf, path+"rtt_"+quantileName(q), labels, strconv.FormatInt(p.RTTQuantiles[i].Microseconds(), 10), ts)
+		}
+	}
+	return buf
+}
+
+func appendGraphiteLine(buf []byte, path, labels, value string, ts int64) []byte {
+	buf = append(buf, path...)
+	buf = append(buf, labels...)
+	buf = append(buf, ' ')
+	buf = append(buf, value...)
+	buf = append(buf, ' ')
+	buf = strconv.AppendInt(buf, ts, 10)
+	return append(buf, '\n')
+}
@@ -0,0 +1,114 @@
+package rollup
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/yandex/pandora/core"
+)
+
+type InfluxConfig struct {
+	Config `config:",squash"`
+	// URL of InfluxDB write API, e.g. http://localhost:8086/api/v2/write?org=my-org&bucket=pandora&precision=ns.
+	// Precision should be ns.
+	URL string `config:"url"`
+	// Token is sent in Authorization header.
+	Token string `config:"token"`
+	// Sink is used instead of URL, e.g. to write points to file.
+	Sink        core.DataSink `config:"sink"`
+	Measurement string        `config:"measurement" validate:"required"`
+}
+
+func DefaultInfluxConfig() InfluxConfig {
+	return InfluxConfig{
+		Config:      DefaultConfig(),
+		Measurement: "pandora",
+	}
+}
+
+// NewInflux returns aggregator, that writes roll ups in InfluxDB line protocol.
+// Tag, proto_code, net_code and labels are tags of point. Count, rps and rtt_* fields are written,
+// RTT is in microseconds.
+func NewInflux(conf InfluxConfig) (core.Aggregator, error) {
+	if (conf.URL == "") == (conf.Sink == nil) {
+		return nil, errors.New("one of url or sink should be set")
+	}
+	header := http.Header{}
+	header.Set("Content-Type", "text/plain; charset=utf-8")
+	if conf.Token != "" {
+		header.Set("Authorization", "Token "+conf.Token)
+	}
+	tags := influxLabels(conf.Labels)
+	encode := func(buf []byte, points []Point) ([]byte, error) {
+		return appendInflux(buf, conf.Measurement, tags, conf.Quantiles, points), nil
+	}
+	return newAggregator(conf.Config, encode, func() (sender, error) {
+		return openSender(conf.Sink, func() sender {
+			return newHTTPSender(conf.URL, header, conf.Timeout)
+		})
+	}), nil
+}
+
+// influxLabels returns escaped labels as ",k=v" sorted by key.
+func influxLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	for _, k := range keys {
+		if labels[k] == "" {
+			continue
+		}
+		sb.WriteByte(',')
+		sb.WriteString(influxEscaper.Replace(k))
+		sb.WriteByte('=')
+		sb.WriteString(influxEscaper.Replace(labels[k]))
+	}
+	return sb.String()
+}
+
+var influxEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+func appendInflux(buf []byte, measurement, labels string, quantiles []float64, points []Point) []byte {
+	measurement = strings.NewReplacer(",", `\,`, " ", `\ `).Replace(measurement)
+	for _, p := range points {
+		buf = append(buf, measurement...)
+		if p.Tag != "" {
+			buf = append(buf, ",tag="...)
+			buf = append(buf, influxEscaper.Replace(p.Tag)...)
+		}
+		buf = append(buf, ",proto_code="...)
+		buf = strconv.AppendInt(buf, int64(p.ProtoCode), 10)
+		buf = append(buf, ",net_code="...)
+		buf = strconv.AppendInt(buf, int64(p.NetCode), 10)
+		buf = append(buf, labels...)
+
+		buf = append(buf, " count="...)
+		buf = strconv.AppendInt(buf, int64(p.Count), 10)
+		buf = append(buf, "i,rps="...)
+		buf = strconv.AppendFloat(buf, p.RPS, 'f', -1, 64)
+		buf = appendInfluxMicros(buf, "rtt_min", p.RTTMin.Microseconds())
+		buf = appendInfluxMicros(buf, "rtt_avg", p.RTTAvg.Microseconds())
+		buf = appendInfluxMicros(buf, "rtt_max", p.RTTMax.Microseconds())
+		for i, q := range quantiles {
+			buf = appendInfluxMicros(buf, "rtt_"+quantileName(q), p.RTTQuantiles[i].Microseconds())
+		}
+		buf = append(buf, ' ')
+		buf = strconv.AppendInt(buf, p.Time.UnixNano(), 10)
+		buf = append(buf, '\n')
+	}
+	return buf
+}
+
+func appendInfluxMicros(buf []byte, field string, v int64) []byte {
+	buf = append(buf, ',')
+	buf = append(buf, field...)
+	buf = append(buf, '=')
+	buf = strconv.AppendInt(buf, v, 10)
+	return append(buf, 'i')
+}
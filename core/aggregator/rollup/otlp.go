@@ -0,0 +1,206 @@
+package rollup
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/yandex/pandora/core"
+)
+
+type OTLPConfig struct {
+	Config `config:",squash"`
+	// URL of OTLP/HTTP metrics receiver, e.g. http://localhost:4318/v1/metrics.
+	URL string `config:"url"`
+	// Headers are added to export requests, e.g. for authorization.
+	Headers map[string]string `config:"headers"`
+	// Sink is used instead of URL, e.g. to write metrics to file. Every export is written as JSON line.
+	Sink core.DataSink `config:"sink"`
+}
+
+func DefaultOTLPConfig() OTLPConfig {
+	return OTLPConfig{
+		Config: DefaultConfig(),
+	}
+}
+
+// NewOTLP returns aggregator, that exports roll ups as OTLP metrics in JSON encoding:
+// pandora.requests delta sum, pandora.rps gauge and pandora.rtt summary in microseconds.
+// Tag and codes are data point attributes, labels are resource attributes.
+func NewOTLP(conf OTLPConfig) (core.Aggregator, error) {
+	if (conf.URL == "") == (conf.Sink == nil) {
+		return nil, errors.New("one of url or sink should be set")
+	}
+	header := http.Header{}
+	for k, v := range conf.Headers {
+		header.Set(k, v)
+	}
+	header.Set("Content-Type", "application/json")
+	resource := otlpResource{Attributes: otlpLabels(conf.Labels)}
+	encode := func(buf []byte, points []Point) ([]byte, error) {
+		return appendOTLP(buf, resource, conf.Quantiles, points)
+	}
+	return newAggregator(conf.Config, encode, func() (sender, error) {
+		return openSender(conf.Sink, func() sender {
+			return newHTTPSender(conf.URL, header, conf.Timeout)
+		})
+	}), nil
+}
+
+// OTLP JSON data model. See https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding.
+// 64 bit integers are encoded as strings.
+
+type otlpRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpMetric struct {
+	Name    string       `json:"name"`
+	Unit    string       `json:"unit,omitempty"`
+	Sum     *otlpSum     `json:"sum,omitempty"`
+	Gauge   *otlpGauge   `json:"gauge,omitempty"`
+	Summary *otlpSummary `json:"summary,omitempty"`
+}
+
+const otlpTemporalityDelta = 1
+
+type otlpSum struct {
+	DataPoints             []otlpNumberDataPoint `json:"dataPoints"`
+	AggregationTemporality int                   `json:"aggregationTemporality"`
+	IsMonotonic            bool                  `json:"isMonotonic"`
+}
+
+type otlpGauge struct {
+	DataPoints []otlpNumberDataPoint `json:"dataPoints"`
+}
+
+type otlpSummary struct {
+	DataPoints []otlpSummaryDataPoint `json:"dataPoints"`
+}
+
+type otlpNumberDataPoint struct {
+	Attributes        []otlpKeyValue `json:"attributes"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	TimeUnixNano      string         `json:"timeUnixNano"`
+	AsInt             string         `json:"asInt,omitempty"`
+	AsDouble          *float64       `json:"asDouble,omitempty"`
+}
+
+type otlpSummaryDataPoint struct {
+	Attributes        []otlpKeyValue      `json:"attributes"`
+	StartTimeUnixNano string              `json:"startTimeUnixNano"`
+	TimeUnixNano      string              `json:"timeUnixNano"`
+	Count             string              `json:"count"`
+	Sum               float64             `json:"sum"`
+	QuantileValues    []otlpQuantileValue `json:"quantileValues"`
+}
+
+type otlpQuantileValue struct {
+	Quantile float64 `json:"quantile"`
+	Value    float64 `json:"value"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    string  `json:"intValue,omitempty"`
+}
+
+func otlpString(key, value string) otlpKeyValue {
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{StringValue: &value}}
+}
+
+func otlpInt(key string, value int) otlpKeyValue {
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{IntValue: strconv.Itoa(value)}}
+}
+
+func otlpLabels(labels map[string]string) []otlpKeyValue {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	attrs := make([]otlpKeyValue, 0, len(keys))
+	for _, k := range keys {
+		attrs = append(attrs, otlpString(k, labels[k]))
+	}
+	return attrs
+}
+
+func appendOTLP(buf []byte, resource otlpResource, quantiles []float64, points []Point) ([]byte, error) {
+	requests := &otlpSum{AggregationTemporality: otlpTemporalityDelta, IsMonotonic: true}
+	rps := &otlpGauge{}
+	rtt := &otlpSummary{}
+	for _, p := range points {
+		attrs := make([]otlpKeyValue, 0, 3)
+		if p.Tag != "" {
+			attrs = append(attrs, otlpString("tag", p.Tag))
+		}
+		attrs = append(attrs, otlpInt("proto_code", p.ProtoCode), otlpInt("net_code", p.NetCode))
+		start := strconv.FormatInt(p.Time.Add(-p.Interval).UnixNano(), 10)
+		end := strconv.FormatInt(p.Time.UnixNano(), 10)
+
+		requests.DataPoints = append(requests.DataPoints, otlpNumberDataPoint{
+			Attributes: attrs, StartTimeUnixNano: start, TimeUnixNano: end,
+			AsInt: strconv.Itoa(p.Count),
+		})
+		pointRPS := p.RPS
+		rps.DataPoints = append(rps.DataPoints, otlpNumberDataPoint{
+			Attributes: attrs, StartTimeUnixNano: start, TimeUnixNano: end,
+			AsDouble: &pointRPS,
+		})
+		values := make([]otlpQuantileValue, 0, len(quantiles)+2)
+		values = append(values, otlpQuantileValue{Quantile: 0, Value: float64(p.RTTMin.Microseconds())})
+		for i, q := range quantiles {
+			values = append(values, otlpQuantileValue{Quantile: q, Value: float64(p.RTTQuantiles[i].Microseconds())})
+		}
+		values = append(values, otlpQuantileValue{Quantile: 1, Value: float64(p.RTTMax.Microseconds())})
+		rtt.DataPoints = append(rtt.DataPoints, otlpSummaryDataPoint{
+			Attributes: attrs, StartTimeUnixNano: start, TimeUnixNano: end,
+			Count:          strconv.Itoa(p.Count),
+			Sum:            float64(p.RTTSum.Microseconds()),
+			QuantileValues: values,
+		})
+	}
+	req := otlpRequest{ResourceMetrics: []otlpResourceMetrics{{
+		Resource: resource,
+		ScopeMetrics: []otlpScopeMetrics{{
+			Scope: otlpScope{Name: "pandora"},
+			Metrics: []otlpMetric{
+				{Name: "pandora.requests", Unit: "{request}", Sum: requests},
+				{Name: "pandora.rps", Unit: "{request}/s", Gauge: rps},
+				{Name: "pandora.rtt", Unit: "us", Summary: rtt},
+			},
+		}},
+	}}}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return buf, err
+	}
+	buf = append(buf, data...)
+	return append(buf, '\n'), nil
+}
@@ -0,0 +1,124 @@
+// Package rollup contains aggregators, that periodically roll up netsample.Sample into
+// counts, RPS and RTT quantiles per tag and codes, and export them to time series databases.
+package rollup
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yandex/pandora/core/aggregator/netsample"
+)
+
+// Point is a roll up of samples with the same tag and codes, reported during interval.
+type Point struct {
+	// Time is the end of interval.
+	Time      time.Time
+	Interval  time.Duration
+	Tag       string
+	ProtoCode int
+	NetCode   int
+
+	Count int
+	RPS   float64
+	// RTT stats. Quantiles are in order of Rollup quantiles.
+	RTTMin       time.Duration
+	RTTMax       time.Duration
+	RTTAvg       time.Duration
+	RTTSum       time.Duration
+	RTTQuantiles []time.Duration
+}
+
+type pointKey struct {
+	tag       string
+	protoCode int
+	netCode   int
+}
+
+// Rollup collects RTT of samples for the current interval.
+// Rollup is not goroutine safe.
+type Rollup struct {
+	quantiles []float64
+	rtts      map[pointKey][]time.Duration
+	started   time.Time
+}
+
+func NewRollup(quantiles []float64, start time.Time) *Rollup {
+	return &Rollup{
+		quantiles: quantiles,
+		rtts:      map[pointKey][]time.Duration{},
+		started:   start,
+	}
+}
+
+func (r *Rollup) Add(s *netsample.Sample) {
+	key := pointKey{tag: s.Tags(), protoCode: s.ProtoCode(), netCode: s.NetCode()}
+	r.rtts[key] = append(r.rtts[key], s.RTT())
+}
+
+// Flush returns points of samples added since the previous flush, sorted by tag and codes,
+// and starts new interval at now.
+func (r *Rollup) Flush(now time.Time) []Point {
+	interval := now.Sub(r.started)
+	r.started = now
+	if len(r.rtts) == 0 {
+		return nil
+	}
+	points := make([]Point, 0, len(r.rtts))
+	for key, rtts := range r.rtts {
+		points = append(points, r.point(key, rtts, now, interval))
+	}
+	r.rtts = make(map[pointKey][]time.Duration, len(r.rtts))
+	sort.Slice(points, func(i, j int) bool {
+		a, b := points[i], points[j]
+		if a.Tag != b.Tag {
+			return a.Tag < b.Tag
+		}
+		if a.ProtoCode != b.ProtoCode {
+			return a.ProtoCode < b.ProtoCode
+		}
+		return a.NetCode < b.NetCode
+	})
+	return points
+}
+
+func (r *Rollup) point(key pointKey, rtts []time.Duration, now time.Time, interval time.Duration) Point {
+	sort.Slice(rtts, func(i, j int) bool { return rtts[i] < rtts[j] })
+	var sum time.Duration
+	for _, rtt := range rtts {
+		sum += rtt
+	}
+	p := Point{
+		Time:         now,
+		Interval:     interval,
+		Tag:          key.tag,
+		ProtoCode:    key.protoCode,
+		NetCode:      key.netCode,
+		Count:        len(rtts),
+		RTTMin:       rtts[0],
+		RTTMax:       rtts[len(rtts)-1],
+		RTTAvg:       sum / time.Duration(len(rtts)),
+		RTTSum:       sum,
+		RTTQuantiles: make([]time.Duration, len(r.quantiles)),
+	}
+	if interval > 0 {
+		p.RPS = float64(len(rtts)) / interval.Seconds()
+	}
+	for i, q := range r.quantiles {
+		// Nearest rank.
+		rank := int(math.Ceil(q*float64(len(rtts)))) - 1
+		if rank < 0 {
+			rank = 0
+		}
+		p.RTTQuantiles[i] = rtts[rank]
+	}
+	return p
+}
+
+// quantileName returns name of quantile field: p50 for 0.5, p99_9 for 0.999.
+func quantileName(q float64) string {
+	percent := math.Round(q*100*1e4) / 1e4 // Avoid float artifacts like 99.89999999999999.
+	return "p" + strings.Replace(strconv.FormatFloat(percent, 'f', -1, 64), ".", "_", 1)
+}
@@ -0,0 +1,341 @@
+package rollup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yandex/pandora/core"
+	"github.com/yandex/pandora/core/aggregator/netsample"
+	"github.com/yandex/pandora/core/datasink"
+	"go.uber.org/zap"
+)
+
+func newSample(tag string, proto, net int, rtt time.Duration) *netsample.Sample {
+	s := netsample.Acquire(tag)
+	s.SetUserProto(proto)
+	s.SetUserNet(net)
+	s.SetUserDuration(rtt)
+	return s
+}
+
+func TestRollup_Flush(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	r := NewRollup([]float64{0.5, 0.9}, start)
+	assert.Nil(t, r.Flush(start.Add(time.Second)))
+
+	for i := 1; i <= 10; i++ {
+		r.Add(newSample("b", 200, 0, time.Duration(i)*time.Millisecond))
+	}
+	r.Add(newSample("a", 500, 0, time.Millisecond))
+	r.Add(newSample("a", 200, 0, 3*time.Millisecond))
+	r.Add(newSample("a", 200, 0, time.Millisecond))
+
+	now := start.Add(3 * time.Second)
+	points := r.Flush(now)
+	require.Len(t, points, 3)
+	assert.Equal(t, Point{
+		Time: now, Interval: 2 * time.Second, Tag: "a", ProtoCode: 200,
+		Count: 2, RPS: 1,
+		RTTMin: time.Millisecond, RTTMax: 3 * time.Millisecond, RTTAvg: 2 * time.Millisecond, RTTSum: 4 * time.Millisecond,
+		RTTQuantiles: []time.Duration{time.Millisecond, 3 * time.Millisecond},
+	}, points[0])
+	assert.Equal(t, 500, points[1].ProtoCode)
+	assert.Equal(t, "b", points[2].Tag)
+	assert.Equal(t, 10, points[2].Count)
+	assert.Equal(t, 5.0, points[2].RPS)
+	assert.Equal(t, []time.Duration{5 * time.Millisecond, 9 * time.Millisecond}, points[2].RTTQuantiles)
+
+	assert.Nil(t, r.Flush(now.Add(time.Second)))
+}
+
+func TestQuantileName(t *testing.T) {
+	assert.Equal(t, "p50", quantileName(0.5))
+	assert.Equal(t, "p99", quantileName(0.99))
+	assert.Equal(t, "p99_9", quantileName(0.999))
+}
+
+func TestAppendInflux(t *testing.T) {
+	points := []Point{{
+		Time: time.Unix(1, 5), Tag: "a b,c", ProtoCode: 200, Count: 3, RPS: 1.5,
+		RTTMin: time.Millisecond, RTTAvg: 2 * time.Millisecond, RTTMax: 3 * time.Millisecond,
+		RTTQuantiles: []time.Duration{2 * time.Millisecond},
+	}, {
+		Time: time.Unix(1, 5), NetCode: 110, Count: 1, RPS: 0.5,
+		RTTQuantiles: []time.Duration{0},
+	}}
+	actual := appendInflux(nil, "pandora", influxLabels(map[string]string{"test": "x=1", "empty": ""}), []float64{0.5}, points)
+	assert.Equal(t,
+		`pandora,tag=a\ b\,c,proto_code=200,net_code=0,test=x\=1 count=3i,rps=1.5,rtt_min=1000i,rtt_avg=2000i,rtt_max=3000i,rtt_p50=2000i 1000000005`+"\n"+
+			`pandora,proto_code=0,net_code=110,test=x\=1 count=1i,rps=0.5,rtt_min=0i,rtt_avg=0i,rtt_max=0i,rtt_p50=0i 1000000005`+"\n",
+		string(actual))
+}
+
+func TestAppendGraphite(t *testing.T) {
+	points := []Point{{
+		Time: time.Unix(10, 0), Tag: "get /a", ProtoCode: 200, Count: 3, RPS: 1.5,
+		RTTMin: time.Millisecond, RTTAvg: 2 * time.Millisecond, RTTMax: 3 * time.Millisecond,
+		RTTQuantiles: []time.Duration{2 * time.Millisecond},
+	}}
+	actual := appendGraphite(nil, "pandora", graphiteLabels(map[string]string{"test": "smoke"}), []float64{0.5}, points)
+	assert.Equal(t, strings.Join([]string{
+		"pandora.get_a.200.0.count;test=smoke 3 10",
+		"pandora.get_a.200.0.rps;test=smoke 1.5 10",
+		"pandora.get_a.200.0.rtt_min;test=smoke 1000 10",
+		"pandora.get_a.200.0.rtt_avg;test=smoke 2000 10",
+		"pandora.get_a.200.0.rtt_max;test=smoke 3000 10",
+		"pandora.get_a.200.0.rtt_p50;test=smoke 2000 10",
+	}, "\n")+"\n", string(actual))
+	assert.Equal(t, "untagged", graphiteNode(""))
+}
+
+func TestNew_EndpointRequired(t *testing.T) {
+	_, err := NewInflux(DefaultInfluxConfig())
+	assert.Error(t, err)
+	_, err = NewGraphite(DefaultGraphiteConfig())
+	assert.Error(t, err)
+	conf := DefaultOTLPConfig()
+	conf.URL = "http://localhost:4318/v1/metrics"
+	conf.Sink = datasink.NewBuffer()
+	_, err = NewOTLP(conf)
+	assert.Error(t, err)
+}
+
+// run reports samples into aggregator and waits at least one export, then stops it.
+func run(t *testing.T, a core.Aggregator, exported func() bool, samples ...*netsample.Sample) {
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error)
+	go func() {
+		runErr <- a.Run(ctx, core.AggregatorDeps{Log: zap.L()})
+	}()
+	for _, s := range samples {
+		a.Report(s)
+	}
+	require.Eventually(t, exported, 5*time.Second, 5*time.Millisecond)
+	cancel()
+	require.NoError(t, <-runErr)
+}
+
+type receiver struct {
+	lock   sync.Mutex
+	bodies []string
+}
+
+func (r *receiver) add(body string) {
+	r.lock.Lock()
+	r.bodies = append(r.bodies, body)
+	r.lock.Unlock()
+}
+
+func (r *receiver) all() string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return strings.Join(r.bodies, "")
+}
+
+func (r *receiver) received() bool {
+	return r.all() != ""
+}
+
+func TestInflux_HTTP(t *testing.T) {
+	rcv := &receiver{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "Token secret", req.Header.Get("Authorization"))
+		body, _ := io.ReadAll(req.Body)
+		rcv.add(string(body))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	conf := DefaultInfluxConfig()
+	conf.URL = server.URL + "/api/v2/write"
+	conf.Token = "secret"
+	conf.Interval = 10 * time.Millisecond
+	a, err := NewInflux(conf)
+	require.NoError(t, err)
+	run(t, a, rcv.received, newSample("a", 200, 0, time.Millisecond), newSample("a", 200, 0, time.Millisecond))
+
+	assert.Contains(t, rcv.all(), "pandora,tag=a,proto_code=200,net_code=0 count=2i,")
+}
+
+func TestInflux_ServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, "bad line", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	conf := DefaultInfluxConfig()
+	conf.URL = server.URL
+	a, err := NewInflux(conf)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	a.Report(newSample("a", 200, 0, time.Millisecond))
+	err = a.Run(ctx, core.AggregatorDeps{Log: zap.L()})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "bad line")
+}
+
+func TestGraphite_TCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	rcv := &receiver{}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				buf := make([]byte, 4096)
+				for {
+					n, err := conn.Read(buf)
+					rcv.add(string(buf[:n]))
+					if err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+
+	conf := DefaultGraphiteConfig()
+	conf.Address = listener.Addr().String()
+	conf.Interval = 10 * time.Millisecond
+	a, err := NewGraphite(conf)
+	require.NoError(t, err)
+	run(t, a, rcv.received, newSample("", 0, 111, time.Millisecond))
+
+	assert.Contains(t, rcv.all(), "pandora.untagged.0.111.count 1 ")
+}
+
+func TestOTLP_HTTP(t *testing.T) {
+	rcv := &receiver{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+		assert.Equal(t, "v", req.Header.Get("X-Key"))
+		body, _ := io.ReadAll(req.Body)
+		rcv.add(string(body))
+	}))
+	defer server.Close()
+
+	conf := DefaultOTLPConfig()
+	conf.URL = server.URL + "/v1/metrics"
+	conf.Headers = map[string]string{"X-Key": "v"}
+	conf.Labels = map[string]string{"service.name": "pandora"}
+	conf.Quantiles = []float64{0.5}
+	conf.Interval = 10 * time.Millisecond
+	a, err := NewOTLP(conf)
+	require.NoError(t, err)
+	run(t, a, rcv.received, newSample("a", 200, 0, time.Millisecond), newSample("a", 200, 0, 3*time.Millisecond))
+
+	var req otlpRequest
+	line, _, _ := strings.Cut(rcv.all(), "\n")
+	require.NoError(t, json.Unmarshal([]byte(line), &req))
+	require.Len(t, req.ResourceMetrics, 1)
+	rm := req.ResourceMetrics[0]
+	require.Len(t, rm.Resource.Attributes, 1)
+	assert.Equal(t, "service.name", rm.Resource.Attributes[0].Key)
+	metrics := rm.ScopeMetrics[0].Metrics
+	require.Len(t, metrics, 3)
+
+	requests := metrics[0].Sum.DataPoints[0]
+	assert.Equal(t, "2", requests.AsInt)
+	assert.Equal(t, "tag", requests.Attributes[0].Key)
+	assert.Equal(t, "200", requests.Attributes[1].Value.IntValue)
+	assert.True(t, metrics[0].Sum.IsMonotonic)
+
+	assert.NotNil(t, metrics[1].Gauge.DataPoints[0].AsDouble)
+
+	rtt := metrics[2].Summary.DataPoints[0]
+	assert.Equal(t, "2", rtt.Count)
+	assert.Equal(t, 4000.0, rtt.Sum)
+	assert.Equal(t, []otlpQuantileValue{{0, 1000}, {0.5, 1000}, {1, 3000}}, rtt.QuantileValues)
+}
+
+func TestOTLP_Sink(t *testing.T) {
+	sink := datasink.NewBuffer()
+	conf := DefaultOTLPConfig()
+	conf.Sink = sink
+	a, err := NewOTLP(conf)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	a.Report(newSample("a", 200, 0, time.Millisecond))
+	a.Report("not a netsample")
+	require.NoError(t, a.Run(ctx, core.AggregatorDeps{Log: zap.L()}))
+
+	line, err := sink.ReadBytes('\n')
+	require.NoError(t, err)
+	var req otlpRequest
+	require.NoError(t, json.Unmarshal(line, &req))
+	assert.Equal(t, "1", req.ResourceMetrics[0].ScopeMetrics[0].Metrics[0].Sum.DataPoints[0].AsInt)
+	assert.Zero(t, sink.Len())
+}
+
+type blockingSender struct {
+	entered chan struct{}
+	release chan struct{}
+	rcv     receiver
+}
+
+func (s *blockingSender) Send(body []byte) error {
+	select {
+	case s.entered <- struct{}{}:
+		<-s.release
+	default:
+	}
+	s.rcv.add(string(body) + ";")
+	return nil
+}
+
+func (s *blockingSender) Close() error {
+	return nil
+}
+
+func TestAggregator_SlowExport(t *testing.T) {
+	snd := &blockingSender{entered: make(chan struct{}), release: make(chan struct{})}
+	encode := func(buf []byte, points []Point) ([]byte, error) {
+		for _, p := range points {
+			buf = append(buf, fmt.Sprintf("%s %d\n", p.Tag, p.Count)...)
+		}
+		return buf, nil
+	}
+	conf := DefaultConfig()
+	conf.Interval = 5 * time.Millisecond
+	a := newAggregator(conf, encode, func() (sender, error) { return snd, nil })
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error)
+	go func() {
+		runErr <- a.Run(ctx, core.AggregatorDeps{Log: zap.L()})
+	}()
+
+	a.Report(newSample("a", 200, 0, time.Millisecond))
+	<-snd.entered
+	// Samples are rolled up, while export is blocked, and their flushes are merged into the next export.
+	for i := 0; i < 3; i++ {
+		a.Report(newSample("b", 200, 0, time.Millisecond))
+		time.Sleep(20 * time.Millisecond)
+	}
+	close(snd.release)
+	require.Eventually(t, func() bool { return strings.Count(snd.rcv.all(), ";") >= 2 }, 5*time.Second, 5*time.Millisecond)
+	cancel()
+	require.NoError(t, <-runErr)
+
+	batches := strings.Split(strings.TrimSuffix(snd.rcv.all(), ";"), ";")
+	assert.Equal(t, "a 1\n", batches[0])
+	// Every sample is flushed in its own interval, but all of them are exported at once.
+	assert.Equal(t, "b 1\nb 1\nb 1\n", batches[1])
+}
@@ -0,0 +1,114 @@
+package rollup
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/yandex/pandora/core"
+)
+
+// sender delivers encoded points.
+type sender interface {
+	Send(body []byte) error
+	Close() error
+}
+
+// openSender returns sender to sink, or newEndpoint sender, if sink is not set.
+func openSender(sink core.DataSink, newEndpoint func() sender) (sender, error) {
+	if sink == nil {
+		return newEndpoint(), nil
+	}
+	w, err := sink.OpenSink()
+	if err != nil {
+		return nil, err
+	}
+	return &sinkSender{w}, nil
+}
+
+type sinkSender struct {
+	w io.WriteCloser
+}
+
+func (s *sinkSender) Send(body []byte) error {
+	_, err := s.w.Write(body)
+	return err
+}
+
+func (s *sinkSender) Close() error {
+	return s.w.Close()
+}
+
+type httpSender struct {
+	client *http.Client
+	url    string
+	header http.Header
+}
+
+func newHTTPSender(url string, header http.Header, timeout time.Duration) *httpSender {
+	return &httpSender{
+		client: &http.Client{Timeout: timeout},
+		url:    url,
+		header: header,
+	}
+}
+
+func (s *httpSender) Send(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range s.header {
+		req.Header[k] = v
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return errors.Errorf("%s responded %s: %s", s.url, resp.Status, bytes.TrimSpace(msg))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+func (s *httpSender) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+// tcpSender keeps connection, and reconnects on the next send after write error.
+type tcpSender struct {
+	address string
+	timeout time.Duration
+	conn    net.Conn
+}
+
+func (s *tcpSender) Send(body []byte) error {
+	if s.conn == nil {
+		conn, err := net.DialTimeout("tcp", s.address, s.timeout)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+	_ = s.conn.SetWriteDeadline(time.Now().Add(s.timeout))
+	_, err := s.conn.Write(body)
+	if err != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+	return err
+}
+
+func (s *tcpSender) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.Close()
+}
@@ -105,6 +105,10 @@ type GunDeps struct {
 
 	Shared any
 
+	// Abort stops shooting of the whole test with err. Instance finishes after current Shoot returns,
+	// and its pool fails with err. Gun MAY call it on unrecoverable failure. It is nil, if gun runs out of engine.
+	Abort func(err error)
+
 	// TODO(skipor): https://github.com/yandex/pandora/issues/71
 	// Pass parallelism value. InstanceId MUST be -1 if parallelism > 1.
 }
@@ -4,27 +4,72 @@ import (
 	"io"
 	"os"
 
+	"github.com/pkg/errors"
 	"github.com/spf13/afero"
 	"github.com/yandex/pandora/core"
 )
 
-// TODO(skipor): gzip on flag
+const (
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+)
 
 type FileConfig struct {
 	Path string `config:"path" validate:"required"`
+	// Compression is none (default), gzip or zstd. Every file, including rotated ones,
+	// is a complete compressed stream.
+	Compression string         `config:"compression"`
+	Rotation    RotationConfig `config:"rotation"`
 }
 
 func NewFile(fs afero.Fs, conf FileConfig) core.DataSink {
-	return &fileSink{afero.Afero{Fs: fs}, conf}
+	return &fileSink{fs: afero.Afero{Fs: fs}, conf: conf}
+}
+
+// NewAppendFile returns file sink, that appends to existing file instead of truncating it.
+// Compressed data is appended as a new compressed stream, which is read by gzip and zstd readers
+// as continuation of the file.
+func NewAppendFile(fs afero.Fs, conf FileConfig) core.DataSink {
+	return &fileSink{fs: afero.Afero{Fs: fs}, conf: conf, append: true}
 }
 
 type fileSink struct {
-	fs   afero.Afero
-	conf FileConfig
+	fs     afero.Afero
+	conf   FileConfig
+	append bool
 }
 
 func (s *fileSink) OpenSink() (wc io.WriteCloser, err error) {
-	return s.fs.OpenFile(s.conf.Path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	switch s.conf.Compression {
+	case "", CompressionNone, CompressionGzip, CompressionZstd:
+	default:
+		return nil, errors.Errorf("unknown compression %q: should be one of %s, %s, %s",
+			s.conf.Compression, CompressionNone, CompressionGzip, CompressionZstd)
+	}
+	compressed := s.conf.Compression == CompressionGzip || s.conf.Compression == CompressionZstd
+	if compressed || s.conf.Rotation.enabled() {
+		w, err := newRotatingWriter(s.fs, s.conf, s.append)
+		if err != nil {
+			return nil, err
+		}
+		return w, nil
+	}
+	return s.fs.OpenFile(s.conf.Path, openFlag(s.append), 0644)
+}
+
+func openFlag(append bool) int {
+	if append {
+		return os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	return os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+}
+
+// Rotated returns true, if sink is file sink with rotation.
+// Rotation splits file only at new line characters, so rotated sink can't be used for binary data.
+func Rotated(sink core.DataSink) bool {
+	s, ok := sink.(*fileSink)
+	return ok && s.conf.Rotation.enabled()
 }
 
 func NewStdout() core.DataSink {
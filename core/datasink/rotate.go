@@ -0,0 +1,249 @@
+package datasink
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/c2h5oh/datasize"
+	multierr "github.com/hashicorp/go-multierror"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+)
+
+// RotationConfig rotates file by size or time: current file is renamed to
+// <name>-<timestamp><ext>, and new one is created by the same path.
+// Rotation happens on write, at line boundary, so line is never split between files.
+type RotationConfig struct {
+	// MaxSize rotates file, when size of data written to it exceeds MaxSize. Size is counted before compression.
+	// Zero means no limit.
+	MaxSize datasize.ByteSize `config:"max_size"`
+	// Interval rotates file on write, when it is older than Interval, so idle file is not rotated. Zero means no limit.
+	Interval time.Duration `config:"interval" validate:"min-time=0s"`
+	// MaxFiles is a number of rotated files to keep. Older ones are removed. Zero keeps all.
+	MaxFiles int `config:"max_files" validate:"min=0"`
+	// MaxAge removes rotated files older than MaxAge. Zero keeps all.
+	MaxAge time.Duration `config:"max_age" validate:"min-time=0s"`
+}
+
+func (c RotationConfig) enabled() bool {
+	return c.MaxSize > 0 || c.Interval > 0
+}
+
+const rotatedTimeLayout = "20060102T150405.000000"
+
+var errClosed = errors.New("file sink is closed")
+
+// rotatingWriter writes compressed file and rotates it according to config.
+type rotatingWriter struct {
+	fs   afero.Afero
+	conf FileConfig
+	now  func() time.Time
+	// append opens the first file in append mode. Files created by rotation are always new.
+	append bool
+
+	mu         sync.Mutex
+	file       afero.File
+	compressor io.WriteCloser
+	written    uint64
+	lineEnded  bool
+	opened     time.Time
+	// cleanupErr is an error of rotated files removal, returned by Close not to fail writes.
+	cleanupErr error
+	closed     bool
+}
+
+func newRotatingWriter(fs afero.Afero, conf FileConfig, append bool) (*rotatingWriter, error) {
+	w := &rotatingWriter{fs: fs, conf: conf, now: time.Now, append: append}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *rotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, errClosed
+	}
+	if w.written == 0 || !w.shouldRotate(len(p)) {
+		return w.write(p)
+	}
+	if w.lineEnded {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+		return w.write(p)
+	}
+	i := bytes.IndexByte(p, '\n')
+	if i < 0 {
+		return w.write(p)
+	}
+	n, err := w.write(p[:i+1])
+	if err != nil {
+		return n, err
+	}
+	if err = w.rotate(); err != nil {
+		return n, err
+	}
+	m, err := w.write(p[i+1:])
+	return n + m, err
+}
+
+func (w *rotatingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	var err error
+	if closeErr := w.closeFile(); closeErr != nil {
+		err = multierr.Append(err, closeErr)
+	}
+	if w.cleanupErr != nil {
+		err = multierr.Append(err, w.cleanupErr)
+	}
+	return err
+}
+
+func (w *rotatingWriter) shouldRotate(size int) bool {
+	rotation := w.conf.Rotation
+	if rotation.MaxSize > 0 && w.written+uint64(size) > rotation.MaxSize.Bytes() {
+		return true
+	}
+	return rotation.Interval > 0 && w.now().Sub(w.opened) >= rotation.Interval
+}
+
+func (w *rotatingWriter) write(p []byte) (int, error) {
+	var n int
+	var err error
+	if w.compressor != nil {
+		n, err = w.compressor.Write(p)
+	} else {
+		n, err = w.file.Write(p)
+	}
+	w.written += uint64(n)
+	if n > 0 {
+		w.lineEnded = p[n-1] == '\n'
+	}
+	return n, err
+}
+
+func (w *rotatingWriter) open() error {
+	file, err := w.fs.OpenFile(w.conf.Path, openFlag(w.append), 0644)
+	if err != nil {
+		return err
+	}
+	w.append = false
+	var compressor io.WriteCloser
+	switch w.conf.Compression {
+	case CompressionGzip:
+		compressor = gzip.NewWriter(file)
+	case CompressionZstd:
+		compressor, err = zstd.NewWriter(file)
+		if err != nil {
+			_ = file.Close()
+			return errors.Wrap(err, "zstd writer create")
+		}
+	}
+	w.file, w.compressor = file, compressor
+	w.written, w.opened = 0, w.now()
+	return nil
+}
+
+func (w *rotatingWriter) closeFile() error {
+	var err error
+	if w.compressor != nil {
+		err = w.compressor.Close()
+	}
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (w *rotatingWriter) rotate() error {
+	if err := w.closeFile(); err != nil {
+		return errors.Wrap(err, "file close before rotation")
+	}
+	if err := w.fs.Rename(w.conf.Path, rotatedPath(w.conf.Path, w.now())); err != nil {
+		return errors.Wrap(err, "file rotation")
+	}
+	if err := w.open(); err != nil {
+		return errors.Wrap(err, "file open after rotation")
+	}
+	if err := w.cleanup(); err != nil {
+		w.cleanupErr = multierr.Append(w.cleanupErr, err)
+	}
+	return nil
+}
+
+// cleanup removes rotated files exceeding MaxFiles or MaxAge.
+func (w *rotatingWriter) cleanup() error {
+	rotation := w.conf.Rotation
+	if rotation.MaxFiles == 0 && rotation.MaxAge == 0 {
+		return nil
+	}
+	rotated, err := w.rotatedFiles()
+	if err != nil {
+		return err
+	}
+	var result error
+	now := w.now()
+	for i, info := range rotated {
+		old := rotation.MaxAge > 0 && now.Sub(info.ModTime()) > rotation.MaxAge
+		extra := rotation.MaxFiles > 0 && len(rotated)-i > rotation.MaxFiles
+		if !old && !extra {
+			continue
+		}
+		err := w.fs.Remove(filepath.Join(filepath.Dir(w.conf.Path), info.Name()))
+		if err != nil && !os.IsNotExist(err) {
+			result = multierr.Append(result, err)
+		}
+	}
+	return result
+}
+
+// rotatedFiles returns rotated files of path from the oldest to the newest.
+func (w *rotatingWriter) rotatedFiles() ([]os.FileInfo, error) {
+	dir := filepath.Dir(w.conf.Path)
+	stem, ext := splitExt(filepath.Base(w.conf.Path))
+	re := regexp.MustCompile("^" + regexp.QuoteMeta(stem) + `-\d{8}T\d{6}\.\d{6}` + regexp.QuoteMeta(ext) + "$")
+	infos, err := w.fs.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var rotated []os.FileInfo
+	for _, info := range infos {
+		if !info.IsDir() && re.MatchString(info.Name()) {
+			rotated = append(rotated, info)
+		}
+	}
+	// Timestamp layout is sorted lexicographically in time order.
+	sort.Slice(rotated, func(i, j int) bool {
+		return rotated[i].Name() < rotated[j].Name()
+	})
+	return rotated, nil
+}
+
+func rotatedPath(path string, t time.Time) string {
+	stem, ext := splitExt(path)
+	return stem + "-" + t.UTC().Format(rotatedTimeLayout) + ext
+}
+
+func splitExt(path string) (stem, ext string) {
+	ext = filepath.Ext(path)
+	if filepath.Base(path) == ext {
+		return path, ""
+	}
+	return path[:len(path)-len(ext)], ext
+}
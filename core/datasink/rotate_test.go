@@ -0,0 +1,163 @@
+package datasink
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yandex/pandora/core"
+)
+
+func TestFileSink_Compression(t *testing.T) {
+	const data = "line 1\nline 2\n"
+	decompress := map[string]func(r io.Reader) (io.Reader, error){
+		CompressionGzip: func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		CompressionZstd: func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) },
+	}
+	for compression, newReader := range decompress {
+		t.Run(compression, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			sink := NewFile(fs, FileConfig{Path: "phout.log.gz", Compression: compression})
+			w, err := sink.OpenSink()
+			require.NoError(t, err)
+			_, err = io.WriteString(w, data)
+			require.NoError(t, err)
+			require.NoError(t, w.Close())
+
+			compressed, err := afero.ReadFile(fs, "phout.log.gz")
+			require.NoError(t, err)
+			assert.NotEqual(t, data, string(compressed))
+			r, err := newReader(bytes.NewReader(compressed))
+			require.NoError(t, err)
+			got, err := io.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, data, string(got))
+		})
+	}
+
+	for compression, newReader := range decompress {
+		t.Run(compression+" append", func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			conf := FileConfig{Path: "phout.log.gz", Compression: compression}
+			for _, sink := range []core.DataSink{NewFile(fs, conf), NewAppendFile(fs, conf)} {
+				w, err := sink.OpenSink()
+				require.NoError(t, err)
+				_, err = io.WriteString(w, data)
+				require.NoError(t, err)
+				require.NoError(t, w.Close())
+			}
+
+			compressed, err := afero.ReadFile(fs, "phout.log.gz")
+			require.NoError(t, err)
+			r, err := newReader(bytes.NewReader(compressed))
+			require.NoError(t, err)
+			got, err := io.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, data+data, string(got))
+		})
+	}
+
+	t.Run("unknown", func(t *testing.T) {
+		sink := NewFile(afero.NewMemMapFs(), FileConfig{Path: "phout.log", Compression: "lz4"})
+		_, err := sink.OpenSink()
+		assert.Error(t, err)
+	})
+}
+
+func TestFileSink_Rotation(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("by size at line boundary", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		w, clock := openRotating(t, fs, FileConfig{Path: "/out/phout.log", Rotation: RotationConfig{MaxSize: 8}}, start)
+		for i, chunk := range []string{"aaaa\nbb", "bb\n", "cccc\ndd", "dd\n"} {
+			*clock = start.Add(time.Duration(i) * time.Second)
+			_, err := io.WriteString(w, chunk)
+			require.NoError(t, err)
+		}
+		require.NoError(t, w.Close())
+
+		assert.Equal(t, map[string]string{
+			"phout-20240101T000001.000000.log": "aaaa\nbbbb\n",
+			"phout-20240101T000003.000000.log": "cccc\ndddd\n",
+			"phout.log":                        "",
+		}, readDir(t, fs, "/out"))
+	})
+
+	t.Run("by interval", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		w, clock := openRotating(t, fs, FileConfig{Path: "answ.log", Rotation: RotationConfig{Interval: time.Minute}}, start)
+		for i := 0; i < 3; i++ {
+			*clock = start.Add(time.Duration(i) * 40 * time.Second)
+			_, err := io.WriteString(w, "entry\n")
+			require.NoError(t, err)
+		}
+		require.NoError(t, w.Close())
+
+		assert.Equal(t, map[string]string{
+			"answ-20240101T000120.000000.log": "entry\nentry\n",
+			"answ.log":                        "entry\n",
+		}, readDir(t, fs, "."))
+	})
+
+	t.Run("retention", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fs, "/out/other-20240101T000000.000000.log", nil, 0644))
+		w, clock := openRotating(t, fs, FileConfig{Path: "/out/phout.log", Compression: CompressionGzip,
+			Rotation: RotationConfig{MaxSize: 1, MaxFiles: 2}}, start)
+		for i := 0; i < 5; i++ {
+			*clock = start.Add(time.Duration(i) * time.Second)
+			_, err := io.WriteString(w, "line\n")
+			require.NoError(t, err)
+		}
+		require.NoError(t, w.Close())
+
+		assert.Equal(t, []string{
+			"other-20240101T000000.000000.log",
+			"phout-20240101T000003.000000.log",
+			"phout-20240101T000004.000000.log",
+			"phout.log",
+		}, names(readDir(t, fs, "/out")))
+	})
+}
+
+// openRotating opens rotating writer, which clock is set by test.
+func openRotating(t *testing.T, fs afero.Fs, conf FileConfig, start time.Time) (*rotatingWriter, *time.Time) {
+	wc, err := NewFile(fs, conf).OpenSink()
+	require.NoError(t, err)
+	w, ok := wc.(*rotatingWriter)
+	require.True(t, ok)
+	clock := start
+	w.now = func() time.Time { return clock }
+	w.opened = start
+	return w, &clock
+}
+
+func readDir(t *testing.T, fs afero.Fs, dir string) map[string]string {
+	infos, err := afero.ReadDir(fs, dir)
+	require.NoError(t, err)
+	files := map[string]string{}
+	for _, info := range infos {
+		data, err := afero.ReadFile(fs, dir+string(os.PathSeparator)+info.Name())
+		require.NoError(t, err)
+		files[info.Name()] = string(data)
+	}
+	return files
+}
+
+func names(files map[string]string) []string {
+	var result []string
+	for name := range files {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
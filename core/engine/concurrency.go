@@ -0,0 +1,147 @@
+package engine
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ConcurrencyStage changes target number of instances linearly from the previous stage value
+// (zero for the first stage) to To during Duration. Stage with zero Duration changes it instantly.
+type ConcurrencyStage struct {
+	Duration time.Duration `config:"duration" validate:"min-time=0s"`
+	To       int           `config:"to" validate:"min=0"`
+}
+
+// Concurrency is target number of instances over time of closed model pool.
+type Concurrency []ConcurrencyStage
+
+// At returns target number of instances at elapsed time since start, and elapsed time of its next change.
+// Returns ok == false, when all stages are finished.
+// Increasing target is rounded down, and decreasing target is rounded up, so an instance is added
+// when its whole part is reached, and removed when it is left.
+func (c Concurrency) At(elapsed time.Duration) (target int, next time.Duration, ok bool) {
+	var from int
+	var stageStart time.Duration
+	for _, stage := range c {
+		stageEnd := stageStart + stage.Duration
+		if elapsed >= stageEnd {
+			from, stageStart = stage.To, stageEnd
+			continue
+		}
+		if stage.To == from {
+			return from, stageEnd, true
+		}
+		delta := float64(stage.To - from)
+		level := float64(from) + delta*float64(elapsed-stageStart)/float64(stage.Duration)
+		var nextLevel float64
+		if delta > 0 {
+			target = int(math.Floor(level))
+			nextLevel = float64(target + 1)
+		} else {
+			target = int(math.Ceil(level))
+			nextLevel = float64(target - 1)
+		}
+		next = stageStart + time.Duration(math.Ceil(float64(stage.Duration)*(nextLevel-float64(from))/delta))
+		if next > stageEnd {
+			next = stageEnd
+		}
+		return target, next, true
+	}
+	return from, stageStart, false
+}
+
+// followConcurrency starts and gracefully stops instances, so number of running instances follows
+// pool concurrency. Finished instances are replaced by new ones, until an instance finishes because
+// provider is out of ammo or shared schedule is finished: new instances would finish at once too.
+// All running instances are stopped, when concurrency stages are finished or startCtx is done.
+func (p *instancePool) followConcurrency(
+	startCtx, runCtx context.Context,
+	deps instanceDeps,
+	runRes chan<- instanceRunResult) (started int, err error) {
+	type running struct {
+		id   int
+		stop context.CancelFunc
+	}
+	var (
+		active    []running
+		exhausted bool
+	)
+	finished := make(chan instanceRunResult)
+	done := make(chan struct{})
+	defer func() {
+		close(done)
+		for _, r := range active {
+			r.stop()
+		}
+	}()
+
+	start := time.Now()
+	for {
+		target, next, ok := p.Concurrency.At(time.Since(start))
+		if !ok {
+			p.log.Info("Concurrency stages finished. Stopping instances.", zap.Int("instances", len(active)))
+			return started, nil
+		}
+		for !exhausted && len(active) < target {
+			id := started
+			started++
+			p.control.instanceStarted()
+			stopCtx, stop := context.WithCancel(runCtx)
+			active = append(active, running{id: id, stop: stop})
+			go func() {
+				res := instanceRunResult{id, runStoppableInstance(runCtx, stopCtx, p.log, p.ID, id, deps)}
+				runRes <- res
+				select {
+				case finished <- res:
+				case <-done:
+				}
+			}()
+		}
+		for len(active) > target {
+			last := active[len(active)-1]
+			last.stop()
+			active = active[:len(active)-1]
+		}
+		if exhausted && len(active) == 0 {
+			p.log.Info("Ammo or schedule is finished. No instances left.")
+			return started, nil
+		}
+
+		select {
+		case res := <-finished:
+			for i, r := range active {
+				if r.id == res.ID {
+					r.stop()
+					active = append(active[:i], active[i+1:]...)
+					// Instance with per instance schedule finishes without error, when its schedule is finished,
+					// and is replaced by instance with new schedule.
+					if res.Err == outOfAmmoErr || res.Err == nil && !p.RPSPerInstance {
+						exhausted = true
+					}
+					break
+				}
+			}
+		case <-time.After(time.Until(start.Add(next))):
+		case <-startCtx.Done():
+			return started, startCtx.Err()
+		}
+	}
+}
+
+// runStoppableInstance runs instance, that gun is bound with runCtx, but shooting is stopped on stopCtx cancel.
+// Instance stopped via stopCtx finishes without error.
+func runStoppableInstance(runCtx, stopCtx context.Context, log *zap.Logger, poolID string, id int, deps instanceDeps) error {
+	instance, err := newInstance(runCtx, log, poolID, id, deps)
+	if err != nil {
+		return err
+	}
+	defer instance.Close()
+	err = instance.Run(stopCtx)
+	if stopCtx.Err() != nil && runCtx.Err() == nil {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,101 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yandex/pandora/core"
+	"github.com/yandex/pandora/core/provider"
+	"github.com/yandex/pandora/core/schedule"
+)
+
+func TestConcurrency_At(t *testing.T) {
+	c := Concurrency{
+		{Duration: 4 * time.Second, To: 4},
+		{Duration: 2 * time.Second, To: 4},
+		{Duration: 0, To: 2},
+		{Duration: 2 * time.Second, To: 0},
+	}
+	tests := []struct {
+		elapsed time.Duration
+		target  int
+		next    time.Duration
+		ok      bool
+	}{
+		{0, 0, time.Second, true},
+		{1500 * time.Millisecond, 1, 2 * time.Second, true},
+		{4 * time.Second, 4, 6 * time.Second, true},
+		{6 * time.Second, 2, 7 * time.Second, true},
+		{7500 * time.Millisecond, 1, 8 * time.Second, true},
+		{8 * time.Second, 0, 8 * time.Second, false},
+	}
+	for _, tt := range tests {
+		target, next, ok := c.At(tt.elapsed)
+		assert.Equal(t, tt.target, target, tt.elapsed)
+		assert.Equal(t, tt.next, next, tt.elapsed)
+		assert.Equal(t, tt.ok, ok, tt.elapsed)
+	}
+}
+
+func TestInstancePool_Concurrency(t *testing.T) {
+	conf, _ := newTestPoolConf()
+	conf.RPSPerInstance = true
+	conf.NewRPSSchedule = func() (core.Schedule, error) {
+		return schedule.NewThinkTime(func() time.Duration { return 10 * time.Millisecond }, 0), nil
+	}
+	conf.StartupSchedule = nil
+	conf.Concurrency = Concurrency{
+		{Duration: 0, To: 3},
+		{Duration: 200 * time.Millisecond, To: 3},
+		{Duration: 0, To: 1},
+		{Duration: 200 * time.Millisecond, To: 1},
+	}
+	pool := newPool(newNopLogger(), newTestMetrics(), nil, conf)
+
+	runErr := make(chan error)
+	go func() {
+		runErr <- pool.Run(context.Background())
+	}()
+	require.Eventually(t, func() bool {
+		return pool.metrics.InstanceStart.Get()-pool.metrics.InstanceFinish.Get() == 3
+	}, time.Second, 5*time.Millisecond)
+	require.Eventually(t, func() bool {
+		return pool.metrics.InstanceStart.Get()-pool.metrics.InstanceFinish.Get() == 1
+	}, time.Second, 5*time.Millisecond)
+
+	select {
+	case err := <-runErr:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("pool has not been finished")
+	}
+	assert.EqualValues(t, 3, pool.metrics.InstanceStart.Get())
+	assert.EqualValues(t, 3, pool.metrics.InstanceFinish.Get())
+}
+
+func TestInstancePool_ConcurrencyOutOfAmmo(t *testing.T) {
+	conf, _ := newTestPoolConf()
+	conf.Provider = provider.NewNum(5)
+	conf.RPSPerInstance = true
+	conf.NewRPSSchedule = func() (core.Schedule, error) {
+		return schedule.NewThinkTime(func() time.Duration { return time.Millisecond }, 0), nil
+	}
+	conf.StartupSchedule = nil
+	conf.Concurrency = Concurrency{{Duration: 0, To: 3}, {Duration: 10 * time.Second, To: 3}}
+	pool := newPool(newNopLogger(), newTestMetrics(), nil, conf)
+
+	runErr := make(chan error)
+	go func() {
+		runErr <- pool.Run(context.Background())
+	}()
+	select {
+	case err := <-runErr:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("pool has not been finished")
+	}
+	assert.EqualValues(t, 3, pool.metrics.InstanceStart.Get())
+}
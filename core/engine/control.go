@@ -0,0 +1,462 @@
+package engine
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/yandex/pandora/core"
+)
+
+// Control changes load of running engine pools.
+// Control is enabled by Engine.EnableControl, and pools are added to it on Engine.Run.
+type Control struct {
+	mu    sync.Mutex
+	pools []*PoolControl
+}
+
+// Pools returns controls of all started pools.
+func (c *Control) Pools() []*PoolControl {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*PoolControl(nil), c.pools...)
+}
+
+// Pool returns control of pool with id, or nil if there is no such pool.
+func (c *Control) Pool(id string) *PoolControl {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, p := range c.pools {
+		if p.id == id {
+			return p
+		}
+	}
+	return nil
+}
+
+// Stop gracefully stops all pools.
+func (c *Control) Stop() {
+	for _, p := range c.Pools() {
+		p.Stop()
+	}
+}
+
+func (c *Control) addPool(id string, closedModel, rpsPerInstance bool) *PoolControl {
+	p := newPoolControl(id)
+	p.closedModel = closedModel
+	p.rpsPerInstance = rpsPerInstance
+	c.mu.Lock()
+	c.pools = append(c.pools, p)
+	c.mu.Unlock()
+	return p
+}
+
+type PoolState string
+
+const (
+	PoolRunning PoolState = "running"
+	PoolPaused  PoolState = "paused"
+	PoolStopped PoolState = "stopped"
+)
+
+type PoolStatus struct {
+	ID    string    `json:"id"`
+	State PoolState `json:"state"`
+	// Multiplier of configured RPS schedule.
+	Multiplier float64 `json:"multiplier"`
+	// RPS is set, when configured RPS schedule is replaced by constant RPS. It is the total RPS of pool,
+	// in case of rps-per-instance it is divided between active instances.
+	RPS             float64 `json:"rps,omitempty"`
+	Instances       int64   `json:"instances"`
+	ActiveInstances int64   `json:"active_instances"`
+}
+
+var ErrPoolStopped = errors.New("pool is stopped")
+
+// PoolControl changes RPS, pauses, resumes and stops pool, and adds instances to it.
+// Changes are applied to all RPS schedules of pool: to the shared one, or to schedule of every instance
+// in case of rps-per-instance. Constant RPS is the total RPS of pool, so in case of rps-per-instance
+// it is divided between active instances.
+// All methods are nil safe, so pool without control uses them as no-op.
+type PoolControl struct {
+	id string
+	// closedModel pool instances follow concurrency, so they can't be added.
+	closedModel    bool
+	rpsPerInstance bool
+
+	mu     sync.Mutex
+	params scheduleParams
+	// changed is closed and replaced on every change, to wake up paused schedules and instance start.
+	changed        chan struct{}
+	schedules      []*controlledSchedule
+	addedInstances int
+	stopStart      func()
+	// active is the number of active instances, which share constant RPS in case of rps-per-instance.
+	active int
+
+	started         atomic.Int64
+	finished        atomic.Int64
+	startupFinished atomic.Bool
+}
+
+type scheduleParams struct {
+	multiplier float64
+	rps        float64
+	paused     bool
+	stopped    bool
+}
+
+func newPoolControl(id string) *PoolControl {
+	return &PoolControl{
+		id:      id,
+		params:  scheduleParams{multiplier: 1},
+		changed: make(chan struct{}),
+	}
+}
+
+func (c *PoolControl) Status() PoolStatus {
+	c.mu.Lock()
+	params := c.params
+	c.mu.Unlock()
+	state := PoolRunning
+	switch {
+	case params.stopped:
+		state = PoolStopped
+	case params.paused:
+		state = PoolPaused
+	}
+	started := c.started.Load()
+	return PoolStatus{
+		ID:              c.id,
+		State:           state,
+		Multiplier:      params.multiplier,
+		RPS:             params.rps,
+		Instances:       started,
+		ActiveInstances: started - c.finished.Load(),
+	}
+}
+
+// SetMultiplier multiplies RPS of configured schedule by m. Constant RPS set by SetRPS is reset.
+func (c *PoolControl) SetMultiplier(m float64) error {
+	if m <= 0 {
+		return errors.Errorf("multiplier should be positive, got %v", m)
+	}
+	return c.update(func(p *scheduleParams) {
+		p.multiplier = m
+		p.rps = 0
+	})
+}
+
+// SetRPS replaces configured schedule by constant total rps of pool, until SetMultiplier call.
+// Configured schedule is frozen meanwhile.
+func (c *PoolControl) SetRPS(rps float64) error {
+	if rps <= 0 {
+		return errors.Errorf("rps should be positive, got %v", rps)
+	}
+	return c.update(func(p *scheduleParams) {
+		p.rps = rps
+	})
+}
+
+// Pause stops shooting until Resume. Configured schedule is frozen meanwhile.
+func (c *PoolControl) Pause() error {
+	return c.update(func(p *scheduleParams) {
+		p.paused = true
+	})
+}
+
+func (c *PoolControl) Resume() error {
+	return c.update(func(p *scheduleParams) {
+		p.paused = false
+	})
+}
+
+// AddInstances starts n more instances. They are started as soon as instance start waits for the
+// next startup schedule event, or immediately, if startup schedule is finished.
+func (c *PoolControl) AddInstances(n int) error {
+	if n <= 0 {
+		return errors.Errorf("instances number should be positive, got %v", n)
+	}
+	if c != nil && c.closedModel {
+		return errors.New("instances of pool follow concurrency and can't be added")
+	}
+	return c.update(func(*scheduleParams) {
+		c.addedInstances += n
+	})
+}
+
+// Stop finishes RPS schedules of pool, so instances finish after current shoot.
+func (c *PoolControl) Stop() {
+	if c == nil {
+		return
+	}
+	_ = c.update(func(p *scheduleParams) {
+		p.stopped = true
+	})
+	c.mu.Lock()
+	stopStart := c.stopStart
+	c.mu.Unlock()
+	if stopStart != nil {
+		stopStart()
+	}
+}
+
+func (c *PoolControl) update(change func(p *scheduleParams)) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.params.stopped {
+		return ErrPoolStopped
+	}
+	old := c.params
+	change(&c.params)
+	now := time.Now()
+	for _, s := range c.schedules {
+		s.reanchor(now, old)
+	}
+	close(c.changed)
+	c.changed = make(chan struct{})
+	return nil
+}
+
+func (c *PoolControl) setStopStart(stopStart func()) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.stopStart = stopStart
+	c.mu.Unlock()
+}
+
+func (c *PoolControl) instanceStarted() {
+	if c != nil {
+		c.started.Add(1)
+		c.changeActive(1)
+	}
+}
+
+func (c *PoolControl) instanceFinished() {
+	if c != nil {
+		c.finished.Add(1)
+		c.changeActive(-1)
+	}
+}
+
+// changeActive changes number of active instances, and so RPS of every instance schedule
+// in case of rps-per-instance.
+func (c *PoolControl) changeActive(delta int) {
+	if !c.rpsPerInstance {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	old := c.scheduleRPS()
+	c.active += delta
+	if c.params.rps == 0 || c.params.paused {
+		return
+	}
+	for _, s := range c.schedules {
+		s.rerate(old)
+	}
+}
+
+// scheduleRPS returns constant RPS of every RPS schedule. It should be called with mutex held.
+func (c *PoolControl) scheduleRPS() float64 {
+	if c.rpsPerInstance && c.active > 1 {
+		return c.params.rps / float64(c.active)
+	}
+	return c.params.rps
+}
+
+// allInstancesFinished returns true, when startup schedule is finished, and all started instances
+// are awaited. Instance start waits for added instances after startup schedule finish,
+// so it should be canceled then.
+func (c *PoolControl) allInstancesFinished(awaited int) bool {
+	return c != nil && c.startupFinished.Load() && int64(awaited) >= c.started.Load()
+}
+
+// wrapStartup returns startup schedule, that also starts added instances.
+// After finish of s, it waits for added instances until ctx is done or pool is stopped.
+func (c *PoolControl) wrapStartup(ctx context.Context, s core.Schedule) core.Schedule {
+	if c == nil {
+		return s
+	}
+	return &controlledStartup{Schedule: s, control: c, ctx: ctx}
+}
+
+type controlledStartup struct {
+	core.Schedule
+	control  *PoolControl
+	ctx      context.Context
+	finished bool
+}
+
+func (s *controlledStartup) Next() (time.Time, bool) {
+	c := s.control
+	for {
+		c.mu.Lock()
+		if c.addedInstances > 0 && !c.params.stopped {
+			c.addedInstances--
+			c.mu.Unlock()
+			return time.Now(), true
+		}
+		changed, stopped := c.changed, c.params.stopped
+		c.mu.Unlock()
+		if stopped {
+			return time.Now(), false
+		}
+		if !s.finished {
+			ts, ok := s.Schedule.Next()
+			if ok {
+				return ts, true
+			}
+			s.finished = true
+			c.startupFinished.Store(true)
+		}
+		select {
+		case <-changed:
+		case <-s.ctx.Done():
+			return time.Now(), false
+		}
+	}
+}
+
+func (s *controlledStartup) Left() int {
+	return -1
+}
+
+// wrapSchedule returns RPS schedule controlled by c.
+// Paused schedule blocks on Next until resume, stop or ctx is done.
+func (c *PoolControl) wrapSchedule(ctx context.Context, s core.Schedule) core.Schedule {
+	if c == nil {
+		return s
+	}
+	cs := &controlledSchedule{Schedule: s, control: c, ctx: ctx}
+	c.mu.Lock()
+	c.schedules = append(c.schedules, cs)
+	c.mu.Unlock()
+	return cs
+}
+
+// controlledSchedule maps virtual time of configured schedule to real time.
+// Virtual time goes multiplier times faster than real, and is frozen on pause and constant RPS.
+// Fields, except startOnce, are guarded by control mutex. Wrapped schedule is called without it,
+// so slow or shared schedule doesn't block control and other instances.
+type controlledSchedule struct {
+	core.Schedule
+	control   *PoolControl
+	ctx       context.Context
+	startOnce sync.Once
+
+	started       bool
+	anchorReal    time.Time
+	anchorVirtual time.Time
+	rpsShots      int64
+	last          time.Time
+}
+
+func (s *controlledSchedule) Start(startAt time.Time) {
+	s.startOnce.Do(func() {
+		s.Schedule.Start(startAt)
+		c := s.control
+		c.mu.Lock()
+		s.started = true
+		s.anchorReal, s.anchorVirtual, s.last = startAt, startAt, startAt
+		c.mu.Unlock()
+	})
+}
+
+func (s *controlledSchedule) SeedInstance(id int) {
+	if seeded, ok := s.Schedule.(instanceSeeded); ok {
+		seeded.SeedInstance(id)
+	}
+}
+
+func (s *controlledSchedule) Next() (time.Time, bool) {
+	c := s.control
+	s.Start(time.Now())
+	for {
+		c.mu.Lock()
+		params := c.params
+		switch {
+		case params.stopped:
+			ts := s.last
+			if now := time.Now(); now.After(ts) {
+				ts = now
+			}
+			c.mu.Unlock()
+			return ts, false
+		case params.paused:
+			changed := c.changed
+			c.mu.Unlock()
+			select {
+			case <-changed:
+				continue
+			case <-s.ctx.Done():
+				return time.Now(), false
+			}
+		case params.rps > 0:
+			ts := s.anchorReal.Add(time.Duration(float64(s.rpsShots) * 1e9 / c.scheduleRPS()))
+			s.rpsShots++
+			ts = s.monotonic(ts)
+			c.mu.Unlock()
+			return ts, true
+		default:
+			anchorReal, anchorVirtual := s.anchorReal, s.anchorVirtual
+			c.mu.Unlock()
+			virtual, ok := s.Schedule.Next()
+			ts := anchorReal.Add(time.Duration(float64(virtual.Sub(anchorVirtual)) / params.multiplier))
+			c.mu.Lock()
+			ts = s.monotonic(ts)
+			c.mu.Unlock()
+			return ts, ok
+		}
+	}
+}
+
+func (s *controlledSchedule) monotonic(ts time.Time) time.Time {
+	if ts.Before(s.last) {
+		return s.last
+	}
+	s.last = ts
+	return ts
+}
+
+func (s *controlledSchedule) Left() int {
+	c := s.control
+	c.mu.Lock()
+	params := c.params
+	c.mu.Unlock()
+	switch {
+	case params.stopped:
+		return 0
+	case params.rps > 0:
+		return -1
+	}
+	return s.Schedule.Left()
+}
+
+// reanchor fixes virtual time of schedule at now, before params change from old.
+func (s *controlledSchedule) reanchor(now time.Time, old scheduleParams) {
+	if !s.started {
+		return
+	}
+	if !old.paused && old.rps == 0 {
+		s.anchorVirtual = s.anchorVirtual.Add(time.Duration(float64(now.Sub(s.anchorReal)) * old.multiplier))
+	}
+	s.anchorReal = now
+	s.rpsShots = 0
+}
+
+// rerate moves anchor to the next constant RPS shot at old rps, so RPS change doesn't shift it.
+func (s *controlledSchedule) rerate(old float64) {
+	if !s.started || old == 0 {
+		return
+	}
+	s.anchorReal = s.anchorReal.Add(time.Duration(float64(s.rpsShots) * 1e9 / old))
+	s.rpsShots = 0
+}
@@ -0,0 +1,144 @@
+package engine
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// NewControlHandler returns HTTP API of control:
+//
+//	GET  /pools                     - statuses of all pools
+//	GET  /pools/<id>                - status of pool
+//	POST /pools/<id>/rps            - {"multiplier": 2} multiplies configured RPS, {"rps": 100} sets constant total RPS of pool
+//	POST /pools/<id>/pause          - pauses shooting
+//	POST /pools/<id>/resume         - resumes shooting
+//	POST /pools/<id>/instances      - {"add": 10} starts more instances
+//	POST /pools/<id>/stop           - gracefully stops pool
+//	POST /stop                      - gracefully stops all pools
+//
+// Pool actions respond with pool status.
+func NewControlHandler(c *Control) http.Handler {
+	return &controlHandler{control: c}
+}
+
+type controlHandler struct {
+	control *Control
+}
+
+type rpsRequest struct {
+	Multiplier *float64 `json:"multiplier"`
+	RPS        *float64 `json:"rps"`
+}
+
+type instancesRequest struct {
+	Add int `json:"add"`
+}
+
+func (h *controlHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.URL.Path, "/")
+	if path == "stop" {
+		if r.Method != http.MethodPost {
+			writeControlError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+			return
+		}
+		h.control.Stop()
+		writeControlJSON(w, h.statuses())
+		return
+	}
+	parts := strings.Split(path, "/")
+	if parts[0] != "pools" || len(parts) > 3 {
+		writeControlError(w, http.StatusNotFound, errors.New("not found"))
+		return
+	}
+	if len(parts) == 1 {
+		if r.Method != http.MethodGet {
+			writeControlError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+			return
+		}
+		writeControlJSON(w, h.statuses())
+		return
+	}
+	pool := h.control.Pool(parts[1])
+	if pool == nil {
+		writeControlError(w, http.StatusNotFound, errors.Errorf("pool %q not found", parts[1]))
+		return
+	}
+	if len(parts) == 2 {
+		if r.Method != http.MethodGet {
+			writeControlError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+			return
+		}
+		writeControlJSON(w, pool.Status())
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeControlError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	var err error
+	switch parts[2] {
+	case "rps":
+		var req rpsRequest
+		if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeControlError(w, http.StatusBadRequest, errors.WithMessage(err, "invalid body"))
+			return
+		}
+		switch {
+		case req.RPS != nil && req.Multiplier != nil:
+			err = errors.New("only one of multiplier and rps should be set")
+		case req.RPS != nil:
+			err = pool.SetRPS(*req.RPS)
+		case req.Multiplier != nil:
+			err = pool.SetMultiplier(*req.Multiplier)
+		default:
+			err = errors.New("multiplier or rps should be set")
+		}
+	case "pause":
+		err = pool.Pause()
+	case "resume":
+		err = pool.Resume()
+	case "instances":
+		var req instancesRequest
+		if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeControlError(w, http.StatusBadRequest, errors.WithMessage(err, "invalid body"))
+			return
+		}
+		err = pool.AddInstances(req.Add)
+	case "stop":
+		pool.Stop()
+	default:
+		writeControlError(w, http.StatusNotFound, errors.Errorf("unknown action %q", parts[2]))
+		return
+	}
+	switch {
+	case err == ErrPoolStopped:
+		writeControlError(w, http.StatusConflict, err)
+	case err != nil:
+		writeControlError(w, http.StatusBadRequest, err)
+	default:
+		writeControlJSON(w, pool.Status())
+	}
+}
+
+func (h *controlHandler) statuses() []PoolStatus {
+	pools := h.control.Pools()
+	statuses := make([]PoolStatus, len(pools))
+	for i, p := range pools {
+		statuses[i] = p.Status()
+	}
+	return statuses
+}
+
+func writeControlJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeControlError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
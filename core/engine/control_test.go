@@ -0,0 +1,244 @@
+package engine
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yandex/pandora/core"
+	"github.com/yandex/pandora/core/schedule"
+)
+
+// changeAt applies params change, as it was made at time at.
+func changeAt(c *PoolControl, at time.Time, change func(p *scheduleParams)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	old := c.params
+	change(&c.params)
+	for _, s := range c.schedules {
+		s.reanchor(at, old)
+	}
+}
+
+func expectNext(t *testing.T, s core.Schedule, want time.Time) {
+	t.Helper()
+	got, ok := s.Next()
+	require.True(t, ok)
+	assert.Equal(t, want, got)
+}
+
+func TestControlledSchedule(t *testing.T) {
+	t.Run("multiplier and rps", func(t *testing.T) {
+		c := newPoolControl("pool")
+		s := c.wrapSchedule(context.Background(), schedule.NewConst(10, 10*time.Second))
+		start := time.Now()
+		s.Start(start)
+		ms := func(n int) time.Time { return start.Add(time.Duration(n) * time.Millisecond) }
+
+		expectNext(t, s, ms(0))
+		expectNext(t, s, ms(100))
+
+		changeAt(c, ms(150), func(p *scheduleParams) { p.multiplier = 2 })
+		expectNext(t, s, ms(175))
+		expectNext(t, s, ms(225))
+
+		changeAt(c, ms(250), func(p *scheduleParams) { p.rps = 4 })
+		assert.Equal(t, -1, s.Left())
+		expectNext(t, s, ms(250))
+		expectNext(t, s, ms(500))
+
+		// Configured schedule has been frozen at 350ms of its time.
+		changeAt(c, ms(600), func(p *scheduleParams) { p.rps, p.multiplier = 0, 1 })
+		expectNext(t, s, ms(650))
+	})
+
+	t.Run("rps is divided between instances", func(t *testing.T) {
+		c := newPoolControl("pool")
+		c.rpsPerInstance = true
+		c.instanceStarted()
+		c.instanceStarted()
+		s := c.wrapSchedule(context.Background(), schedule.NewConst(10, 10*time.Second))
+		start := time.Now()
+		s.Start(start)
+		ms := func(n int) time.Time { return start.Add(time.Duration(n) * time.Millisecond) }
+
+		changeAt(c, ms(0), func(p *scheduleParams) { p.rps = 4 })
+		expectNext(t, s, ms(0))
+		expectNext(t, s, ms(500))
+
+		// Next shot is not shifted, following ones go at RPS of the only active instance.
+		c.instanceFinished()
+		expectNext(t, s, ms(1000))
+		expectNext(t, s, ms(1250))
+		assert.Equal(t, float64(4), c.Status().RPS)
+	})
+
+	t.Run("pause and resume", func(t *testing.T) {
+		c := newPoolControl("pool")
+		s := c.wrapSchedule(context.Background(), schedule.NewConst(1, time.Hour))
+		s.Start(time.Now())
+		_, _ = s.Next()
+		require.NoError(t, c.Pause())
+		assert.Equal(t, PoolPaused, c.Status().State)
+
+		next := make(chan time.Time)
+		go func() {
+			ts, _ := s.Next()
+			next <- ts
+		}()
+		select {
+		case <-next:
+			t.Fatal("paused schedule returned next")
+		case <-time.After(50 * time.Millisecond):
+		}
+		resumed := time.Now()
+		require.NoError(t, c.Resume())
+		// Pause time is not counted, so next operation is a second after resume.
+		assert.False(t, (<-next).Before(resumed.Add(time.Second-10*time.Millisecond)))
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		c := newPoolControl("pool")
+		var stopStartCalled bool
+		c.setStopStart(func() { stopStartCalled = true })
+		s := c.wrapSchedule(context.Background(), schedule.NewConst(1000, time.Hour))
+		s.Start(time.Now())
+		c.Stop()
+		_, ok := s.Next()
+		assert.False(t, ok)
+		assert.Equal(t, 0, s.Left())
+		assert.True(t, stopStartCalled)
+		assert.Equal(t, ErrPoolStopped, c.SetMultiplier(2))
+	})
+
+	t.Run("blocked schedule does not block control", func(t *testing.T) {
+		c := newPoolControl("pool")
+		blocked := &blockingSchedule{Schedule: schedule.NewConst(10, time.Hour), entered: make(chan struct{}), release: make(chan struct{})}
+		s := c.wrapSchedule(context.Background(), blocked)
+		s.Start(time.Now())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			s.Next()
+		}()
+		<-blocked.entered
+		require.NoError(t, c.SetMultiplier(2))
+		close(blocked.release)
+		<-done
+	})
+
+	t.Run("invalid values", func(t *testing.T) {
+		c := newPoolControl("pool")
+		assert.Error(t, c.SetMultiplier(0))
+		assert.Error(t, c.SetRPS(-1))
+		assert.Error(t, c.AddInstances(0))
+	})
+}
+
+func TestControl_Pool(t *testing.T) {
+	conf, _ := newTestPoolConf()
+	conf.NewRPSSchedule = func() (core.Schedule, error) {
+		return schedule.NewConst(100, time.Hour), nil
+	}
+	conf.RPSPerInstance = true
+	conf.StartupSchedule = schedule.NewOnce(1)
+	engine := New(newNopLogger(), newTestMetrics(), Config{[]InstancePoolConfig{conf}})
+	control := engine.EnableControl()
+
+	runErr := make(chan error)
+	go func() {
+		runErr <- engine.Run(context.Background())
+	}()
+
+	var pool *PoolControl
+	require.Eventually(t, func() bool {
+		pool = control.Pool("pool_0")
+		return pool != nil && pool.Status().Instances == 1
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, pool.AddInstances(2))
+	require.Eventually(t, func() bool {
+		return pool.Status().ActiveInstances == 3
+	}, time.Second, 10*time.Millisecond)
+
+	control.Stop()
+	select {
+	case err := <-runErr:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("engine has not been stopped")
+	}
+	assert.Equal(t, PoolStatus{ID: "pool_0", State: PoolStopped, Multiplier: 1, Instances: 3}, pool.Status())
+}
+
+func TestControlHandler(t *testing.T) {
+	control := &Control{}
+	control.addPool("pool_0", false, false)
+	server := httptest.NewServer(NewControlHandler(control))
+	defer server.Close()
+
+	do := func(method, path, body string) (int, string) {
+		req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(data)
+	}
+
+	code, body := do(http.MethodGet, "/pools", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `[{"id": "pool_0", "state": "running", "multiplier": 1, "instances": 0, "active_instances": 0}]`, body)
+
+	code, body = do(http.MethodPost, "/pools/pool_0/rps", `{"multiplier": 1.5}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, body, `"multiplier":1.5`)
+
+	code, body = do(http.MethodPost, "/pools/pool_0/rps", `{"rps": 100}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, body, `"rps":100`)
+
+	code, body = do(http.MethodPost, "/pools/pool_0/pause", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, body, `"state":"paused"`)
+
+	code, _ = do(http.MethodPost, "/pools/pool_0/rps", `{}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = do(http.MethodPost, "/pools/pool_0/instances", `{"add": -1}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = do(http.MethodGet, "/pools/pool_0/pause", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, code)
+	code, _ = do(http.MethodGet, "/pools/unknown", "")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, body = do(http.MethodPost, "/stop", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, body, `"state":"stopped"`)
+	code, _ = do(http.MethodPost, "/pools/pool_0/resume", "")
+	assert.Equal(t, http.StatusConflict, code)
+}
+
+// blockingSchedule closes entered on first Next and blocks Next until release is closed.
+type blockingSchedule struct {
+	core.Schedule
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingSchedule) Next() (time.Time, bool) {
+	select {
+	case <-s.entered:
+	default:
+		close(s.entered)
+	}
+	<-s.release
+	return s.Schedule.Next()
+}
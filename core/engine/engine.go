@@ -25,8 +25,10 @@ type InstancePoolConfig struct {
 	NewGun          func() (core.Gun, error)      `config:"gun" validate:"required"`
 	RPSPerInstance  bool                          `config:"rps-per-instance"`
 	NewRPSSchedule  func() (core.Schedule, error) `config:"rps" validate:"required"`
-	StartupSchedule core.Schedule                 `config:"startup" validate:"required"`
-	DiscardOverflow bool                          `config:"discard_overflow"`
+	StartupSchedule core.Schedule                 `config:"startup"`
+	// Concurrency makes pool closed model: number of running instances follows it, instead of startup schedule.
+	Concurrency     Concurrency `config:"concurrency" validate:"dive"`
+	DiscardOverflow bool        `config:"discard_overflow"`
 }
 
 // TODO(skipor): use something github.com/rcrowley/go-metrics based.
@@ -47,9 +49,18 @@ type Engine struct {
 	log     *zap.Logger
 	config  Config
 	metrics Metrics
+	control *Control
 	wait    sync.WaitGroup
 }
 
+// EnableControl enables runtime control of pools load. It should be called before Run.
+func (e *Engine) EnableControl() *Control {
+	if e.control == nil {
+		e.control = &Control{}
+	}
+	return e.control
+}
+
 // Run runs all instance pools. Run blocks until fail happen, or all pools
 // subroutines are successfully finished.
 // Ctx will be ancestor to Contexts passed to AmmoQueue, Gun and Aggregator.
@@ -68,6 +79,9 @@ func (e *Engine) Run(ctx context.Context) error {
 		}
 		e.wait.Add(1)
 		pool := newPool(e.log, e.metrics, e.wait.Done, conf)
+		if e.control != nil {
+			pool.control = e.control.addPool(pool.ID, len(conf.Concurrency) > 0, conf.RPSPerInstance)
+		}
 		go func() {
 			err := pool.Run(ctx)
 			select {
@@ -117,6 +131,8 @@ type instancePool struct {
 	onWaitDone func()
 	InstancePoolConfig
 	sharedGunDeps any
+	// control is nil, if runtime control is not enabled.
+	control *PoolControl
 }
 
 // Run start instance pool. Run blocks until fail happen, or all instances finish.
@@ -137,6 +153,11 @@ func (p *instancePool) Run(ctx context.Context) error {
 		cancel()
 	}()
 
+	if p.StartupSchedule == nil && len(p.Concurrency) == 0 {
+		p.onWaitDone()
+		return errors.New("startup schedule or concurrency should be set")
+	}
+
 	if err := p.warmUpGun(ctx); err != nil {
 		p.onWaitDone()
 		return err
@@ -200,6 +221,7 @@ func (p *instancePool) runAsync(runCtx context.Context) (*poolAsyncRunHandle, er
 	if err != nil {
 		return nil, err
 	}
+	p.control.setStopStart(instanceStartCancel)
 	// Seems good enough. Even if some run will block on result send, it's not real problem.
 	const runResultBufSize = 64
 	var (
@@ -252,6 +274,7 @@ type runAwaitHandle struct {
 	log *zap.Logger
 	poolAsyncRunHandle
 	awaitErr         chan<- error
+	control          *PoolControl
 	toWait           int
 	startedInstances int
 	awaitedInstances int
@@ -264,6 +287,7 @@ func (p *instancePool) newAwaitRunHandle(runHandle *poolAsyncRunHandle) (*runAwa
 		log:                p.log,
 		poolAsyncRunHandle: *runHandle,
 		awaitErr:           awaitErr,
+		control:            p.control,
 		toWait:             resultsToWait,
 		startedInstances:   -1, // Undefined until start finish.
 	}
@@ -299,6 +323,7 @@ func (ah *runAwaitHandle) awaitRun() {
 			ah.checkAllInstancesAreFinished() // There is a race between run and start results.
 		case res := <-ah.runRes:
 			ah.awaitedInstances++
+			ah.control.instanceFinished()
 			if ent := ah.log.Check(zap.DebugLevel, "Instance run awaited"); ent != nil {
 				ent.Write(zap.Int("id", res.ID), zap.Int("awaited", ah.awaitedInstances), zap.Error(res.Err))
 			}
@@ -311,6 +336,10 @@ func (ah *runAwaitHandle) awaitRun() {
 			} else if !errutil.IsCtxError(ah.runCtx, res.Err) {
 				ah.onErrAwaited(errors.WithMessage(res.Err, fmt.Sprintf("instance %q run failed", res.ID)))
 			}
+			if !ah.isStartFinished() && ah.control.allInstancesFinished(ah.awaitedInstances) {
+				ah.log.Debug("Canceling instance start because all instances are finished")
+				ah.instanceStartCancel()
+			}
 			ah.checkAllInstancesAreFinished()
 		}
 	}
@@ -365,7 +394,11 @@ func (p *instancePool) startInstances(
 		},
 	}
 
-	waiter := coreutil.NewWaiter(p.StartupSchedule)
+	if len(p.Concurrency) > 0 {
+		return p.followConcurrency(startCtx, runCtx, deps, runRes)
+	}
+
+	waiter := coreutil.NewWaiter(p.control.wrapStartup(startCtx, p.StartupSchedule))
 
 	// If create all instances asynchronously, and creation will fail, too many errors appears in log.
 	ok := waiter.Wait(startCtx)
@@ -378,6 +411,7 @@ func (p *instancePool) startInstances(
 		return
 	}
 	started++
+	p.control.instanceStarted()
 	go func() {
 		runRes <- instanceRunResult{0, func() error {
 			defer firstInstance.Close()
@@ -387,6 +421,7 @@ func (p *instancePool) startInstances(
 
 	for ; waiter.Wait(startCtx); started++ {
 		id := started
+		p.control.instanceStarted()
 		go func() {
 			runRes <- instanceRunResult{id, runNewInstance(runCtx, p.log, p.ID, id, deps)}
 		}()
@@ -399,12 +434,22 @@ func (p *instancePool) buildNewInstanceSchedule(startCtx context.Context, cancel
 	func() (core.Schedule, error), error,
 ) {
 	if p.RPSPerInstance {
-		return p.NewRPSSchedule, nil
+		if p.control == nil {
+			return p.NewRPSSchedule, nil
+		}
+		return func() (core.Schedule, error) {
+			s, err := p.NewRPSSchedule()
+			if err != nil {
+				return nil, err
+			}
+			return p.control.wrapSchedule(startCtx, s), nil
+		}, nil
 	}
 	sharedRPSSchedule, err := p.NewRPSSchedule()
 	if err != nil {
 		return nil, err
 	}
+	sharedRPSSchedule = p.control.wrapSchedule(startCtx, sharedRPSSchedule)
 	sharedRPSSchedule = coreutil.NewCallbackOnFinishSchedule(sharedRPSSchedule, func() {
 		select {
 		case <-startCtx.Done():
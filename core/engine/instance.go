@@ -17,16 +17,27 @@ type instance struct {
 	id       int
 	gun      core.Gun
 	schedule core.Schedule
+	aborted  chan error
 	instanceSharedDeps
 }
 
 func newInstance(ctx context.Context, log *zap.Logger, poolID string, id int, deps instanceDeps) (*instance, error) {
 	log = log.With(zap.Int("instance", id))
-	gunDeps := core.GunDeps{Ctx: ctx, Log: log, PoolID: poolID, InstanceID: id, Shared: deps.gunDeps}
+	aborted := make(chan error, 1)
+	abort := func(err error) {
+		select {
+		case aborted <- err:
+		default: // Already aborted.
+		}
+	}
+	gunDeps := core.GunDeps{Ctx: ctx, Log: log, PoolID: poolID, InstanceID: id, Shared: deps.gunDeps, Abort: abort}
 	sched, err := deps.newSchedule()
 	if err != nil {
 		return nil, err
 	}
+	if seeded, ok := sched.(instanceSeeded); ok {
+		seeded.SeedInstance(id)
+	}
 	gun, err := deps.newGun()
 	if err != nil {
 		return nil, err
@@ -36,10 +47,16 @@ func newInstance(ctx context.Context, log *zap.Logger, poolID string, id int, de
 	if err != nil {
 		return nil, err
 	}
-	inst := &instance{log: log, id: id, gun: gun, schedule: sched, instanceSharedDeps: deps.instanceSharedDeps}
+	inst := &instance{log: log, id: id, gun: gun, schedule: sched, aborted: aborted, instanceSharedDeps: deps.instanceSharedDeps}
 	return inst, nil
 }
 
+// instanceSeeded is a random schedule, that should differ between instances with the same seed.
+// Shared schedule doesn't implement it.
+type instanceSeeded interface {
+	SeedInstance(id int)
+}
+
 type instanceDeps struct {
 	newSchedule func() (core.Schedule, error)
 	newGun      func() (core.Gun, error)
@@ -96,6 +113,11 @@ func (i *instance) Run(ctx context.Context) (recoverErr error) {
 			} else {
 				i.aggregator.Report(netsample.DiscardedShootSample())
 			}
+			select {
+			case err := <-i.aborted:
+				return errors.WithMessage(err, "shooting aborted")
+			default:
+			}
 			return nil
 		}()
 		if err != nil {
@@ -121,6 +121,28 @@ func Test_Instance(t *testing.T) {
 		})
 	})
 
+	t.Run("gun aborts shooting / run fails after shoot", func(t *testing.T) {
+		beforeEach()
+		sched = schedule.NewOnce(5)
+		abortErr := errors.New("scenario failed")
+		gun.On("Bind", aggregator, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+			deps := args.Get(1).(core.GunDeps)
+			gun.On("Shoot", 1).Run(func(mock.Arguments) { deps.Abort(abortErr) }).Once()
+		}).Once()
+		provider.On("Acquire").Return(1, true).Once()
+		provider.On("Release", 1).Once()
+
+		justBeforeEach()
+		require.NoError(t, insCreateErr)
+
+		err := ins.Run(ctx)
+		require.ErrorIs(t, err, abortErr)
+		gun.AssertExpectations(t)
+		provider.AssertExpectations(t)
+
+		afterEach()
+	})
+
 	t.Run("context canceled after run / start fail", func(t *testing.T) {
 		beforeEach()
 
@@ -7,6 +7,7 @@ import (
 	"github.com/yandex/pandora/core"
 	"github.com/yandex/pandora/core/aggregator"
 	"github.com/yandex/pandora/core/aggregator/netsample"
+	"github.com/yandex/pandora/core/aggregator/rollup"
 	"github.com/yandex/pandora/core/config"
 	"github.com/yandex/pandora/core/datasink"
 	"github.com/yandex/pandora/core/datasource"
@@ -77,10 +78,14 @@ func Import(fs afero.Fs) {
 		a, err := netsample.NewPhout(fs, conf)
 		return netsample.WrapAggregator(a), err
 	}, netsample.DefaultPhoutConfig)
+	register.Aggregator("binary", netsample.NewBinaryAggregator, netsample.DefaultBinaryAggregatorConfig)
 	register.Aggregator("jsonlines", aggregator.NewJSONLinesAggregator, aggregator.DefaultJSONLinesAggregatorConfig)
 	register.Aggregator("json", aggregator.NewJSONLinesAggregator, aggregator.DefaultJSONLinesAggregatorConfig) // TODO(skipor): should be done via alias, but we don't have them yet
 	register.Aggregator("log", aggregator.NewLog)
 	register.Aggregator("discard", aggregator.NewDiscard)
+	register.Aggregator("influx", rollup.NewInflux, rollup.DefaultInfluxConfig)
+	register.Aggregator("graphite", rollup.NewGraphite, rollup.DefaultGraphiteConfig)
+	register.Aggregator("otlp", rollup.NewOTLP, rollup.DefaultOTLPConfig)
 
 	register.Limiter("line", schedule.NewLineConf)
 	register.Limiter("const", schedule.NewConstConf)
@@ -88,14 +93,30 @@ func Import(fs afero.Fs) {
 	register.Limiter("unlimited", schedule.NewUnlimitedConf)
 	register.Limiter("step", schedule.NewStepConf)
 	register.Limiter("instance_step", schedule.NewInstanceStepConf)
+	register.Limiter("poisson", schedule.NewPoissonConf)
+	register.Limiter("on_off", schedule.NewOnOffConf)
+	register.Limiter("profile", schedule.NewProfileConf)
+	register.Limiter("sine", schedule.NewSineConf)
+	register.Limiter("periodic", schedule.NewPeriodicConf)
+	register.Limiter("think_time", schedule.NewThinkTimeConf)
 	register.Limiter(compositeScheduleKey, schedule.NewCompositeConf)
 
 	config.AddTypeHook(sinkStringHook)
 	config.AddTypeHook(scheduleSliceToCompositeConfigHook)
+	pluginconfig.AddSchemaAlternative(dataSinkType, func(map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{"type": "string", "description": "file name, stdout or stderr"}
+	})
+	pluginconfig.AddSchemaAlternative(scheduleType, func(ref map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{"type": "array", "items": ref, "description": "schedules run one after another"}
+	})
 
 	confutil.RegisterTagResolver("", confutil.EnvTagResolver)
 	confutil.RegisterTagResolver("ENV", confutil.EnvTagResolver)
 	confutil.RegisterTagResolver("PROPERTY", confutil.PropertyTagResolver)
+	confutil.RegisterTagResolver("FILE", confutil.FileTagResolver)
+	confutil.RegisterTagResolver("BASE64", confutil.Base64TagResolver)
+	confutil.RegisterTagResolver("HTTP", confutil.HTTPTagResolver)
+	confutil.RegisterTagResolver("AES", confutil.AESTagResolver)
 
 	// Required for decoding plugins. Need to be added after Composite Schedule hacky hook.
 	pluginconfig.AddHooks()
@@ -51,6 +51,64 @@ func Test_PluginConfig(t *testing.T) {
 			coretest.ExpectScheduleNextsT(t, sched, 0, 0, time.Second)
 		})
 	})
+
+	t.Run("on_off schedule", func(t *testing.T) {
+		var conf struct {
+			Schedule core.Schedule
+		}
+		err := config.Decode(map[string]interface{}{
+			"schedule": map[string]interface{}{"type": "on_off", "ops": 2, "on": "1s", "off": "1s", "duration": "2s"},
+		}, &conf)
+		assert.NoError(t, err)
+		coretest.ExpectScheduleNextsT(t, conf.Schedule, 0, 500*time.Millisecond, 2*time.Second)
+	})
+
+	t.Run("sine schedule", func(t *testing.T) {
+		var conf struct {
+			Schedule core.Schedule
+		}
+		err := config.Decode(map[string]interface{}{
+			"schedule": map[string]interface{}{"type": "sine", "min": 2, "max": 2, "period": "1s", "duration": "1s"},
+		}, &conf)
+		assert.NoError(t, err)
+		coretest.ExpectScheduleNextsT(t, conf.Schedule, 0, 500*time.Millisecond, time.Second)
+	})
+
+	t.Run("profile schedule", func(t *testing.T) {
+		var conf struct {
+			Schedule core.Schedule
+		}
+		err := config.Decode(map[string]interface{}{
+			"schedule": map[string]interface{}{
+				"type":   "profile",
+				"source": map[string]interface{}{"type": "inline", "data": "0,1\n2s,1\n"},
+			},
+		}, &conf)
+		assert.NoError(t, err)
+		coretest.ExpectScheduleNextsT(t, conf.Schedule, 0, time.Second, 2*time.Second)
+	})
+
+	t.Run("roll up aggregator", func(t *testing.T) {
+		var conf struct {
+			Result core.Aggregator
+		}
+		err := config.Decode(map[string]interface{}{
+			"result": map[string]interface{}{
+				"type":      "graphite",
+				"sink":      "stdout",
+				"interval":  "10s",
+				"quantiles": []float64{0.5, 0.999},
+				"labels":    map[string]interface{}{"test": "smoke"},
+			},
+		}, &conf)
+		assert.NoError(t, err)
+		assert.NotNil(t, conf.Result)
+
+		err = config.Decode(map[string]interface{}{
+			"result": map[string]interface{}{"type": "influx", "url": "http://localhost:8086", "quantiles": []float64{2}},
+		}, &conf)
+		assert.Error(t, err)
+	})
 }
 
 func TestSink(t *testing.T) {
@@ -45,6 +45,21 @@ func NewFactory(factoryType reflect.Type, name string, fillConfOptional ...func(
 	return defaultRegistry.NewFactory(factoryType, name, fillConfOptional...)
 }
 
+// Types is DefaultRegistry().Types shortcut.
+func Types() []reflect.Type {
+	return DefaultRegistry().Types()
+}
+
+// Names is DefaultRegistry().Names shortcut.
+func Names(pluginType reflect.Type) []string {
+	return DefaultRegistry().Names(pluginType)
+}
+
+// DefaultConfig is DefaultRegistry().DefaultConfig shortcut.
+func DefaultConfig(pluginType reflect.Type, name string) (conf interface{}, err error) {
+	return DefaultRegistry().DefaultConfig(pluginType, name)
+}
+
 // PtrType is helper to extract plugin types.
 // Example: plugin.PtrType((*PluginInterface)(nil)) instead of
 // reflect.TypeOf((*PluginInterface)(nil)).Elem()
@@ -0,0 +1,424 @@
+package pluginconfig
+
+import (
+	"encoding"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/yandex/pandora/core/config"
+	"github.com/yandex/pandora/core/plugin"
+)
+
+// SchemaVersion is JSON Schema draft of schemas generated by Schema.
+const SchemaVersion = "http://json-schema.org/draft-07/schema#"
+
+const (
+	// durationPattern matches time.ParseDuration strings.
+	durationPattern = `^[-+]?(0|([0-9]*\.?[0-9]+(ns|us|µs|ms|s|m|h))+)$`
+	// placeholderPattern matches values with ${VAR} placeholders, that are resolved on config decode.
+	placeholderPattern = `\$\{[^{}]+\}`
+	validateTagName    = "validate"
+)
+
+// SchemaAlternative returns schema of config form, that is decoded into plugin by some config hook.
+// For example, data sink could be set by file name string.
+// ref is a reference to plugin schema definition.
+type SchemaAlternative func(ref map[string]interface{}) map[string]interface{}
+
+var schemaAlternatives = map[reflect.Type][]SchemaAlternative{}
+
+// AddSchemaAlternative adds alternative config form of pluginType into schemas generated by Schema.
+// It should be called along with config hook, that decodes such form.
+func AddSchemaAlternative(pluginType reflect.Type, alternative SchemaAlternative) {
+	schemaAlternatives[pluginType] = append(schemaAlternatives[pluginType], alternative)
+}
+
+// Schema returns JSON Schema of conf, that is struct or struct pointer with default values.
+// Plugins registered in plugin.DefaultRegistry are described in schema definitions: one
+// variant for every plugin name with its config fields, defaults and validation constraints.
+// Definitions are generated for every registered plugin type, even if conf has no fields of such type.
+func Schema(conf interface{}) (map[string]interface{}, error) {
+	g := &schemaGenerator{
+		registry:    plugin.DefaultRegistry(),
+		names:       map[reflect.Type]string{},
+		definitions: map[string]interface{}{},
+		structs:     map[reflect.Type]bool{},
+	}
+	for _, pluginType := range g.registry.Types() {
+		if _, err := g.pluginRef(pluginType); err != nil {
+			return nil, err
+		}
+	}
+	v := reflect.ValueOf(conf)
+	schema, err := g.typeSchema(v.Type(), v)
+	if err != nil {
+		return nil, err
+	}
+	schema["$schema"] = SchemaVersion
+	schema["definitions"] = g.definitions
+	return schema, nil
+}
+
+type schemaGenerator struct {
+	registry    *plugin.Registry
+	names       map[reflect.Type]string
+	definitions map[string]interface{}
+	// structs are types of structs, which schemas are being generated.
+	structs map[reflect.Type]bool
+}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// typeSchema returns schema of type t, or nil if values of such type can't be set by config.
+// v is a default value of type t, or invalid value, if there is no default.
+func (g *schemaGenerator) typeSchema(t reflect.Type, v reflect.Value) (map[string]interface{}, error) {
+	if g.registry.Lookup(t) {
+		return g.pluginRef(t)
+	}
+	if g.registry.LookupFactory(t) {
+		pluginType, _ := plugin.FactoryPluginType(t)
+		return g.pluginRef(pluginType)
+	}
+	switch {
+	case t == durationType:
+		return map[string]interface{}{"type": []string{"string", "integer"}, "pattern": durationPattern}, nil
+	case t == urlType || t == urlPtrType:
+		return map[string]interface{}{"type": "string", "format": "uri"}, nil
+	case t.Implements(textUnmarshalerType) || reflect.PtrTo(t).Implements(textUnmarshalerType):
+		if isNumber(t) {
+			return map[string]interface{}{"type": []string{"string", "integer"}}, nil
+		}
+		return map[string]interface{}{"type": "string"}, nil
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return map[string]interface{}{"type": "integer", "minimum": 0}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}, nil
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}, nil
+	case reflect.Interface:
+		return map[string]interface{}{}, nil
+	case reflect.Ptr:
+		if v.IsValid() && !v.IsNil() {
+			return g.typeSchema(t.Elem(), v.Elem())
+		}
+		return g.typeSchema(t.Elem(), reflect.Value{})
+	case reflect.Slice, reflect.Array:
+		items, err := g.typeSchema(t.Elem(), reflect.Value{})
+		if err != nil || items == nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "array", "items": items}, nil
+	case reflect.Map:
+		values, err := g.typeSchema(t.Elem(), reflect.Value{})
+		if err != nil || values == nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "object", "additionalProperties": values}, nil
+	case reflect.Struct:
+		return g.structSchema(t, v)
+	}
+	// Funcs, that are not plugin factories, and channels.
+	return nil, nil
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type, v reflect.Value) (map[string]interface{}, error) {
+	if g.structs[t] {
+		// Recursive struct, that can't be inlined.
+		return map[string]interface{}{"type": "object"}, nil
+	}
+	g.structs[t] = true
+	defer delete(g.structs, t)
+	properties := map[string]interface{}{}
+	var required []string
+	if err := g.addFields(properties, &required, t, v); err != nil {
+		return nil, err
+	}
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		// Config decode fails on unused keys.
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema, nil
+}
+
+func (g *schemaGenerator) addFields(properties map[string]interface{}, required *[]string, t reflect.Type, v reflect.Value) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get(config.TagName), ",")
+		if name == "-" {
+			continue
+		}
+		var fieldValue reflect.Value
+		if v.IsValid() {
+			fieldValue = v.Field(i)
+		}
+		if strings.Contains(opts, "squash") && field.Type.Kind() == reflect.Struct {
+			// Exported fields of squashed struct are decoded, even if it is unexported.
+			if err := g.addFields(properties, required, field.Type, fieldValue); err != nil {
+				return err
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			// Unexported fields are not decoded.
+			continue
+		}
+		if name == "" {
+			// Keys are matched case-insensitively, and lower cased by viper.
+			name = strings.ToLower(field.Name)
+		}
+		schema, err := g.typeSchema(field.Type, fieldValue)
+		if err != nil {
+			return errors.WithMessagef(err, "%s field %s", t, field.Name)
+		}
+		if schema == nil {
+			continue
+		}
+		isRequired := addConstraints(schema, field.Type, field.Tag.Get(validateTagName))
+		defaultValue, hasDefault := schemaDefault(fieldValue)
+		if isScalar(field.Type) {
+			// Placeholders can be used instead of any value, and are resolved into field type.
+			schema = map[string]interface{}{"anyOf": []interface{}{
+				schema,
+				map[string]interface{}{"type": "string", "pattern": placeholderPattern},
+			}}
+		}
+		if hasDefault {
+			schema["default"] = defaultValue
+		}
+		if isRequired && !hasDefault {
+			*required = append(*required, name)
+		}
+		properties[name] = schema
+	}
+	return nil
+}
+
+// pluginRef returns reference to definition of pluginType, and generates the definition on first call.
+func (g *schemaGenerator) pluginRef(pluginType reflect.Type) (map[string]interface{}, error) {
+	name, ok := g.names[pluginType]
+	if !ok {
+		name = g.definitionName(pluginType)
+		// Name is set before schema generation, as plugin configs can contain plugins of the same type.
+		g.names[pluginType] = name
+		definition, err := g.pluginSchema(pluginType, g.ref(name))
+		if err != nil {
+			return nil, err
+		}
+		g.definitions[name] = definition
+	}
+	return g.ref(name), nil
+}
+
+func (g *schemaGenerator) ref(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/definitions/" + name}
+}
+
+func (g *schemaGenerator) definitionName(pluginType reflect.Type) string {
+	name := pluginType.String()
+	if _, ok := g.definitions[name]; ok {
+		// Types with the same name from different packages.
+		name = strings.ReplaceAll(pluginType.PkgPath(), "/", ".") + "." + pluginType.Name()
+	}
+	return name
+}
+
+func (g *schemaGenerator) pluginSchema(pluginType reflect.Type, ref map[string]interface{}) (map[string]interface{}, error) {
+	var variants []interface{}
+	for _, name := range g.registry.Names(pluginType) {
+		conf, err := g.registry.DefaultConfig(pluginType, name)
+		if err != nil {
+			return nil, err
+		}
+		variant := map[string]interface{}{
+			"type":                 "object",
+			"properties":           map[string]interface{}{},
+			"additionalProperties": false,
+		}
+		if conf != nil {
+			confValue := reflect.ValueOf(conf)
+			variant, err = g.typeSchema(confValue.Type(), confValue)
+			if err != nil {
+				return nil, errors.WithMessagef(err, "%s %s plugin", pluginType, name)
+			}
+		}
+		variant["properties"].(map[string]interface{})[PluginNameKey] = map[string]interface{}{"const": name}
+		required, _ := variant["required"].([]string)
+		variant["required"] = append([]string{PluginNameKey}, required...)
+		variants = append(variants, variant)
+	}
+	for _, alternative := range schemaAlternatives[pluginType] {
+		variants = append(variants, alternative(ref))
+	}
+	return map[string]interface{}{
+		"description": pluginType.String() + " plugin",
+		"oneOf":       variants,
+	}, nil
+}
+
+// addConstraints adds validate tag constraints, that can be expressed by JSON Schema, into schema of type t.
+// Constraints after dive are added into schema of elements. Returns true, if value is required.
+func addConstraints(schema map[string]interface{}, t reflect.Type, tag string) (required bool) {
+	var descriptions []string
+	for _, constraint := range strings.Split(tag, ",") {
+		key, param, _ := strings.Cut(constraint, "=")
+		switch key {
+		case "required":
+			required = true
+		case "dive":
+			for t.Kind() == reflect.Ptr {
+				t = t.Elem()
+			}
+			elemKey := "items"
+			if t.Kind() == reflect.Map {
+				elemKey = "additionalProperties"
+			}
+			elemSchema, ok := schema[elemKey].(map[string]interface{})
+			if ok && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map) {
+				rest := tag[strings.Index(tag, constraint)+len(constraint):]
+				addConstraints(elemSchema, t.Elem(), strings.TrimPrefix(rest, ","))
+			}
+			return
+		case "min", "max":
+			addLimit(schema, t, key, param)
+		case "min-time", "min-size":
+			descriptions = append(descriptions, "minimum is "+param)
+		case "max-time", "max-size":
+			descriptions = append(descriptions, "maximum is "+param)
+		case "url":
+			schema["format"] = "uri"
+		case "endpoint":
+			descriptions = append(descriptions, "should be host:port")
+		case "url-path":
+			descriptions = append(descriptions, "should be URL path")
+		case "ip":
+			descriptions = append(descriptions, "should be IP address")
+		}
+	}
+	if len(descriptions) > 0 {
+		schema["description"] = strings.Join(descriptions, ", ")
+	}
+	return
+}
+
+func addLimit(schema map[string]interface{}, t reflect.Type, key, param string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == durationType || t.Implements(textUnmarshalerType) || reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		// Limits of such values are not comparable with config values.
+		return
+	}
+	limit, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	keywords := map[reflect.Kind]string{
+		reflect.String: "Length",
+		reflect.Slice:  "Items",
+		reflect.Array:  "Items",
+		reflect.Map:    "Properties",
+	}
+	if suffix, ok := keywords[t.Kind()]; ok {
+		schema[key+suffix] = int(limit)
+		return
+	}
+	if isNumber(t) {
+		schema[key+"imum"] = limit
+	}
+}
+
+// schemaDefault returns JSON value of v, if it is not zero scalar, or list or map of scalars.
+func schemaDefault(v reflect.Value) (interface{}, bool) {
+	for v.IsValid() && v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if !v.IsValid() || v.IsZero() {
+		return nil, false
+	}
+	if v.Type() == durationType {
+		return v.Interface().(time.Duration).String(), true
+	}
+	if v.Type().Implements(textMarshalerType) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err == nil
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint(), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.String:
+		return v.String(), true
+	case reflect.Slice, reflect.Array:
+		list := make([]interface{}, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			elem, ok := schemaDefault(v.Index(i))
+			if !ok {
+				return nil, false
+			}
+			list = append(list, elem)
+		}
+		return list, true
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, false
+		}
+		m := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			elem, ok := schemaDefault(iter.Value())
+			if !ok {
+				return nil, false
+			}
+			m[iter.Key().String()] = elem
+		}
+		return m, true
+	}
+	return nil, false
+}
+
+// isScalar returns true, if t is decoded from config value, that is not string, map or list.
+func isScalar(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Bool || isNumber(t)
+}
+
+func isNumber(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+var (
+	urlType    = reflect.TypeOf(url.URL{})
+	urlPtrType = reflect.TypeOf(&url.URL{})
+)
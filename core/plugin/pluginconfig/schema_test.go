@@ -0,0 +1,137 @@
+package pluginconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/c2h5oh/datasize"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yandex/pandora/core/plugin"
+)
+
+type schemaTestPlugin interface {
+	SchemaTest()
+}
+
+type schemaTestCommonConf struct {
+	Name string `config:"name" validate:"required"`
+}
+
+type schemaTestConf struct {
+	schemaTestCommonConf `config:",squash"`
+	Timeout              time.Duration      `config:"timeout" validate:"min-time=1ms"`
+	Size                 datasize.ByteSize  `config:"size"`
+	Count                int                `config:"count" validate:"min=1,max=10"`
+	Headers              []string           `config:"headers" validate:"dive,min=1"`
+	Nested               []schemaTestPlugin `config:"nested"`
+	Skipped              string             `config:"-"`
+	Callback             func()
+}
+
+type schemaTestRoot struct {
+	Plugin    schemaTestPlugin                 `config:"plugin" validate:"required"`
+	NewPlugin func() (schemaTestPlugin, error) `config:"new_plugin"`
+	Level     string                           `config:"level" validate:"required"`
+	Untagged  bool
+}
+
+func TestSchema(t *testing.T) {
+	defaultRegistry := plugin.DefaultRegistry()
+	defer plugin.SetDefaultRegistry(defaultRegistry)
+	plugin.SetDefaultRegistry(plugin.NewRegistry())
+
+	pluginType := reflect.TypeOf((*schemaTestPlugin)(nil)).Elem()
+	plugin.Register(pluginType, "full", func(schemaTestConf) schemaTestPlugin { return nil }, func() schemaTestConf {
+		return schemaTestConf{Timeout: time.Second, Count: 1}
+	})
+	plugin.Register(pluginType, "empty", func() schemaTestPlugin { return nil })
+	AddSchemaAlternative(pluginType, func(ref map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{"type": "array", "items": ref}
+	})
+	defer delete(schemaAlternatives, pluginType)
+
+	schema, err := Schema(&schemaTestRoot{Level: "info"})
+	require.NoError(t, err)
+
+	properties := schema["properties"].(map[string]interface{})
+	assert.Equal(t, SchemaVersion, schema["$schema"])
+	assert.Equal(t, []string{"plugin"}, schema["required"])
+	assert.Equal(t, map[string]interface{}{"$ref": "#/definitions/pluginconfig.schemaTestPlugin"}, properties["plugin"])
+	assert.Equal(t, properties["plugin"], properties["new_plugin"])
+	assert.Equal(t, map[string]interface{}{"type": "string", "default": "info"}, properties["level"])
+	assert.Contains(t, properties, "untagged")
+
+	definition := schema["definitions"].(map[string]interface{})["pluginconfig.schemaTestPlugin"].(map[string]interface{})
+	variants := definition["oneOf"].([]interface{})
+	require.Len(t, variants, 3)
+	assert.Equal(t, map[string]interface{}{
+		"type":                 "object",
+		"properties":           map[string]interface{}{PluginNameKey: map[string]interface{}{"const": "empty"}},
+		"additionalProperties": false,
+		"required":             []string{PluginNameKey},
+	}, variants[0])
+	full := variants[1].(map[string]interface{})
+	assert.Equal(t, []string{PluginNameKey, "name"}, full["required"])
+	fullProperties := full["properties"].(map[string]interface{})
+	assert.ElementsMatch(t, []string{PluginNameKey, "name", "timeout", "size", "count", "headers", "nested"}, keys(fullProperties))
+	timeout := fullProperties["timeout"].(map[string]interface{})
+	assert.Equal(t, "1s", timeout["default"])
+	assert.Equal(t, "minimum is 1ms", timeout["anyOf"].([]interface{})[0].(map[string]interface{})["description"])
+	count := fullProperties["count"].(map[string]interface{})
+	assert.Equal(t, int64(1), count["default"])
+	assert.Equal(t, map[string]interface{}{"type": "integer", "minimum": 1.0, "maximum": 10.0}, count["anyOf"].([]interface{})[0])
+	assert.Equal(t, map[string]interface{}{
+		"type":  "array",
+		"items": map[string]interface{}{"type": "string", "minLength": 1},
+	}, fullProperties["headers"])
+
+	// Compile and validate JSON encoded schema, as editors do.
+	data, err := json.Marshal(schema)
+	require.NoError(t, err)
+	compiler := jsonschema.NewCompiler()
+	require.NoError(t, compiler.AddResource("schema.json", bytes.NewReader(data)))
+	compiled, err := compiler.Compile("schema.json")
+	require.NoError(t, err)
+
+	valid := []string{
+		`{"plugin": {"type": "empty"}}`,
+		`{"plugin": {"type": "full", "name": "a", "timeout": "100ms", "size": "1MB", "count": "${COUNT}"}}`,
+		`{"plugin": {"type": "full", "name": "a", "nested": [{"type": "empty"}]}, "new_plugin": [{"type": "empty"}]}`,
+		`{"plugin": {"type": "empty"}, "level": "debug", "untagged": true}`,
+	}
+	for _, conf := range valid {
+		assert.NoError(t, compiled.Validate(decodeJSON(t, conf)), conf)
+	}
+	invalid := []string{
+		`{}`,
+		`{"plugin": {"type": "absent"}}`,
+		`{"plugin": {"type": "empty", "name": "a"}}`,
+		`{"plugin": {"type": "full"}}`,
+		`{"plugin": {"type": "full", "name": "a", "timeout": "1 second"}}`,
+		`{"plugin": {"type": "full", "name": "a", "count": 0}}`,
+		`{"plugin": {"type": "full", "name": "a", "headers": [""]}}`,
+		`{"plugin": {"type": "empty"}, "extra": 1}`,
+	}
+	for _, conf := range invalid {
+		assert.Error(t, compiled.Validate(decodeJSON(t, conf)), conf)
+	}
+}
+
+func decodeJSON(t *testing.T, data string) interface{} {
+	var v interface{}
+	require.NoError(t, json.Unmarshal([]byte(data), &v))
+	return v
+}
+
+func keys(m map[string]interface{}) []string {
+	result := make([]string, 0, len(m))
+	for k := range m {
+		result = append(result, k)
+	}
+	return result
+}
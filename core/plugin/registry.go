@@ -2,6 +2,7 @@ package plugin
 
 import (
 	"reflect"
+	"sort"
 
 	"github.com/pkg/errors"
 )
@@ -122,6 +123,41 @@ func (r *Registry) NewFactory(factoryType reflect.Type, name string, fillConfOpt
 	return registered.constructor.NewFactory(factoryType, getMaybeConfig)
 }
 
+// Types returns plugin interface types, that have registered plugins, sorted by name.
+func (r *Registry) Types() []reflect.Type {
+	types := make([]reflect.Type, 0, len(r.typeToNameReg))
+	for pluginType := range r.typeToNameReg {
+		types = append(types, pluginType)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		return types[i].String() < types[j].String()
+	})
+	return types
+}
+
+// Names returns sorted names of plugins registered for given type.
+func (r *Registry) Names(pluginType reflect.Type) []string {
+	nameReg := r.typeToNameReg[pluginType]
+	names := make([]string, 0, len(nameReg))
+	for name := range nameReg {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DefaultConfig returns new default config of plugin, that is passed to its constructor
+// before config fill: struct or not nil struct pointer. Returns nil config, if plugin
+// constructor accepts no config, and error if no plugin were registered for given type and name.
+func (r *Registry) DefaultConfig(pluginType reflect.Type, name string) (conf interface{}, err error) {
+	registered, err := r.get(pluginType, name)
+	if err != nil || !registered.defaultConfig.configRequired() {
+		return
+	}
+	maybeConf, _ := registered.defaultConfig.new()
+	return maybeConf[0].Interface(), nil
+}
+
 func newNameRegistryEntry(pluginType reflect.Type, constructor interface{}, defaultConfig interface{}) nameRegistryEntry {
 	implConstructor := newImplConstructor(pluginType, constructor)
 	defaultConfigContainer := newDefaultConfigContainer(reflect.TypeOf(constructor), defaultConfig)
@@ -121,6 +121,28 @@ func TestRegistry(t *testing.T) {
 		assert.False(t, r.LookupFactory(reflect.TypeOf(&ptestImpl{})))
 		assert.False(t, r.LookupFactory(reflect.TypeOf((*io.Writer)(nil)).Elem()))
 	})
+
+	t.Run("introspection", func(t *testing.T) {
+		r := NewRegistry()
+		r.ptestRegister(ptestNewConf, ptestDefaultConf)
+		r.Register(ptestType(), "ptr", ptestNewPtrConf)
+		r.Register(ptestType(), "no_config", ptestNewImpl)
+		assert.Equal(t, []reflect.Type{ptestType()}, r.Types())
+		assert.Equal(t, []string{"no_config", ptestPluginName, "ptr"}, r.Names(ptestType()))
+		assert.Empty(t, r.Names(reflect.TypeOf((*io.Writer)(nil)).Elem()))
+
+		conf, err := r.DefaultConfig(ptestType(), ptestPluginName)
+		require.NoError(t, err)
+		assert.Equal(t, ptestDefaultConf(), conf)
+		conf, err = r.DefaultConfig(ptestType(), "ptr")
+		require.NoError(t, err)
+		assert.Equal(t, &ptestConfig{}, conf)
+		conf, err = r.DefaultConfig(ptestType(), "no_config")
+		require.NoError(t, err)
+		assert.Nil(t, conf)
+		_, err = r.DefaultConfig(ptestType(), "absent")
+		assert.Error(t, err)
+	})
 }
 
 func TestNew(t *testing.T) {
@@ -1,6 +1,8 @@
 package register
 
 import (
+	"fmt"
+
 	"github.com/yandex/pandora/core"
 	"github.com/yandex/pandora/core/plugin"
 )
@@ -38,3 +40,28 @@ func DataSink(name string, newDataSink interface{}, defaultConfigOptional ...int
 	var ptr *core.DataSink
 	RegisterPtr(ptr, name, newDataSink, defaultConfigOptional...)
 }
+
+// Command is run by pandora <name> [flags] [args] instead of test.
+type Command struct {
+	Name string
+	// Usage describes command flags and args in pandora usage.
+	Usage string
+	Run   func(args []string) error
+}
+
+var commands []Command
+
+// CLICommand adds command to pandora CLI. It should be called on plugins import, before cli.Run.
+func CLICommand(name, usage string, run func(args []string) error) {
+	for _, c := range commands {
+		if c.Name == name {
+			panic(fmt.Sprintf("command %s is already registered", name))
+		}
+	}
+	commands = append(commands, Command{Name: name, Usage: usage, Run: run})
+}
+
+// Commands returns commands added by CLICommand in order of registration.
+func Commands() []Command {
+	return commands
+}
@@ -0,0 +1,67 @@
+package schedule
+
+import (
+	"math"
+	"time"
+
+	"github.com/yandex/pandora/core"
+)
+
+// OnOffConfig is a config of bursty schedule: Ops rate during On period, then OffOps rate
+// during Off period, repeating until Duration ends.
+type OnOffConfig struct {
+	Ops      float64       `validate:"min=0"`
+	OffOps   float64       `validate:"min=0"`
+	On       time.Duration `validate:"min-time=1ms"`
+	Off      time.Duration `validate:"min-time=0s"`
+	Duration time.Duration `validate:"min-time=1ms"`
+	// Poisson makes inter-arrival times random, like in poisson schedule, instead of even.
+	Poisson bool
+	Seed    int64 // Zero means random seed.
+}
+
+func NewOnOffConf(conf OnOffConfig) core.Schedule {
+	return NewOnOff(conf.Ops, conf.OffOps, conf.On, conf.Off, conf.Duration, conf.Poisson, conf.Seed)
+}
+
+func NewOnOff(ops, offOps float64, on, off, duration time.Duration, poisson bool, seed int64) core.Schedule {
+	onN := ops * on.Seconds()
+	periodN := onN + offOps*off.Seconds()
+	if periodN <= 0 {
+		return NewConst(0, duration)
+	}
+	at := onOffAt(ops, offOps, on, off)
+	if poisson {
+		return newPoissonSchedule(duration, seed, at)
+	}
+	period := on + off
+	periods := math.Floor(float64(duration) / float64(period))
+	n := periods * periodN
+	rest := duration - time.Duration(periods)*period
+	if rest <= on {
+		n += ops * rest.Seconds()
+	} else {
+		n += onN + offOps*(rest-on).Seconds()
+	}
+	return NewDoAtSchedule(duration, int64(math.Ceil(n-1e-9)), func(i int64) time.Duration {
+		return at(float64(i))
+	})
+}
+
+// onOffAt returns inverse of cumulative number of operations of on-off schedule.
+func onOffAt(ops, offOps float64, on, off time.Duration) At {
+	onN := ops * on.Seconds()
+	periodN := onN + offOps*off.Seconds()
+	period := on + off
+	return func(x float64) time.Duration {
+		periods := math.Floor(x / periodN)
+		r := x - periods*periodN
+		var d time.Duration
+		if r < onN {
+			d = time.Duration(r / ops * 1e9)
+		} else {
+			d = on + time.Duration((r-onN)/offOps*1e9)
+		}
+		return time.Duration(periods)*period + d
+	}
+}
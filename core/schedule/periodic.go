@@ -0,0 +1,139 @@
+package schedule
+
+import (
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/yandex/pandora/core"
+)
+
+const (
+	PeriodicShapeSine     = "sine"
+	PeriodicShapeTriangle = "triangle"
+	PeriodicShapeSquare   = "square"
+	PeriodicShapeSawtooth = "sawtooth"
+)
+
+// PeriodicConfig is a config of schedule, that RPS oscillates between Min and Max with Period.
+// With zero Phase sine and triangle start from the middle and grow, square starts from Max,
+// sawtooth grows from Min. Phase is in degrees: 360 is a whole period.
+type PeriodicConfig struct {
+	// Shape is sine (default), triangle, square or sawtooth.
+	Shape    string
+	Min      float64       `validate:"min=0"`
+	Max      float64       `validate:"min=0"`
+	Period   time.Duration `validate:"min-time=1ms"`
+	Phase    float64
+	Duration time.Duration `validate:"min-time=1ms"`
+}
+
+func NewPeriodicConf(conf PeriodicConfig) (core.Schedule, error) {
+	return NewPeriodic(conf.Shape, conf.Min, conf.Max, conf.Period, conf.Phase, conf.Duration)
+}
+
+type SineConfig struct {
+	Min      float64       `validate:"min=0"`
+	Max      float64       `validate:"min=0"`
+	Period   time.Duration `validate:"min-time=1ms"`
+	Phase    float64
+	Duration time.Duration `validate:"min-time=1ms"`
+}
+
+func NewSineConf(conf SineConfig) (core.Schedule, error) {
+	return NewPeriodic(PeriodicShapeSine, conf.Min, conf.Max, conf.Period, conf.Phase, conf.Duration)
+}
+
+// NewPeriodic returns schedule, that RPS oscillates between min and max.
+// Time of i'th operation is found by inversion of cumulative number of operations, so it is exact up to nanosecond.
+func NewPeriodic(shape string, min, max float64, period time.Duration, phase float64, duration time.Duration) (core.Schedule, error) {
+	if min > max {
+		return nil, errors.Errorf("min %v should not be greater than max %v", min, max)
+	}
+	if period <= 0 {
+		return nil, errors.New("period should be positive")
+	}
+	if shape == "" {
+		shape = PeriodicShapeSine
+	}
+	unitCumulative, ok := periodicShapes[shape]
+	if !ok {
+		return nil, errors.Errorf("unknown periodic shape %q", shape)
+	}
+	if min == max {
+		return NewConst(min, duration), nil
+	}
+
+	periodSec := period.Seconds()
+	phaseX := phase / 360
+	// cumulativeUnit returns integral of unit shape from 0 to x periods. Mean of every shape is 0.5.
+	cumulativeUnit := func(x float64) float64 {
+		whole := math.Floor(x)
+		return whole/2 + unitCumulative(x-whole)
+	}
+	phaseCumulative := cumulativeUnit(phaseX)
+	cumulative := func(t time.Duration) float64 {
+		sec := t.Seconds()
+		return min*sec + (max-min)*periodSec*(cumulativeUnit(sec/periodSec+phaseX)-phaseCumulative)
+	}
+	n := int64(math.Floor(cumulative(duration) + 1e-9))
+	return NewDoAtSchedule(duration, n, func(i int64) time.Duration {
+		return invertCumulative(cumulative, float64(i), duration)
+	}), nil
+}
+
+// periodicShapes contains integrals of unit shapes from 0 to x in [0, 1) period.
+// Unit shape values are in [0, 1].
+var periodicShapes = map[string]func(x float64) float64{
+	PeriodicShapeSine: func(x float64) float64 {
+		// (1 + sin(2*Pi*x)) / 2
+		return x/2 + (1-math.Cos(2*math.Pi*x))/(4*math.Pi)
+	},
+	PeriodicShapeTriangle: func(x float64) float64 {
+		// Triangle 1 - |2v - 1|, where v = x + 0.25, so it starts from the middle.
+		v := x + 0.25
+		tri := func(v float64) float64 {
+			if v <= 0.5 {
+				return v * v
+			}
+			return 2*v - v*v - 0.5
+		}
+		if v < 1 {
+			return tri(v) - tri(0.25)
+		}
+		return 0.5 - tri(0.25) + tri(v-1)
+	},
+	PeriodicShapeSquare: func(x float64) float64 {
+		return math.Min(x, 0.5)
+	},
+	PeriodicShapeSawtooth: func(x float64) float64 {
+		return x * x / 2
+	},
+}
+
+// invertCumulative returns minimal t in [0, limit] nanoseconds, such that cumulative(t) >= x.
+// It expects, that cumulative is non-decreasing, cumulative(0) == 0 and cumulative(limit) >= x.
+// Bisection is mixed with secant steps for fast convergence, so result is exact and monotonic in x.
+func invertCumulative(cumulative func(t time.Duration) float64, x float64, limit time.Duration) time.Duration {
+	if x <= 0 {
+		return 0
+	}
+	lo, hi := time.Duration(0), limit
+	loVal, hiVal := 0.0, cumulative(limit)
+	for i := 0; hi-lo > 1; i++ {
+		mid := lo + (hi-lo)/2
+		if i%2 == 0 && hiVal > loVal {
+			guess := lo + time.Duration(float64(hi-lo)*(x-loVal)/(hiVal-loVal))
+			if guess > lo && guess < hi {
+				mid = guess
+			}
+		}
+		midVal := cumulative(mid)
+		if midVal >= x {
+			hi, hiVal = mid, midVal
+		} else {
+			lo, loVal = mid, midVal
+		}
+	}
+	return hi
+}
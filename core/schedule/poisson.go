@@ -0,0 +1,101 @@
+package schedule
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/yandex/pandora/core"
+)
+
+type PoissonConfig struct {
+	Ops      float64       `validate:"min=0"`
+	Duration time.Duration `validate:"min-time=1ms"`
+	Seed     int64         // Zero means random seed.
+}
+
+func NewPoissonConf(conf PoissonConfig) core.Schedule {
+	return NewPoisson(conf.Ops, conf.Duration, conf.Seed)
+}
+
+// NewPoisson returns schedule of Poisson process with ops mean rate: inter-arrival times
+// are exponentially distributed with 1/ops mean. Schedules with same non-zero seed are equal.
+func NewPoisson(ops float64, duration time.Duration, seed int64) core.Schedule {
+	if ops <= 0 {
+		return NewConst(0, duration)
+	}
+	return newPoissonSchedule(duration, seed, constAt(ops))
+}
+
+// At returns time of operation x, assuming that schedule started at 0.
+// It is inverse function of cumulative number of operations and MUST be non-decreasing.
+type At func(x float64) time.Duration
+
+func constAt(ops float64) At {
+	billionDivOps := 1e9 / ops
+	return func(x float64) time.Duration {
+		return time.Duration(x * billionDivOps)
+	}
+}
+
+// newPoissonSchedule returns schedule, that takes operations at random points of cumulative
+// number of operations: distance between points is exponentially distributed with mean 1.
+// So, schedule is non-homogeneous Poisson process, which rate is defined by at.
+// Number of operations is not known before schedule finish.
+func newPoissonSchedule(duration time.Duration, seed int64, at At) core.Schedule {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &poissonSchedule{
+		duration: duration,
+		at:       at,
+		rand:     rand.New(rand.NewSource(seed)),
+	}
+}
+
+type poissonSchedule struct {
+	duration time.Duration
+	at       At
+
+	mu       sync.Mutex
+	rand     *rand.Rand
+	x        float64
+	finished bool
+
+	StartSync
+	start time.Time
+}
+
+func (s *poissonSchedule) Start(startAt time.Time) {
+	s.MarkStarted()
+	s.startOnce.Do(func() {
+		s.start = startAt
+	})
+}
+
+func (s *poissonSchedule) Next() (tx time.Time, ok bool) {
+	s.startOnce.Do(func() {
+		s.MarkStarted()
+		s.start = time.Now()
+	})
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.finished {
+		d := s.at(s.x)
+		if d < s.duration {
+			s.x += s.rand.ExpFloat64()
+			return s.start.Add(d), true
+		}
+		s.finished = true
+	}
+	return s.start.Add(s.duration), false
+}
+
+func (s *poissonSchedule) Left() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.finished {
+		return 0
+	}
+	return -1
+}
@@ -0,0 +1,184 @@
+package schedule
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/yandex/pandora/core"
+)
+
+const (
+	ProfileFormatCSV  = "csv"
+	ProfileFormatJSON = "json"
+
+	ProfileInterpolationLinear = "linear"
+	ProfileInterpolationStep   = "step"
+)
+
+// ProfileConfig is a config of schedule, that follows RPS time series read from Source.
+// CSV source contains "time,rps" lines, JSON source contains array of {"time": ..., "rps": ...} objects.
+// Time is an offset from profile start in seconds or Go duration format ("1h30m"), or RFC3339 timestamp.
+// Timestamps are counted from the first point.
+type ProfileConfig struct {
+	Source core.DataSource `validate:"required"`
+	// Format is csv (default) or json.
+	Format string
+	// Interpolation between points is linear (default) or step. Step keeps RPS of point until the next point.
+	Interpolation string
+	// Compression divides profile time: 24h profile with compression 24 takes 1h with the same RPS values.
+	Compression float64 `validate:"min=0"`
+}
+
+func NewProfileConf(conf ProfileConfig) (core.Schedule, error) {
+	points, err := readProfile(conf.Source, conf.Format)
+	if err != nil {
+		return nil, errors.WithMessage(err, "profile read failed")
+	}
+	return NewProfile(points, conf.Interpolation, conf.Compression)
+}
+
+// ProfilePoint is RPS at time offset from profile start.
+type ProfilePoint struct {
+	Time time.Duration
+	RPS  float64
+}
+
+// NewProfile returns composite of line (or const for step interpolation) schedules between points.
+// Profile ends at the last point.
+func NewProfile(points []ProfilePoint, interpolation string, compression float64) (core.Schedule, error) {
+	if len(points) < 2 {
+		return nil, errors.New("profile should contain at least 2 points")
+	}
+	if compression == 0 {
+		compression = 1
+	}
+	var newSegment func(from, to float64, duration time.Duration) core.Schedule
+	switch interpolation {
+	case "", ProfileInterpolationLinear:
+		newSegment = NewLine
+	case ProfileInterpolationStep:
+		newSegment = func(from, _ float64, duration time.Duration) core.Schedule {
+			return NewConst(from, duration)
+		}
+	default:
+		return nil, errors.Errorf("unknown profile interpolation %q", interpolation)
+	}
+
+	segments := make([]core.Schedule, 0, len(points)-1)
+	for i := 1; i < len(points); i++ {
+		from, to := points[i-1], points[i]
+		if from.RPS < 0 || to.RPS < 0 {
+			return nil, errors.Errorf("profile point %d: negative rps", i)
+		}
+		if to.Time <= from.Time {
+			return nil, errors.Errorf("profile point %d: time should increase", i)
+		}
+		duration := time.Duration(float64(to.Time-from.Time) / compression)
+		segments = append(segments, newSegment(from.RPS, to.RPS, duration))
+	}
+	return NewCompositeConf(CompositeConf{segments}), nil
+}
+
+func readProfile(source core.DataSource, format string) ([]ProfilePoint, error) {
+	r, err := source.OpenSource()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var raw [][2]string
+	switch format {
+	case "", ProfileFormatCSV:
+		raw, err = readProfileCSV(r)
+	case ProfileFormatJSON:
+		raw, err = readProfileJSON(r)
+	default:
+		return nil, errors.Errorf("unknown profile format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]ProfilePoint, 0, len(raw))
+	var start time.Time
+	for i, rec := range raw {
+		rps, err := strconv.ParseFloat(rec[1], 64)
+		if err != nil {
+			if i == 0 && len(raw) > 1 {
+				continue // Header.
+			}
+			return nil, errors.Errorf("point %d: invalid rps %q", i, rec[1])
+		}
+		offset, timestamp, err := parseProfileTime(rec[0])
+		if err != nil {
+			return nil, errors.WithMessagef(err, "point %d", i)
+		}
+		if !timestamp.IsZero() {
+			if start.IsZero() {
+				if len(points) > 0 {
+					return nil, errors.Errorf("point %d: timestamps and offsets can't be mixed", i)
+				}
+				start = timestamp
+			}
+			offset = timestamp.Sub(start)
+		} else if !start.IsZero() {
+			return nil, errors.Errorf("point %d: timestamps and offsets can't be mixed", i)
+		}
+		points = append(points, ProfilePoint{Time: offset, RPS: rps})
+	}
+	return points, nil
+}
+
+func readProfileCSV(r io.Reader) ([][2]string, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	result := make([][2]string, len(records))
+	for i, rec := range records {
+		result[i] = [2]string{strings.TrimSpace(rec[0]), strings.TrimSpace(rec[1])}
+	}
+	return result, nil
+}
+
+func readProfileJSON(r io.Reader) ([][2]string, error) {
+	var points []struct {
+		Time json.RawMessage `json:"time"`
+		RPS  json.Number     `json:"rps"`
+	}
+	if err := json.NewDecoder(r).Decode(&points); err != nil {
+		return nil, err
+	}
+	result := make([][2]string, len(points))
+	for i, p := range points {
+		var t string
+		if err := json.Unmarshal(p.Time, &t); err != nil {
+			t = string(p.Time) // Number of seconds.
+		}
+		result[i] = [2]string{t, p.RPS.String()}
+	}
+	return result, nil
+}
+
+// parseProfileTime parses offset in seconds or Go duration format, or RFC3339 timestamp.
+func parseProfileTime(s string) (offset time.Duration, timestamp time.Time, err error) {
+	if seconds, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Duration(seconds * float64(time.Second)), time.Time{}, nil
+	}
+	if offset, err := time.ParseDuration(s); err == nil {
+		return offset, time.Time{}, nil
+	}
+	if timestamp, err := time.Parse(time.RFC3339, s); err == nil {
+		return 0, timestamp, nil
+	}
+	return 0, time.Time{}, errors.Errorf("invalid time %q: should be seconds, duration or RFC3339 timestamp", s)
+}
@@ -1,13 +1,17 @@
 package schedule
 
 import (
+	"math"
+	"math/rand"
 	"sort"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/yandex/pandora/core"
 	"github.com/yandex/pandora/core/coretest"
+	"github.com/yandex/pandora/core/datasource"
 )
 
 func Test_unlimited(t *testing.T) {
@@ -197,6 +201,164 @@ func TestInstanceStep(t *testing.T) {
 	assert.Equal(t, 3, testee.Left())
 }
 
+func TestPoisson(t *testing.T) {
+	drain := func(sched core.Schedule, start time.Time) []time.Duration {
+		var nexts []time.Duration
+		for {
+			assert.Equal(t, -1, sched.Left())
+			x, ok := sched.Next()
+			nexts = append(nexts, x.Sub(start))
+			if !ok {
+				assert.Equal(t, 0, sched.Left())
+				return nexts
+			}
+		}
+	}
+	conf := PoissonConfig{Ops: 1000, Duration: 10 * time.Second, Seed: 42}
+	start := time.Now()
+	testee := NewPoissonConf(conf)
+	testee.Start(start)
+	nexts := drain(testee, start)
+
+	assert.Equal(t, time.Duration(0), nexts[0])
+	assert.Equal(t, conf.Duration, nexts[len(nexts)-1])
+	assert.True(t, sort.SliceIsSorted(nexts, func(i, j int) bool { return nexts[i] < nexts[j] }))
+	assert.InDelta(t, 10000, len(nexts)-1, 400) // 4 standard deviations.
+
+	var exceedMean int
+	for i := 1; i < len(nexts)-1; i++ {
+		if nexts[i]-nexts[i-1] > time.Millisecond {
+			exceedMean++
+		}
+	}
+	// P(X > mean) = 1/e for exponential distribution.
+	assert.InDelta(t, 0.368, float64(exceedMean)/float64(len(nexts)-2), 0.03)
+
+	same := NewPoissonConf(conf)
+	same.Start(start)
+	assert.Equal(t, nexts, drain(same, start))
+
+	zero := NewPoisson(0, time.Second, 1)
+	coretest.ExpectScheduleNexts(t, zero, time.Second)
+}
+
+func TestOnOff(t *testing.T) {
+	t.Run("even", func(t *testing.T) {
+		testee := NewOnOffConf(OnOffConfig{Ops: 2, On: time.Second, Off: time.Second, Duration: 5 * time.Second})
+		coretest.ExpectScheduleNexts(t, testee,
+			0, 500*time.Millisecond,
+			2*time.Second, 2500*time.Millisecond,
+			4*time.Second, 4500*time.Millisecond,
+			5*time.Second,
+		)
+	})
+
+	t.Run("low rate during off", func(t *testing.T) {
+		testee := NewOnOffConf(OnOffConfig{Ops: 2, OffOps: 1, On: time.Second, Off: 2 * time.Second, Duration: 3 * time.Second})
+		coretest.ExpectScheduleNexts(t, testee,
+			0, 500*time.Millisecond, time.Second, 2*time.Second,
+			3*time.Second,
+		)
+	})
+
+	t.Run("poisson", func(t *testing.T) {
+		testee := NewOnOffConf(OnOffConfig{Ops: 1000, On: 100 * time.Millisecond, Off: 100 * time.Millisecond, Duration: time.Second, Poisson: true})
+		start := time.Now()
+		testee.Start(start)
+		var n int
+		for {
+			x, ok := testee.Next()
+			if !ok {
+				break
+			}
+			n++
+			assert.Less(t, x.Sub(start)%(200*time.Millisecond), 100*time.Millisecond)
+		}
+		assert.InDelta(t, 500, n, 100)
+	})
+}
+
+func TestPoissonInComposite(t *testing.T) {
+	testee := NewComposite(NewPoisson(100, time.Second, 1), NewOnce(1))
+	assert.Equal(t, -1, testee.Left())
+	start := time.Now()
+	testee.Start(start)
+	var last time.Time
+	var n int
+	for {
+		x, ok := testee.Next()
+		if !ok {
+			break
+		}
+		assert.False(t, x.Before(last))
+		last = x
+		n++
+	}
+	assert.Equal(t, start.Add(time.Second), last) // once token.
+	assert.Greater(t, n, 50)
+}
+
+func TestProfile(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    ProfileConfig
+		want    []time.Duration
+		wantErr bool
+	}{
+		{
+			name: "csv linear",
+			conf: ProfileConfig{Source: datasource.NewString("time,rps\n0,0\n2s,2\n3,2\n")},
+			want: []time.Duration{0, 1414213562, 2 * time.Second, 2500 * time.Millisecond, 3 * time.Second},
+		},
+		{
+			name: "json step with timestamps and compression",
+			conf: ProfileConfig{
+				Source:        datasource.NewString(`[{"time": "2024-01-01T00:00:00Z", "rps": 1}, {"time": "2024-01-01T00:00:04Z", "rps": 0}]`),
+				Format:        ProfileFormatJSON,
+				Interpolation: ProfileInterpolationStep,
+				Compression:   2,
+			},
+			want: []time.Duration{0, time.Second, 2 * time.Second},
+		},
+		{
+			name: "json seconds",
+			conf: ProfileConfig{Source: datasource.NewString(`[{"time": 0, "rps": 2}, {"time": 1.5, "rps": 2}]`), Format: ProfileFormatJSON},
+			want: []time.Duration{0, 500 * time.Millisecond, time.Second, 1500 * time.Millisecond},
+		},
+		{
+			name:    "single point",
+			conf:    ProfileConfig{Source: datasource.NewString("0,10\n")},
+			wantErr: true,
+		},
+		{
+			name:    "decreasing time",
+			conf:    ProfileConfig{Source: datasource.NewString("0,10\n10,10\n5,10\n")},
+			wantErr: true,
+		},
+		{
+			name:    "mixed time",
+			conf:    ProfileConfig{Source: datasource.NewString("0,10\n2024-01-01T00:00:04Z,10\n")},
+			wantErr: true,
+		},
+		{
+			name:    "unknown interpolation",
+			conf:    ProfileConfig{Source: datasource.NewString("0,10\n10,10\n"), Interpolation: "cubic"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testee, err := NewProfileConf(tt.conf)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			coretest.ExpectScheduleNexts(t, testee, tt.want...)
+		})
+	}
+}
+
 func BenchmarkLineSchedule(b *testing.B) {
 	schedule := NewLine(0, float64(b.N), 2*time.Second)
 	benchmarkScheduleNext(b, schedule)
@@ -237,3 +399,153 @@ func benchmarkScheduleNext(b *testing.B, schedule core.Schedule) {
 		schedule.Next()
 	}
 }
+
+func TestPeriodic(t *testing.T) {
+	tests := []struct {
+		name string
+		conf PeriodicConfig
+		want []time.Duration
+	}{
+		{
+			name: "square",
+			conf: PeriodicConfig{Shape: PeriodicShapeSquare, Max: 2, Period: 2 * time.Second, Duration: 4 * time.Second},
+			want: []time.Duration{0, 500 * time.Millisecond, time.Second, 2500 * time.Millisecond, 4 * time.Second},
+		},
+		{
+			name: "square with phase",
+			conf: PeriodicConfig{Shape: PeriodicShapeSquare, Max: 2, Period: 2 * time.Second, Phase: 180, Duration: 4 * time.Second},
+			want: []time.Duration{0, 1500 * time.Millisecond, 2 * time.Second, 3500 * time.Millisecond, 4 * time.Second},
+		},
+		{
+			name: "sawtooth",
+			conf: PeriodicConfig{Shape: PeriodicShapeSawtooth, Max: 2, Period: 2 * time.Second, Duration: 2 * time.Second},
+			want: []time.Duration{0, 1414213563, 2 * time.Second},
+		},
+		{
+			name: "triangle",
+			conf: PeriodicConfig{Shape: PeriodicShapeTriangle, Min: 1, Max: 3, Period: 4 * time.Second, Duration: 4 * time.Second},
+			want: []time.Duration{0, 449489743, 828427125, 1171572876, 1550510258, 2 * time.Second, 2585786438, 3414213563, 4 * time.Second},
+		},
+		{
+			name: "const",
+			conf: PeriodicConfig{Min: 2, Max: 2, Period: time.Second, Duration: time.Second},
+			want: []time.Duration{0, 500 * time.Millisecond, time.Second},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testee, err := NewPeriodicConf(tt.conf)
+			require.NoError(t, err)
+			coretest.ExpectScheduleNexts(t, testee, tt.want...)
+		})
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := NewPeriodic("unknown", 0, 1, time.Second, 0, time.Second)
+		assert.Error(t, err)
+		_, err = NewPeriodic(PeriodicShapeSine, 2, 1, time.Second, 0, time.Second)
+		assert.Error(t, err)
+	})
+}
+
+func TestSine(t *testing.T) {
+	const min, max = 10.0, 1000.0
+	period, duration := 2*time.Second, 5*time.Second
+	testee, err := NewSineConf(SineConfig{Min: min, Max: max, Period: period, Phase: 90, Duration: duration})
+	require.NoError(t, err)
+	cumulative := func(t time.Duration) float64 {
+		x := 2 * math.Pi * t.Seconds() / period.Seconds()
+		return (min+max)/2*t.Seconds() + (max-min)/2*period.Seconds()/(2*math.Pi)*(math.Cos(math.Pi/2)-math.Cos(x+math.Pi/2))
+	}
+	start := time.Now()
+	testee.Start(start)
+	var (
+		n    int
+		prev time.Time
+	)
+	for {
+		x, ok := testee.Next()
+		if !ok {
+			assert.Equal(t, start.Add(duration), x)
+			break
+		}
+		require.False(t, x.Before(prev), "shot times should be monotonic")
+		assert.InDelta(t, n, cumulative(x.Sub(start)), 1e-5)
+		prev = x
+		n++
+	}
+	assert.Equal(t, int(math.Floor(cumulative(duration)+1e-9)), n)
+}
+
+func TestThinkTime(t *testing.T) {
+	t.Run("const", func(t *testing.T) {
+		testee, err := NewThinkTimeConf(ThinkTimeConfig{Mean: 100 * time.Millisecond, Duration: time.Second})
+		require.NoError(t, err)
+		start := time.Now()
+		testee.Start(start)
+		x, ok := testee.Next()
+		require.True(t, ok)
+		assert.Equal(t, start, x)
+
+		before := time.Now()
+		x, ok = testee.Next()
+		require.True(t, ok)
+		assert.False(t, x.Before(before.Add(100*time.Millisecond)))
+		assert.Equal(t, -1, testee.Left())
+	})
+
+	t.Run("finish", func(t *testing.T) {
+		testee := NewThinkTime(func() time.Duration { return time.Second }, 500*time.Millisecond)
+		start := time.Now()
+		testee.Start(start)
+		x, ok := testee.Next()
+		assert.True(t, ok)
+		assert.Equal(t, start, x)
+		x, ok = testee.Next()
+		assert.False(t, ok)
+		assert.Equal(t, start.Add(500*time.Millisecond), x)
+		assert.Equal(t, 0, testee.Left())
+	})
+
+	t.Run("distributions", func(t *testing.T) {
+		for _, conf := range []ThinkTimeConfig{
+			{Distribution: ThinkTimeUniform, Min: 10 * time.Millisecond, Max: 20 * time.Millisecond},
+			{Distribution: ThinkTimeExponential, Mean: 15 * time.Millisecond},
+			{Distribution: ThinkTimeNormal, Mean: 15 * time.Millisecond, StdDev: 10 * time.Millisecond, Min: 10 * time.Millisecond, Max: 20 * time.Millisecond},
+		} {
+			think, err := newThinkTime(conf, rand.New(rand.NewSource(1)))
+			require.NoError(t, err)
+			var sum time.Duration
+			const n = 10000
+			for i := 0; i < n; i++ {
+				d := think()
+				require.GreaterOrEqual(t, d, time.Duration(0))
+				if conf.Max > 0 {
+					require.LessOrEqual(t, d, conf.Max)
+					require.GreaterOrEqual(t, d, conf.Min)
+				}
+				sum += d
+			}
+			assert.InDelta(t, 15*time.Millisecond, sum/n, float64(time.Millisecond), conf.Distribution)
+		}
+	})
+
+	t.Run("seed per instance", func(t *testing.T) {
+		thinks := func(id int) []time.Duration {
+			testee, err := NewThinkTimeConf(ThinkTimeConfig{Distribution: ThinkTimeExponential, Mean: time.Second, Seed: 42})
+			require.NoError(t, err)
+			testee.(*thinkTimeSchedule).SeedInstance(id)
+			think := testee.(*thinkTimeSchedule).think
+			return []time.Duration{think(), think(), think()}
+		}
+		assert.Equal(t, thinks(0), thinks(0))
+		assert.NotEqual(t, thinks(0), thinks(1))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := NewThinkTimeConf(ThinkTimeConfig{Distribution: "unknown"})
+		assert.Error(t, err)
+		_, err = NewThinkTimeConf(ThinkTimeConfig{Distribution: ThinkTimeUniform, Min: time.Second})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,164 @@
+package schedule
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/yandex/pandora/core"
+)
+
+const (
+	ThinkTimeConst       = "const"
+	ThinkTimeUniform     = "uniform"
+	ThinkTimeExponential = "exponential"
+	ThinkTimeNormal      = "normal"
+)
+
+// ThinkTimeConfig is a config of closed model schedule: every operation is taken after think time
+// since the previous Next call, that is, since finish of the previous shoot of instance.
+// It should be used with rps-per-instance.
+type ThinkTimeConfig struct {
+	// Distribution is const (default), uniform, exponential or normal.
+	Distribution string
+	// Mean is think time of const distribution, and mean of exponential and normal ones.
+	Mean time.Duration `validate:"min-time=0s"`
+	// Min and Max are bounds of uniform distribution. Normal distribution is clamped by them, if they are set.
+	Min    time.Duration `validate:"min-time=0s"`
+	Max    time.Duration `validate:"min-time=0s"`
+	StdDev time.Duration `validate:"min-time=0s"`
+	// Duration is zero for unlimited schedule.
+	Duration time.Duration `validate:"min-time=0s"`
+	// Seed is zero for random seed. Schedule of instance is seeded by Seed plus instance id,
+	// so instances with the same seed have different think times.
+	Seed int64
+}
+
+func NewThinkTimeConf(conf ThinkTimeConfig) (core.Schedule, error) {
+	seed := conf.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	r := rand.New(rand.NewSource(seed))
+	think, err := newThinkTime(conf, r)
+	if err != nil {
+		return nil, err
+	}
+	s := &thinkTimeSchedule{think: think, duration: conf.Duration}
+	if conf.Seed != 0 {
+		s.seed = func(id int) { r.Seed(conf.Seed + int64(id)) }
+	}
+	return s, nil
+}
+
+// NewThinkTime returns schedule, that first operation is taken at start, and next ones after think()
+// since Next call. Schedule is unlimited, if duration is zero.
+func NewThinkTime(think func() time.Duration, duration time.Duration) core.Schedule {
+	return &thinkTimeSchedule{think: think, duration: duration}
+}
+
+func newThinkTime(conf ThinkTimeConfig, r *rand.Rand) (func() time.Duration, error) {
+	clamp := func(d time.Duration) time.Duration {
+		if conf.Max > 0 && d > conf.Max {
+			d = conf.Max
+		}
+		if d < conf.Min {
+			d = conf.Min
+		}
+		return d
+	}
+	switch conf.Distribution {
+	case "", ThinkTimeConst:
+		return func() time.Duration { return conf.Mean }, nil
+	case ThinkTimeUniform:
+		if conf.Max < conf.Min {
+			return nil, errors.Errorf("max %s should not be less than min %s", conf.Max, conf.Min)
+		}
+		return func() time.Duration {
+			return conf.Min + time.Duration(r.Float64()*float64(conf.Max-conf.Min))
+		}, nil
+	case ThinkTimeExponential:
+		return func() time.Duration {
+			return time.Duration(r.ExpFloat64() * float64(conf.Mean))
+		}, nil
+	case ThinkTimeNormal:
+		return func() time.Duration {
+			return clamp(conf.Mean + time.Duration(r.NormFloat64()*float64(conf.StdDev)))
+		}, nil
+	}
+	return nil, errors.Errorf("unknown think time distribution %q", conf.Distribution)
+}
+
+type thinkTimeSchedule struct {
+	think    func() time.Duration
+	duration time.Duration
+	seed     func(id int)
+
+	mu       sync.Mutex
+	last     time.Time
+	first    bool
+	finished bool
+
+	StartSync
+	start time.Time
+}
+
+// SeedInstance is called by engine for per instance schedule before its start.
+// Schedule with configured seed is reseeded by seed plus instance id.
+func (s *thinkTimeSchedule) SeedInstance(id int) {
+	if s.seed != nil {
+		s.seed(id)
+	}
+}
+
+func (s *thinkTimeSchedule) Start(startAt time.Time) {
+	s.MarkStarted()
+	s.startOnce.Do(func() {
+		s.start = startAt
+		s.first = true
+	})
+}
+
+func (s *thinkTimeSchedule) Next() (tx time.Time, ok bool) {
+	s.startOnce.Do(func() {
+		s.MarkStarted()
+		s.start = time.Now()
+		s.first = true
+	})
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.finished {
+		return s.finish(), false
+	}
+	tx = s.start
+	if !s.first {
+		tx = time.Now().Add(s.think())
+	}
+	s.first = false
+	if tx.Before(s.last) {
+		tx = s.last
+	}
+	if s.duration > 0 && !tx.Before(s.start.Add(s.duration)) {
+		s.finished = true
+		return s.finish(), false
+	}
+	s.last = tx
+	return tx, true
+}
+
+func (s *thinkTimeSchedule) finish() time.Time {
+	if s.duration == 0 || s.last.After(s.start.Add(s.duration)) {
+		return s.last
+	}
+	return s.start.Add(s.duration)
+}
+
+func (s *thinkTimeSchedule) Left() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.finished {
+		return 0
+	}
+	return -1
+}
@@ -1,29 +1,135 @@
 package answlog
 
 import (
-	"os"
+	"io"
+	"sync"
 
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+	"github.com/yandex/pandora/core"
+	"github.com/yandex/pandora/core/datasink"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
-func Init(path string, enabled bool) *zap.Logger {
-	if !enabled {
-		return zap.NewNop()
-	}
-	writerSyncer := getAnswWriter(path)
-	encoder := zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig())
-	core := zapcore.NewCore(encoder, writerSyncer, zapcore.DebugLevel)
+const defaultPath = "./answ.log"
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
 
-	Log := zap.New(core)
-	defer Log.Sync()
-	return Log
+// Config is a config of answer log, shared by guns.
+type Config struct {
+	Enabled bool   `config:"enabled"`
+	Path    string `config:"path"`
+	Filter  string `config:"filter" valid:"oneof=all warning error"`
+	// Format is text or json. Text is a dump of request and response. JSON is one Entry object per line.
+	Format string `config:"format"`
+	// Compression and Rotation are options of file sink. See datasink.FileConfig.
+	Compression string                  `config:"compression"`
+	Rotation    datasink.RotationConfig `config:"rotation"`
+	RulesConfig `config:",squash"`
 }
 
-func getAnswWriter(path string) zapcore.WriteSyncer {
+func (c Config) fileConfig() datasink.FileConfig {
+	path := c.Path
 	if path == "" {
-		path = "./answ.log"
+		path = defaultPath
+	}
+	return datasink.FileConfig{Path: path, Compression: c.Compression, Rotation: c.Rotation}
+}
+
+func (c Config) textFormat() bool {
+	return c.Format != FormatJSON
+}
+
+// sharedFile is a file of loggers with the same path. File is closed, when the last logger is released.
+type sharedFile struct {
+	log        *zap.Logger
+	closer     io.Closer
+	refs       int
+	textFormat bool
+	file       datasink.FileConfig
+}
+
+var files = struct {
+	sync.Mutex
+	byPath map[string]*sharedFile
+}{byPath: map[string]*sharedFile{}}
+
+// Init returns logger of answers written to file sink of conf, or nop logger, if answer log is disabled,
+// and closer, that releases logger. Loggers with the same path share the file, so it is opened only once,
+// and closed, when all of them are released. So every gun should close its logger on Close.
+// Logger initialized after that appends to the file.
+// Error is returned, if logger with the same path is opened with other format, compression or rotation.
+func Init(fs afero.Fs, conf Config) (*zap.Logger, io.Closer, error) {
+	if !conf.Enabled {
+		return zap.NewNop(), nopCloser{}, nil
+	}
+	fileConf := conf.fileConfig()
+	files.Lock()
+	defer files.Unlock()
+	f, ok := files.byPath[fileConf.Path]
+	sameConf := ok && f.textFormat == conf.textFormat() && f.file == fileConf
+	if ok && f.refs > 0 && !sameConf {
+		return nil, nil, errors.Errorf("answ log %s is already opened with other format, compression or rotation", fileConf.Path)
+	}
+	if !ok || f.refs == 0 {
+		sink := datasink.NewFile(fs, fileConf)
+		if sameConf {
+			// File has been closed by all its loggers, e.g. when instances are replaced, so it is continued.
+			sink = datasink.NewAppendFile(fs, fileConf)
+		}
+		log, closer, err := New(sink, conf.Format)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "answ log %s open", fileConf.Path)
+		}
+		f = &sharedFile{log: log, closer: closer, textFormat: conf.textFormat(), file: fileConf}
+		files.byPath[fileConf.Path] = f
+	}
+	f.refs++
+	return f.log, &fileRelease{file: f}, nil
+}
+
+type fileRelease struct {
+	once sync.Once
+	file *sharedFile
+}
+
+func (r *fileRelease) Close() error {
+	var err error
+	r.once.Do(func() {
+		files.Lock()
+		defer files.Unlock()
+		r.file.refs--
+		if r.file.refs == 0 {
+			err = r.file.closer.Close()
+		}
+	})
+	return err
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
+
+// New returns logger of answers in format written to sink, and closer of opened sink.
+func New(sink core.DataSink, format string) (*zap.Logger, io.Closer, error) {
+	w, err := sink.OpenSink()
+	if err != nil {
+		return nil, nil, err
+	}
+	var encoder zapcore.Encoder
+	if format == FormatJSON {
+		// Only fields of Entry are written.
+		encoder = zapcore.NewJSONEncoder(zapcore.EncoderConfig{
+			EncodeTime:     zapcore.RFC3339NanoTimeEncoder,
+			EncodeDuration: zapcore.StringDurationEncoder,
+		})
+	} else {
+		encoder = zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig())
 	}
-	file, _ := os.Create(path)
-	return zapcore.AddSync(file)
+	core := zapcore.NewCore(encoder, zapcore.AddSync(w), zapcore.DebugLevel)
+	return zap.New(core), w, nil
 }
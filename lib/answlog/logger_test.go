@@ -0,0 +1,97 @@
+package answlog
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yandex/pandora/core/datasink"
+)
+
+func TestInit(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		log, closer, err := Init(fs, Config{Path: "answ.log"})
+		require.NoError(t, err)
+		log.Debug("answer")
+		require.NoError(t, closer.Close())
+		exists, err := afero.Exists(fs, "answ.log")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("shared compressed", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		conf := Config{Enabled: true, Path: "answ.log.gz", Compression: datasink.CompressionGzip}
+		log1, closer1, err := Init(fs, conf)
+		require.NoError(t, err)
+		log2, closer2, err := Init(fs, conf)
+		require.NoError(t, err)
+		assert.Same(t, log1, log2)
+		log1.Debug("first answer")
+		require.NoError(t, closer1.Close())
+		require.NoError(t, closer1.Close(), "closer should be idempotent")
+		log2.Debug("second answer")
+		require.NoError(t, closer2.Close())
+
+		got := readGzip(t, fs, "answ.log.gz")
+		assert.Contains(t, got, "first answer")
+		assert.Contains(t, got, "second answer")
+	})
+
+	t.Run("reopened after close", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		conf := Config{Enabled: true, Path: "answ.log.gz", Compression: datasink.CompressionGzip}
+		log, closer, err := Init(fs, conf)
+		require.NoError(t, err)
+		log.Debug("first answer")
+		require.NoError(t, closer.Close())
+		log, closer, err = Init(fs, conf)
+		require.NoError(t, err)
+		log.Debug("second answer")
+		require.NoError(t, closer.Close())
+
+		got := readGzip(t, fs, "answ.log.gz")
+		assert.Contains(t, got, "first answer")
+		assert.Contains(t, got, "second answer")
+	})
+
+	t.Run("conflicting config", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		_, closer1, err := Init(fs, Config{Enabled: true, Path: "answ.log"})
+		require.NoError(t, err)
+		_, closer2, err := Init(fs, Config{Enabled: true, Path: "answ.log", Format: FormatText, Filter: "all"})
+		require.NoError(t, err)
+		_, _, err = Init(fs, Config{Enabled: true, Path: "answ.log", Format: FormatJSON})
+		assert.Error(t, err)
+		_, _, err = Init(fs, Config{Enabled: true, Path: "answ.log", Compression: datasink.CompressionGzip})
+		assert.Error(t, err)
+		require.NoError(t, closer1.Close())
+		require.NoError(t, closer2.Close())
+
+		// Closed file may be opened with other config.
+		_, closer, err := Init(fs, Config{Enabled: true, Path: "answ.log", Format: FormatJSON})
+		require.NoError(t, err)
+		require.NoError(t, closer.Close())
+	})
+
+	t.Run("open error", func(t *testing.T) {
+		fs := afero.NewReadOnlyFs(afero.NewMemMapFs())
+		_, _, err := Init(fs, Config{Enabled: true, Path: "answ.log"})
+		assert.Error(t, err)
+	})
+}
+
+func readGzip(t *testing.T, fs afero.Fs, path string) string {
+	data, err := afero.ReadFile(fs, path)
+	require.NoError(t, err)
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	got, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(got)
+}
@@ -0,0 +1,285 @@
+package answlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/c2h5oh/datasize"
+	"github.com/yandex/pandora/core/config"
+	"github.com/yandex/pandora/lib/numbers"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Redacted replaces values of redacted headers and JSON fields.
+const Redacted = "[REDACTED]"
+
+// DefaultRedactHeaders are always redacted in JSON answer log.
+var DefaultRedactHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// RulesConfig selects answers to log and defines how to write them in JSON format.
+type RulesConfig struct {
+	// Sample is probability of logging of an answer, that passed filter or codes. 0 is the same as 1.
+	Sample float64 `config:"sample" validate:"min=0,max=1"`
+	// Codes are response codes to log instead of filter: exact code like 429, class like 5xx or range like 500-503.
+	Codes []string `config:"codes"`
+	// MaxBodySize truncates request and response bodies in JSON format. Zero means no limit.
+	MaxBodySize datasize.ByteSize `config:"max_body_size"`
+	// RedactHeaders are redacted in JSON format in addition to DefaultRedactHeaders. Case-insensitive.
+	RedactHeaders []string `config:"redact_headers"`
+	// RedactFields are names of JSON body fields at any depth, that are redacted in JSON format.
+	RedactFields []string `config:"redact_fields"`
+}
+
+var _ = config.RegisterCustom(func(h config.ValidateHandle) {
+	conf := h.Value().(Config)
+	switch conf.Format {
+	case "", FormatText, FormatJSON:
+	default:
+		h.ReportError("format", "should be text or json")
+	}
+	for _, code := range conf.Codes {
+		if _, err := numbers.ParseCodeRange(code); err != nil {
+			h.ReportError("codes", err.Error())
+		}
+	}
+}, Config{})
+
+// Rules decide, which answers are logged, and write them. Rules are goroutine safe.
+type Rules struct {
+	filter      string
+	format      string
+	sample      float64
+	codes       []numbers.CodeRange
+	maxBodySize int
+	headers     map[string]bool
+	fields      map[string]bool
+}
+
+func NewRules(conf Config) *Rules {
+	r := &Rules{
+		filter:      conf.Filter,
+		format:      conf.Format,
+		sample:      conf.Sample,
+		maxBodySize: int(conf.MaxBodySize),
+		headers:     map[string]bool{},
+		fields:      map[string]bool{},
+	}
+	for _, code := range conf.Codes {
+		// Invalid codes are reported on config validation.
+		if cr, err := numbers.ParseCodeRange(code); err == nil {
+			r.codes = append(r.codes, cr)
+		}
+	}
+	for _, h := range append(DefaultRedactHeaders, conf.RedactHeaders...) {
+		r.headers[strings.ToLower(h)] = true
+	}
+	for _, f := range conf.RedactFields {
+		r.fields[f] = true
+	}
+	return r
+}
+
+// JSON returns true, if answers should be written by Write.
+func (r *Rules) JSON() bool {
+	return r.format == FormatJSON
+}
+
+// Match returns true, if answer with HTTP code should be logged. gRPC codes should be converted to HTTP.
+func (r *Rules) Match(code int) bool {
+	if !r.matchCode(code) {
+		return false
+	}
+	return r.sample == 0 || r.sample >= 1 || rand.Float64() < r.sample
+}
+
+func (r *Rules) matchCode(code int) bool {
+	if len(r.codes) > 0 {
+		for _, cr := range r.codes {
+			if cr.Contains(code) {
+				return true
+			}
+		}
+		return false
+	}
+	switch r.filter {
+	case "all":
+		return true
+	case "warning":
+		return code >= 400
+	case "error":
+		return code >= 500
+	}
+	return false
+}
+
+// Entry is a request and response pair in JSON answer log.
+type Entry struct {
+	// Time is the start of request.
+	Time   time.Time
+	AmmoID uint64
+	Tag    string
+	// Step is name of scenario step.
+	Step     string
+	Duration time.Duration
+	Code     int
+	Err      error
+	Request  Message
+	Response Message
+}
+
+type Message struct {
+	// Method is HTTP method or gRPC method name.
+	Method  string
+	URL     string
+	Status  string
+	Headers map[string][]string
+	Body    []byte
+}
+
+// Write writes entry to log with redacted headers and body fields, and truncated bodies.
+func (r *Rules) Write(log *zap.Logger, e *Entry) {
+	fields := []zap.Field{
+		zap.Time("ts", e.Time),
+		zap.Uint64("ammo_id", e.AmmoID),
+		zap.String("tag", e.Tag),
+	}
+	if e.Step != "" {
+		fields = append(fields, zap.String("step", e.Step))
+	}
+	fields = append(fields,
+		zap.Int64("rtt_us", e.Duration.Microseconds()),
+		zap.Int("code", e.Code),
+	)
+	if e.Err != nil {
+		fields = append(fields, zap.String("error", e.Err.Error()))
+	}
+	fields = append(fields,
+		zap.Object("request", messageMarshaler{r, &e.Request}),
+		zap.Object("response", messageMarshaler{r, &e.Response}),
+	)
+	log.Info("", fields...)
+}
+
+type messageMarshaler struct {
+	rules *Rules
+	m     *Message
+}
+
+func (mm messageMarshaler) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	m := mm.m
+	if m.Method != "" {
+		enc.AddString("method", m.Method)
+	}
+	if m.URL != "" {
+		enc.AddString("url", m.URL)
+	}
+	if m.Status != "" {
+		enc.AddString("status", m.Status)
+	}
+	if len(m.Headers) > 0 {
+		if err := enc.AddObject("headers", headersMarshaler{mm.rules, m.Headers}); err != nil {
+			return err
+		}
+	}
+	if len(m.Body) > 0 {
+		body := mm.rules.redactBody(m.Body)
+		enc.AddInt("body_size", len(m.Body))
+		if mm.rules.maxBodySize > 0 && len(body) > mm.rules.maxBodySize {
+			body = body[:mm.rules.maxBodySize]
+			enc.AddBool("body_truncated", true)
+		}
+		enc.AddByteString("body", body)
+	}
+	return nil
+}
+
+type headersMarshaler struct {
+	rules   *Rules
+	headers map[string][]string
+}
+
+func (hm headersMarshaler) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	keys := make([]string, 0, len(hm.headers))
+	for k := range hm.headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		values := hm.headers[k]
+		redacted := hm.rules.headers[strings.ToLower(k)]
+		err := enc.AddArray(k, zapcore.ArrayMarshalerFunc(func(arr zapcore.ArrayEncoder) error {
+			for _, v := range values {
+				if redacted {
+					v = Redacted
+				}
+				arr.AppendString(v)
+			}
+			return nil
+		}))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// redactBody returns body with redacted fields, if body is JSON and there are fields to redact.
+func (r *Rules) redactBody(body []byte) []byte {
+	if len(r.fields) == 0 {
+		return body
+	}
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return body
+	}
+	var v any
+	if err := json.Unmarshal(trimmed, &v); err != nil {
+		return body
+	}
+	if !r.redactValue(v) {
+		return body
+	}
+	redacted, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return redacted
+}
+
+// redactValue redacts fields of v in place, and returns true, if anything is redacted.
+func (r *Rules) redactValue(v any) bool {
+	redacted := false
+	switch v := v.(type) {
+	case map[string]any:
+		for k, fv := range v {
+			if r.fields[k] {
+				v[k] = Redacted
+				redacted = true
+				continue
+			}
+			redacted = r.redactValue(fv) || redacted
+		}
+	case []any:
+		for _, ev := range v {
+			redacted = r.redactValue(ev) || redacted
+		}
+	}
+	return redacted
+}
+
+// MetadataHeaders returns gRPC metadata as headers.
+func MetadataHeaders(md map[string]string) map[string][]string {
+	if len(md) == 0 {
+		return nil
+	}
+	headers := make(map[string][]string, len(md))
+	for k, v := range md {
+		headers[k] = []string{v}
+	}
+	return headers
+}
@@ -0,0 +1,130 @@
+package answlog
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yandex/pandora/core/config"
+	"github.com/yandex/pandora/core/datasink"
+)
+
+func TestRules_Match(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    Config
+		matched []int
+		skipped []int
+	}{
+		{"all", Config{Filter: "all"}, []int{200, 404, 503}, nil},
+		{"warning", Config{Filter: "warning"}, []int{400, 503}, []int{200, 399}},
+		{"error", Config{Filter: "error"}, []int{500}, []int{200, 499}},
+		{"none", Config{}, nil, []int{200, 500}},
+		{
+			"codes",
+			Config{Filter: "all", RulesConfig: RulesConfig{Codes: []string{"429", "5xx", "300-302"}}},
+			[]int{429, 500, 599, 300, 302},
+			[]int{200, 303, 404, 600},
+		},
+		{"never sampled", Config{Filter: "all", RulesConfig: RulesConfig{Sample: 1e-12}}, nil, []int{200, 200, 200}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := NewRules(tt.conf)
+			for _, code := range tt.matched {
+				assert.True(t, rules.Match(code), "code %v", code)
+			}
+			for _, code := range tt.skipped {
+				assert.False(t, rules.Match(code), "code %v", code)
+			}
+		})
+	}
+}
+
+func TestRules_Sample(t *testing.T) {
+	rules := NewRules(Config{Filter: "all", RulesConfig: RulesConfig{Sample: 0.5}})
+	matched := 0
+	for i := 0; i < 10000; i++ {
+		if rules.Match(200) {
+			matched++
+		}
+	}
+	assert.InDelta(t, 5000, matched, 500)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, config.Validate(Config{Format: FormatJSON, RulesConfig: RulesConfig{Codes: []string{"2XX", "404"}}}))
+	assert.Error(t, config.Validate(Config{Format: "xml"}))
+	assert.Error(t, config.Validate(Config{RulesConfig: RulesConfig{Codes: []string{"5x"}}}))
+	assert.Error(t, config.Validate(Config{RulesConfig: RulesConfig{Codes: []string{"503-500"}}}))
+	assert.Error(t, config.Validate(Config{RulesConfig: RulesConfig{Sample: 2}}))
+}
+
+func TestRules_Write(t *testing.T) {
+	sink := datasink.NewBuffer()
+	log, closer, err := New(sink, FormatJSON)
+	require.NoError(t, err)
+	rules := NewRules(Config{Format: FormatJSON, RulesConfig: RulesConfig{
+		MaxBodySize:   32,
+		RedactHeaders: []string{"x-api-key"},
+		RedactFields:  []string{"password"},
+	}})
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	rules.Write(log, &Entry{
+		Time:     start,
+		AmmoID:   7,
+		Tag:      "login",
+		Step:     "auth",
+		Duration: 1500 * time.Microsecond,
+		Code:     500,
+		Err:      errors.New("failed"),
+		Request: Message{
+			Method: "POST",
+			URL:    "http://localhost/login",
+			Headers: map[string][]string{
+				"Authorization": {"Bearer secret"},
+				"X-Api-Key":     {"secret"},
+				"Accept":        {"application/json"},
+			},
+			Body: []byte(`{"user":{"name":"u","password":"secret"}}`),
+		},
+		Response: Message{
+			Status: "500 Internal Server Error",
+			Body:   []byte(`{"error":"something went wrong on server side"}`),
+		},
+	})
+	require.NoError(t, closer.Close())
+
+	var got map[string]any
+	require.NoError(t, json.Unmarshal(sink.Bytes(), &got))
+	assert.Equal(t, map[string]any{
+		"ts":      "2024-01-02T03:04:05Z",
+		"ammo_id": 7.0,
+		"tag":     "login",
+		"step":    "auth",
+		"rtt_us":  1500.0,
+		"code":    500.0,
+		"error":   "failed",
+		"request": map[string]any{
+			"method": "POST",
+			"url":    "http://localhost/login",
+			"headers": map[string]any{
+				"Accept":        []any{"application/json"},
+				"Authorization": []any{Redacted},
+				"X-Api-Key":     []any{Redacted},
+			},
+			"body":           `{"user":{"name":"u","password":"[REDACTED]"}}`[:32],
+			"body_size":      41.0,
+			"body_truncated": true,
+		},
+		"response": map[string]any{
+			"status":         "500 Internal Server Error",
+			"body":           `{"error":"something went wrong o`,
+			"body_size":      47.0,
+			"body_truncated": true,
+		},
+	}, got)
+}
@@ -0,0 +1,99 @@
+package confutil
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// SecretKeyEnv is env variable with base64 encoded 16, 24 or 32 bytes key of AES tag resolver.
+const SecretKeyEnv = "PANDORA_SECRET_KEY"
+
+var (
+	ErrSecretKeyNotProvided = errors.New(SecretKeyEnv + " env variable not set")
+	ErrInvalidSecretKey     = errors.New("secret key should be base64 encoded 16, 24 or 32 bytes")
+	ErrDecryptionFailed     = errors.New("decryption failed: wrong key or corrupted value")
+)
+
+// Resolve custom tag token with AES-GCM decrypted value. Key is read from PANDORA_SECRET_KEY env variable.
+// Values are encrypted by EncryptValue, or by pandora encrypt command
+// for example: token: '${aes: Yb9Dqk1T...}'
+var AESTagResolver TagResolver = aesTokenResolver
+
+func aesTokenResolver(in string) (string, error) {
+	encodedKey, ok := os.LookupEnv(SecretKeyEnv)
+	if !ok {
+		return "", ErrSecretKeyNotProvided
+	}
+	key, err := ParseSecretKey(encodedKey)
+	if err != nil {
+		return "", err
+	}
+	return DecryptValue(key, in)
+}
+
+// NewSecretKey returns new random key in SecretKeyEnv format.
+func NewSecretKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// ParseSecretKey decodes key in SecretKeyEnv format.
+func ParseSecretKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidSecretKey
+	}
+	switch len(key) {
+	case 16, 24, 32:
+		return key, nil
+	}
+	return nil, ErrInvalidSecretKey
+}
+
+// EncryptValue returns base64 encoded AES-GCM encrypted value with random nonce, that is decrypted by DecryptValue.
+func EncryptValue(key []byte, value string) (string, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	encrypted := aead.Seal(nonce, nonce, []byte(value), nil)
+	return base64.StdEncoding.EncodeToString(encrypted), nil
+}
+
+// DecryptValue decrypts value encrypted by EncryptValue.
+func DecryptValue(key []byte, encrypted string) (string, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil || len(data) < aead.NonceSize() {
+		return "", ErrDecryptionFailed
+	}
+	nonceSize := aead.NonceSize()
+	value, err := aead.Open(nil, data[:nonceSize], data[nonceSize:], nil)
+	if err != nil {
+		return "", ErrDecryptionFailed
+	}
+	return string(value), nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSecretKey, err)
+	}
+	return cipher.NewGCM(block)
+}
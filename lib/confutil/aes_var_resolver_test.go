@@ -0,0 +1,44 @@
+package confutil
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAESTokenResolver(t *testing.T) {
+	_, err := aesTokenResolver("value")
+	assert.ErrorIs(t, err, ErrSecretKeyNotProvided)
+
+	encodedKey, err := NewSecretKey()
+	require.NoError(t, err)
+	key, err := ParseSecretKey(encodedKey)
+	require.NoError(t, err)
+	encrypted, err := EncryptValue(key, "secret token")
+	require.NoError(t, err)
+	again, err := EncryptValue(key, "secret token")
+	require.NoError(t, err)
+	assert.NotEqual(t, encrypted, again, "nonce should be random")
+
+	t.Setenv(SecretKeyEnv, encodedKey)
+	actual, err := aesTokenResolver(encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, "secret token", actual)
+
+	_, err = aesTokenResolver(again[:len(again)-4] + "AAAA")
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+	_, err = aesTokenResolver("short")
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+
+	otherKey, err := NewSecretKey()
+	require.NoError(t, err)
+	t.Setenv(SecretKeyEnv, otherKey)
+	_, err = aesTokenResolver(encrypted)
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+
+	t.Setenv(SecretKeyEnv, base64.StdEncoding.EncodeToString([]byte("short key")))
+	_, err = aesTokenResolver(encrypted)
+	assert.ErrorIs(t, err, ErrInvalidSecretKey)
+}
@@ -0,0 +1,28 @@
+package confutil
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidBase64 = errors.New("invalid base64 value")
+
+// Resolve custom tag token with base64 decoded value. Standard and URL encodings, padded or not, are supported
+// for example: password: '${base64: cGFzc3dvcmQ=}'
+var Base64TagResolver TagResolver = base64TokenResolver
+
+func base64TokenResolver(in string) (string, error) {
+	in = strings.TrimRight(in, "=")
+	decoded, err := base64.RawStdEncoding.DecodeString(in)
+	if err != nil {
+		var urlErr error
+		decoded, urlErr = base64.RawURLEncoding.DecodeString(in)
+		if urlErr != nil {
+			// Value is not in error, as it is a secret.
+			return "", fmt.Errorf("%w: %v", ErrInvalidBase64, err)
+		}
+	}
+	return string(decoded), nil
+}
@@ -0,0 +1,29 @@
+package confutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBase64TokenResolver(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"cGFzc3dvcmQ=", "password"},
+		{"cGFzc3dvcmQ", "password"},
+		{"Pz8_Pw==", "????"},
+		{"Pz8/Pw", "????"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		actual, err := base64TokenResolver(tt.input)
+		assert.NoError(t, err, tt.input)
+		assert.Equal(t, tt.expected, actual, tt.input)
+	}
+
+	_, err := base64TokenResolver("not base64!")
+	assert.ErrorIs(t, err, ErrInvalidBase64)
+	assert.NotContains(t, err.Error(), "not base64")
+}
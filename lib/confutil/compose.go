@@ -0,0 +1,251 @@
+package confutil
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// ExtendsKey is a config key with file or list of files, that config inherits and overrides.
+	ExtendsKey = "extends"
+	// IncludeKey is a config key with file or list of files, that are merged into config
+	// after extended files, and are overridden by config itself.
+	IncludeKey = "include"
+	// MergeIDKey is a key of list elements, that are merged by it instead of list replacement.
+	MergeIDKey = "id"
+)
+
+// ReadConfigFile reads config file in any format supported by viper, and resolves its extends
+// and include directives. Extension-less files are read as YAML.
+func ReadConfigFile(fs afero.Fs, filename string) (map[string]any, error) {
+	return readConfigFile(fs, filename, nil)
+}
+
+func readConfigFile(fs afero.Fs, filename string, stack []string) (map[string]any, error) {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range stack {
+		if f == abs {
+			return nil, fmt.Errorf("config %s is included recursively: %s", filename, strings.Join(append(stack, abs), " -> "))
+		}
+	}
+	v := viper.New()
+	v.SetFs(fs)
+	v.SetConfigFile(filename)
+	if filepath.Ext(filename) == "" {
+		v.SetConfigType("yaml")
+	}
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("config %s read failed: %w", filename, err)
+	}
+	return resolveIncludes(fs, v.AllSettings(), filepath.Dir(filename), append(stack, abs))
+}
+
+// ResolveIncludes returns config with merged files of its extends and include directives.
+// Relative paths are resolved relative to dir. Directives of included files are resolved too.
+func ResolveIncludes(fs afero.Fs, conf map[string]any, dir string) (map[string]any, error) {
+	return resolveIncludes(fs, conf, dir, nil)
+}
+
+func resolveIncludes(fs afero.Fs, conf map[string]any, dir string, stack []string) (map[string]any, error) {
+	var files []string
+	for _, key := range []string{ExtendsKey, IncludeKey} {
+		keyFiles, err := directiveFiles(conf, key)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, keyFiles...)
+		delete(conf, key)
+	}
+	if len(files) == 0 {
+		return conf, nil
+	}
+	result := map[string]any{}
+	for _, file := range files {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+		included, err := readConfigFile(fs, file, stack)
+		if err != nil {
+			return nil, err
+		}
+		result = MergeConfig(result, included)
+	}
+	return MergeConfig(result, conf), nil
+}
+
+func directiveFiles(conf map[string]any, key string) ([]string, error) {
+	switch value := conf[key].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{value}, nil
+	case []any:
+		files := make([]string, 0, len(value))
+		for _, v := range value {
+			file, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s should be file or list of files, but has %v", key, v)
+			}
+			files = append(files, file)
+		}
+		return files, nil
+	default:
+		return nil, fmt.Errorf("%s should be file or list of files, but is %v", key, value)
+	}
+}
+
+// MergeConfig deep merges src into dst, and returns dst. Maps are merged by keys, and src values override dst ones.
+// Lists of maps with id key, like pools, are merged by id: elements with the same id are merged, and new ones are appended.
+// Other lists are replaced.
+func MergeConfig(dst, src map[string]any) map[string]any {
+	if dst == nil {
+		dst = map[string]any{}
+	}
+	for key, srcValue := range src {
+		dstKey := findKey(dst, key)
+		if dstKey == "" {
+			dst[key] = srcValue
+			continue
+		}
+		dst[dstKey] = mergeValue(dst[dstKey], srcValue)
+	}
+	return dst
+}
+
+func mergeValue(dst, src any) any {
+	switch src := src.(type) {
+	case map[string]any:
+		if dst, ok := dst.(map[string]any); ok {
+			return MergeConfig(dst, src)
+		}
+	case []any:
+		if dst, ok := dst.([]any); ok && hasMergeIDs(dst) && hasMergeIDs(src) {
+			return mergeListByID(dst, src)
+		}
+	}
+	return src
+}
+
+func hasMergeIDs(list []any) bool {
+	for _, v := range list {
+		m, ok := v.(map[string]any)
+		if !ok || m[MergeIDKey] == nil {
+			return false
+		}
+	}
+	return len(list) > 0
+}
+
+func mergeListByID(dst, src []any) []any {
+	result := append([]any(nil), dst...)
+	for _, srcElem := range src {
+		srcMap := srcElem.(map[string]any)
+		merged := false
+		for i, dstElem := range result {
+			dstMap := dstElem.(map[string]any)
+			if fmt.Sprint(dstMap[MergeIDKey]) == fmt.Sprint(srcMap[MergeIDKey]) {
+				result[i] = MergeConfig(dstMap, srcMap)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			result = append(result, srcMap)
+		}
+	}
+	return result
+}
+
+// findKey returns key of m, that equals to key case-insensitively, as viper lower cases config keys.
+func findKey(m map[string]any, key string) string {
+	if _, ok := m[key]; ok {
+		return key
+	}
+	for k := range m {
+		if strings.EqualFold(k, key) {
+			return k
+		}
+	}
+	return ""
+}
+
+// SetConfigValue sets config value by assignment like pools.0.gun.target=host:80.
+// Path elements are map keys or list indexes. Index equal to list length appends element.
+// Value is parsed as YAML, so numbers, booleans and lists could be set.
+func SetConfigValue(conf map[string]any, assignment string) error {
+	path, rawValue, ok := strings.Cut(assignment, "=")
+	if !ok || path == "" {
+		return fmt.Errorf("invalid assignment %q: should be path=value", assignment)
+	}
+	var value any
+	if err := yaml.Unmarshal([]byte(rawValue), &value); err != nil {
+		return fmt.Errorf("invalid value of %s: %w", path, err)
+	}
+	keys := strings.Split(path, ".")
+	_, err := setValue(conf, keys, 0, normalizeYAML(value))
+	return err
+}
+
+// setValue sets value by keys[i:] in node, and returns node, which is created, if it is nil.
+func setValue(node any, keys []string, i int, value any) (any, error) {
+	if i == len(keys) {
+		return value, nil
+	}
+	key := keys[i]
+	switch n := node.(type) {
+	case nil:
+		return setValue(map[string]any{}, keys, i, value)
+	case map[string]any:
+		if k := findKey(n, key); k != "" {
+			key = k
+		}
+		child, err := setValue(n[key], keys, i+1, value)
+		if err != nil {
+			return nil, err
+		}
+		n[key] = child
+		return n, nil
+	case []any:
+		index, err := strconv.Atoi(key)
+		if err != nil || index < 0 || index > len(n) {
+			return nil, fmt.Errorf("invalid index %s of %s: list has %v elements", key, strings.Join(keys[:i], "."), len(n))
+		}
+		if index == len(n) {
+			n = append(n, nil)
+		}
+		child, err := setValue(n[index], keys, i+1, value)
+		if err != nil {
+			return nil, err
+		}
+		n[index] = child
+		return n, nil
+	default:
+		return nil, fmt.Errorf("%s is not a map or list", strings.Join(keys[:i], "."))
+	}
+}
+
+// normalizeYAML converts maps decoded by yaml.v2 to map[string]any, as they are in configs read by viper.
+func normalizeYAML(value any) any {
+	switch v := value.(type) {
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			m[fmt.Sprint(k)] = normalizeYAML(e)
+		}
+		return m
+	case []any:
+		for i, e := range v {
+			v[i] = normalizeYAML(e)
+		}
+	}
+	return value
+}
@@ -0,0 +1,122 @@
+package confutil
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newComposeFs(t *testing.T, files map[string]string) afero.Fs {
+	fs := afero.NewMemMapFs()
+	for name, content := range files {
+		require.NoError(t, afero.WriteFile(fs, name, []byte(content), 0644))
+	}
+	return fs
+}
+
+func TestReadConfigFile(t *testing.T) {
+	fs := newComposeFs(t, map[string]string{
+		"/conf/base.yaml": `
+pools:
+  - id: api
+    gun: {type: http, target: base:80}
+    rps: {type: const, ops: 10, duration: 10s}
+  - id: static
+    gun: {type: http, target: base:80}
+log:
+  level: debug
+`,
+		"/conf/common/monitoring.json": `{"monitoring": {"expvar": {"enabled": true}}}`,
+		"/conf/prod.yaml": `
+extends: base.yaml
+include: [common/monitoring.json]
+pools:
+  - id: api
+    gun: {target: prod:80}
+  - id: new
+    gun: {type: grpc}
+`,
+	})
+	conf, err := ReadConfigFile(fs, "/conf/prod.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"pools": []any{
+			map[string]any{
+				"id":  "api",
+				"gun": map[string]any{"type": "http", "target": "prod:80"},
+				"rps": map[string]any{"type": "const", "ops": 10, "duration": "10s"},
+			},
+			map[string]any{"id": "static", "gun": map[string]any{"type": "http", "target": "base:80"}},
+			map[string]any{"id": "new", "gun": map[string]any{"type": "grpc"}},
+		},
+		"log":        map[string]any{"level": "debug"},
+		"monitoring": map[string]any{"expvar": map[string]any{"enabled": true}},
+	}, conf)
+}
+
+func TestReadConfigFile_Errors(t *testing.T) {
+	fs := newComposeFs(t, map[string]string{
+		"/a.yaml":       "extends: b.yaml",
+		"/b.yaml":       "include: [a.yaml]",
+		"/invalid.yaml": "extends: {file: a.yaml}",
+	})
+	_, err := ReadConfigFile(fs, "/a.yaml")
+	assert.ErrorContains(t, err, "included recursively")
+	_, err = ReadConfigFile(fs, "/invalid.yaml")
+	assert.ErrorContains(t, err, "should be file or list of files")
+	_, err = ReadConfigFile(fs, "/absent.yaml")
+	assert.Error(t, err)
+}
+
+func TestMergeConfig(t *testing.T) {
+	dst := map[string]any{
+		"list":    []any{1, 2},
+		"map":     map[string]any{"a": 1, "b": map[string]any{"c": 2}},
+		"replace": map[string]any{"a": 1},
+		"Upper":   1,
+	}
+	src := map[string]any{
+		"list":    []any{3},
+		"map":     map[string]any{"b": map[string]any{"d": 3}},
+		"replace": "value",
+		"upper":   2,
+	}
+	assert.Equal(t, map[string]any{
+		"list":    []any{3},
+		"map":     map[string]any{"a": 1, "b": map[string]any{"c": 2, "d": 3}},
+		"replace": "value",
+		"Upper":   2,
+	}, MergeConfig(dst, src))
+}
+
+func TestSetConfigValue(t *testing.T) {
+	conf := map[string]any{
+		"pools": []any{
+			map[string]any{"id": "api", "gun": map[string]any{"target": "localhost:80"}},
+		},
+	}
+	require.NoError(t, SetConfigValue(conf, "pools.0.gun.target=host:80"))
+	require.NoError(t, SetConfigValue(conf, "pools.0.rps.ops=100"))
+	require.NoError(t, SetConfigValue(conf, "pools.0.gun.ssl=true"))
+	require.NoError(t, SetConfigValue(conf, "pools.1.id=new"))
+	require.NoError(t, SetConfigValue(conf, "log.level=debug"))
+	require.NoError(t, SetConfigValue(conf, "pools.0.headers={a: b}"))
+	assert.Equal(t, map[string]any{
+		"pools": []any{
+			map[string]any{
+				"id":      "api",
+				"gun":     map[string]any{"target": "host:80", "ssl": true},
+				"rps":     map[string]any{"ops": 100},
+				"headers": map[string]any{"a": "b"},
+			},
+			map[string]any{"id": "new"},
+		},
+		"log": map[string]any{"level": "debug"},
+	}, conf)
+
+	assert.ErrorContains(t, SetConfigValue(conf, "pools.3.id=x"), "invalid index 3 of pools")
+	assert.ErrorContains(t, SetConfigValue(conf, "pools.0.id.x=y"), "pools.0.id is not a map or list")
+	assert.ErrorContains(t, SetConfigValue(conf, "pools"), "should be path=value")
+}
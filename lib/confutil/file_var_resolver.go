@@ -0,0 +1,19 @@
+package confutil
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Resolve custom tag token with file content without trailing line breaks. Allow to keep secrets in mounted files
+// for example: token: '${file: /run/secrets/token}'
+var FileTagResolver TagResolver = fileTokenResolver
+
+func fileTokenResolver(in string) (string, error) {
+	content, err := os.ReadFile(in)
+	if err != nil {
+		return "", fmt.Errorf("cannot read file: '%v'", in)
+	}
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
@@ -0,0 +1,22 @@
+package confutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileTokenResolver(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(filename, []byte("secret\nwith lines\r\n\n"), 0600))
+
+	actual, err := fileTokenResolver(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, "secret\nwith lines", actual)
+
+	_, err = fileTokenResolver("nonexistent.txt")
+	assert.EqualError(t, err, "cannot read file: 'nonexistent.txt'")
+}
@@ -0,0 +1,98 @@
+package confutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PaesslerAG/jsonpath"
+)
+
+// HTTPTagHeadersEnv is env variable with headers of HTTP tag resolver requests, like secret storage tokens.
+// Headers are "Name: value" lines.
+const HTTPTagHeadersEnv = "PANDORA_HTTP_TAG_HEADERS"
+
+const httpTagTimeout = 10 * time.Second
+
+// Resolve custom tag token with HTTP GET response body or its JSON value got by jsonpath after #.
+// Every URL is requested once, and its response is reused for all tokens and config decodes.
+// for example: token: '${http: https://vault.local/v1/secret/data/load#$.data.data.token}'
+var HTTPTagResolver TagResolver = NewHTTPTagResolver(&http.Client{Timeout: httpTagTimeout})
+
+// NewHTTPTagResolver returns HTTP tag resolver, that sends requests by client.
+func NewHTTPTagResolver(client *http.Client) TagResolver {
+	r := &httpTagResolver{client: client, bodies: map[string][]byte{}}
+	return r.resolve
+}
+
+type httpTagResolver struct {
+	client *http.Client
+	mu     sync.Mutex
+	bodies map[string][]byte
+}
+
+func (r *httpTagResolver) resolve(in string) (string, error) {
+	url, path, hasPath := strings.Cut(in, "#")
+	body, err := r.body(url)
+	if err != nil {
+		return "", err
+	}
+	if !hasPath {
+		return string(body), nil
+	}
+	var data any
+	if err := json.Unmarshal(body, &data); err != nil {
+		return "", fmt.Errorf("%s response is not JSON: %w", url, err)
+	}
+	value, err := jsonpath.Get(path, data)
+	if err != nil {
+		return "", fmt.Errorf("%s response has no %s: %w", url, path, err)
+	}
+	switch value := value.(type) {
+	case string:
+		return value, nil
+	case map[string]any, []any:
+		encoded, err := json.Marshal(value)
+		return string(encoded), err
+	default:
+		return fmt.Sprint(value), nil
+	}
+}
+
+func (r *httpTagResolver) body(url string) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if body, ok := r.bodies[url]; ok {
+		return body, nil
+	}
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url '%v': %w", url, err)
+	}
+	for _, line := range strings.Split(os.Getenv(HTTPTagHeadersEnv), "\n") {
+		name, value, ok := strings.Cut(line, ":")
+		if ok {
+			req.Header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+		}
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%s response read failed: %w", url, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s responded %s", url, resp.Status)
+	}
+	r.bodies[url] = bytes.TrimSpace(body)
+	return r.bodies[url], nil
+}
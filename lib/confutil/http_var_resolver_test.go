@@ -0,0 +1,54 @@
+package confutil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPTagResolver(t *testing.T) {
+	t.Setenv(HTTPTagHeadersEnv, "X-Vault-Token: root\nAccept: application/json")
+	requests := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/secret":
+			_, _ = w.Write([]byte(`{"data": {"token": "abc", "port": 8080, "tags": ["a"]}}`))
+		case "/plain":
+			_, _ = w.Write([]byte("plain token\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	resolve := NewHTTPTagResolver(server.Client())
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{server.URL + "/secret#$.data.token", "abc"},
+		{server.URL + "/secret#$.data.port", "8080"},
+		{server.URL + "/secret#$.data.tags", `["a"]`},
+		{server.URL + "/plain", "plain token"},
+	}
+	for _, tt := range tests {
+		actual, err := resolve(tt.input)
+		assert.NoError(t, err, tt.input)
+		assert.Equal(t, tt.expected, actual, tt.input)
+	}
+	assert.Equal(t, map[string]int{"/secret": 1, "/plain": 1}, requests)
+
+	_, err := resolve(server.URL + "/secret#$.data.absent")
+	assert.ErrorContains(t, err, "response has no $.data.absent")
+	_, err = resolve(server.URL + "/plain#$.token")
+	assert.ErrorContains(t, err, "response is not JSON")
+	_, err = resolve(server.URL + "/absent")
+	assert.ErrorContains(t, err, "responded 404 Not Found")
+}
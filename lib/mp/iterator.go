@@ -1,11 +1,28 @@
 package mp
 
 import (
+	"errors"
 	"math/rand"
 	"sync"
 	"sync/atomic"
 )
 
+// ErrExhausted is returned by Stream, when all its elements are consumed.
+var ErrExhausted = errors.New("sequence is exhausted")
+
+// Stream gives elements only for next index, e.g. when elements are read from disk.
+type Stream interface {
+	// Next returns ErrExhausted, when there are no more elements.
+	Next() (any, error)
+}
+
+// Sequence is a collection, that chooses element for next index by itself, e.g. to give every element only once.
+type Sequence interface {
+	Stream
+	Len() int
+	At(i int) any
+}
+
 type Iterator interface {
 	Next(segment string) int
 	Rand(length int) int
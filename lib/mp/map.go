@@ -66,6 +66,22 @@ func GetMapValue(current map[string]any, path string, iter Iterator) (any, error
 }
 
 func extractFromSlice(curValue any, indexStr string, curSegment string, iter Iterator) (result any, err error) {
+	if stream, ok := curValue.(Stream); ok && indexStr == "next" {
+		return stream.Next()
+	}
+	if seq, ok := curValue.(Sequence); ok {
+		if seq.Len() == 0 {
+			return nil, ErrExhausted
+		}
+		index, err := calcIndex(indexStr, curSegment, seq.Len(), iter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calc index for %T; err: %w", curValue, err)
+		}
+		return seq.At(index), nil
+	}
+	if _, ok := curValue.(Stream); ok {
+		return nil, fmt.Errorf("only [next] index is supported for stream %s", curSegment)
+	}
 	validTypes := []reflect.Type{
 		reflect.TypeOf([]map[string]string{}),
 		reflect.TypeOf([]map[string]any{}),
@@ -365,6 +365,48 @@ func Test_getValue_iterators(t *testing.T) {
 
 }
 
+type testSequence struct {
+	items []any
+	next  int
+}
+
+func (s *testSequence) Len() int     { return len(s.items) }
+func (s *testSequence) At(i int) any { return s.items[i] }
+func (s *testSequence) Next() (any, error) {
+	if s.next >= len(s.items) {
+		return nil, ErrExhausted
+	}
+	s.next++
+	return s.items[s.next-1], nil
+}
+
+func Test_getValue_sequence(t *testing.T) {
+	iter := NewNextIterator(0)
+	reqMap := map[string]any{
+		"source": map[string]any{
+			"users": &testSequence{items: []any{map[string]any{"id": 1}, map[string]any{"id": 2}}},
+			"empty": &testSequence{},
+		},
+	}
+
+	got, err := GetMapValue(reqMap, "source.users[next].id", iter)
+	require.NoError(t, err)
+	assert.Equal(t, 1, got)
+	got, err = GetMapValue(reqMap, "source.users[0].id", iter)
+	require.NoError(t, err)
+	assert.Equal(t, 1, got)
+	got, err = GetMapValue(reqMap, "source.users[next].id", iter)
+	require.NoError(t, err)
+	assert.Equal(t, 2, got)
+	_, err = GetMapValue(reqMap, "source.users[next].id", iter)
+	assert.ErrorIs(t, err, ErrExhausted)
+	got, err = GetMapValue(reqMap, "source.users[last].id", iter)
+	require.NoError(t, err)
+	assert.Equal(t, 2, got)
+	_, err = GetMapValue(reqMap, "source.empty[0]", iter)
+	assert.ErrorIs(t, err, ErrExhausted)
+}
+
 var tmpJSON = `{
     "name": "John Doe",
     "age": 30,
@@ -0,0 +1,38 @@
+package numbers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CodeRange is an inclusive range of response codes.
+type CodeRange struct {
+	From, To int
+}
+
+// Contains returns true, if code is in range.
+func (r CodeRange) Contains(code int) bool {
+	return r.From <= code && code <= r.To
+}
+
+// ParseCodeRange parses exact code like 429, class like 5xx or range like 500-503.
+func ParseCodeRange(s string) (CodeRange, error) {
+	str := strings.TrimSpace(s)
+	if len(str) == 3 && strings.HasSuffix(strings.ToLower(str), "xx") && str[0] >= '0' && str[0] <= '9' {
+		class := int(str[0]-'0') * 100
+		return CodeRange{class, class + 99}, nil
+	}
+	from, to, isRange := strings.Cut(str, "-")
+	var r CodeRange
+	var err error
+	r.From, err = strconv.Atoi(strings.TrimSpace(from))
+	r.To = r.From
+	if err == nil && isRange {
+		r.To, err = strconv.Atoi(strings.TrimSpace(to))
+	}
+	if err != nil || r.From > r.To {
+		return CodeRange{}, fmt.Errorf("invalid code %q: should be code like 429, class like 5xx or range like 500-503", s)
+	}
+	return r, nil
+}
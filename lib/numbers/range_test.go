@@ -0,0 +1,28 @@
+package numbers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCodeRange(t *testing.T) {
+	for s, want := range map[string]CodeRange{
+		"429":       {429, 429},
+		" 5xx ":     {500, 599},
+		"2XX":       {200, 299},
+		"500-503":   {500, 503},
+		"500 - 503": {500, 503},
+	} {
+		got, err := ParseCodeRange(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, want, got, s)
+	}
+	for _, s := range []string{"", "x", "axx", "503-500", "500-", "2x"} {
+		_, err := ParseCodeRange(s)
+		assert.Error(t, err, s)
+	}
+	assert.True(t, CodeRange{500, 503}.Contains(503))
+	assert.False(t, CodeRange{500, 503}.Contains(504))
+}
@@ -0,0 +1,114 @@
+package report
+
+import (
+	"fmt"
+	"time"
+)
+
+type CompareConfig struct {
+	// LatencyThreshold is relative latency increase, that is regression. E.g. 0.1 is +10%.
+	LatencyThreshold float64
+	// ErrorThreshold is absolute error rate increase, that is regression. E.g. 0.01 is +1%.
+	ErrorThreshold float64
+}
+
+func DefaultCompareConfig() CompareConfig {
+	return CompareConfig{
+		LatencyThreshold: 0.1,
+		ErrorThreshold:   0.01,
+	}
+}
+
+// Comparison of report with baseline report.
+type Comparison struct {
+	Baseline string
+	Rows     []ComparisonRow
+	// Regressions is number of rows with regression.
+	Regressions int
+}
+
+// ComparisonRow compares metric of total or tag stats.
+type ComparisonRow struct {
+	// Stats is "total" or tag name.
+	Stats    string
+	Metric   string
+	Baseline string
+	Current  string
+	// Change is relative change for latency and RPS, and absolute change for error rate.
+	Change     float64
+	Regression bool
+}
+
+// Compare sets comparison of r with baseline for total stats and tags present in both reports.
+func Compare(r, baseline *Report, conf CompareConfig) {
+	c := &Comparison{Baseline: baseline.Title}
+	c.add("total", r.Total, baseline.Total, conf)
+	baseTags := map[string]Stats{}
+	for _, s := range baseline.Tags {
+		baseTags[s.Tag] = s
+	}
+	// Total is the same as tag stats, if results have single tag.
+	if len(r.Tags) > 1 || len(baseline.Tags) > 1 {
+		for _, s := range r.Tags {
+			if base, ok := baseTags[s.Tag]; ok {
+				c.add("tag "+tagName(s.Tag), s, base, conf)
+			}
+		}
+	}
+	r.Comparison = c
+}
+
+func (c *Comparison) add(stats string, current, base Stats, conf CompareConfig) {
+	c.addRow(ComparisonRow{
+		Stats: stats, Metric: "rps",
+		Baseline: fmt.Sprintf("%.1f", base.RPS), Current: fmt.Sprintf("%.1f", current.RPS),
+		Change: relativeChange(current.RPS, base.RPS),
+	})
+
+	latency := func(metric string, cur, b time.Duration) {
+		change := relativeChange(float64(cur), float64(b))
+		c.addRow(ComparisonRow{
+			Stats: stats, Metric: metric,
+			Baseline: FormatDuration(b), Current: FormatDuration(cur),
+			Change:     change,
+			Regression: change > conf.LatencyThreshold,
+		})
+	}
+	latency("avg", current.Avg, base.Avg)
+	for i, q := range Quantiles {
+		latency(QuantileName(q), current.Quantiles[i], base.Quantiles[i])
+	}
+
+	change := current.ErrorRate() - base.ErrorRate()
+	c.addRow(ComparisonRow{
+		Stats: stats, Metric: "errors",
+		Baseline: FormatPercent(base.ErrorRate()), Current: FormatPercent(current.ErrorRate()),
+		Change:     change,
+		Regression: change > conf.ErrorThreshold,
+	})
+}
+
+func (c *Comparison) addRow(row ComparisonRow) {
+	c.Rows = append(c.Rows, row)
+	if row.Regression {
+		c.Regressions++
+	}
+}
+
+// FormatChange returns signed percent of change. Change of error rate is in percentage points.
+func (r ComparisonRow) FormatChange() string {
+	if r.Metric == "errors" {
+		return fmt.Sprintf("%+.2f pp", r.Change*100)
+	}
+	return fmt.Sprintf("%+.1f%%", r.Change*100)
+}
+
+func relativeChange(current, base float64) float64 {
+	if base == 0 {
+		if current == 0 {
+			return 0
+		}
+		return 1
+	}
+	return current/base - 1
+}
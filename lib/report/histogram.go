@@ -0,0 +1,98 @@
+package report
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+// histogramSubBits defines precision of histogram: every power of two range is split
+// into 1<<histogramSubBits buckets, so relative error is less than 1%.
+const histogramSubBits = 7
+
+// histogram counts durations in microseconds with fixed relative precision, so memory
+// does not depend on number of samples.
+type histogram struct {
+	counts []uint64
+	total  uint64
+	sum    uint64
+	min    uint64
+	max    uint64
+}
+
+func bucketIndex(v uint64) int {
+	if v < 1<<(histogramSubBits+1) {
+		return int(v)
+	}
+	shift := bits.Len64(v) - histogramSubBits - 1
+	return (shift+1)<<histogramSubBits + int(v>>shift) - 1<<histogramSubBits
+}
+
+// bucketValue returns middle of bucket range.
+func bucketValue(i int) uint64 {
+	if i < 1<<(histogramSubBits+1) {
+		return uint64(i)
+	}
+	shift := i>>histogramSubBits - 1
+	lower := uint64(i&(1<<histogramSubBits-1)+1<<histogramSubBits) << shift
+	return lower + (1<<shift-1)/2
+}
+
+func (h *histogram) add(d time.Duration) {
+	v := uint64(0)
+	if d > 0 {
+		v = uint64(d / time.Microsecond)
+	}
+	i := bucketIndex(v)
+	if i >= len(h.counts) {
+		counts := make([]uint64, i+1, 2*(i+1))
+		copy(counts, h.counts)
+		h.counts = counts
+	}
+	h.counts[i]++
+	if h.total == 0 || v < h.min {
+		h.min = v
+	}
+	if v > h.max {
+		h.max = v
+	}
+	h.total++
+	h.sum += v
+}
+
+// quantile returns nearest rank quantile.
+func (h *histogram) quantile(q float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(q * float64(h.total)))
+	switch {
+	case rank <= 1:
+		return time.Duration(h.min) * time.Microsecond
+	case rank >= h.total:
+		return time.Duration(h.max) * time.Microsecond
+	}
+	var seen uint64
+	for i, c := range h.counts {
+		seen += c
+		if seen >= rank {
+			v := bucketValue(i)
+			// Bucket middle may be out of observed range.
+			if v < h.min {
+				v = h.min
+			}
+			if v > h.max {
+				v = h.max
+			}
+			return time.Duration(v) * time.Microsecond
+		}
+	}
+	return time.Duration(h.max) * time.Microsecond
+}
+
+func (h *histogram) mean() time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	return time.Duration(h.sum/h.total) * time.Microsecond
+}
@@ -0,0 +1,207 @@
+package report
+
+import (
+	_ "embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var (
+	//go:embed report.html.tmpl
+	htmlTemplateText string
+	//go:embed report.md.tmpl
+	markdownTemplateText string
+)
+
+var funcs = map[string]any{
+	"duration":  FormatDuration,
+	"percent":   FormatPercent,
+	"quantile":  QuantileName,
+	"quantiles": func() []float64 { return Quantiles },
+	"tag":       tagName,
+	"time":      func(t time.Time) string { return t.UTC().Format(time.RFC3339) },
+	"float":     func(f float64) string { return strconv.FormatFloat(f, 'f', 1, 64) },
+	"sparkline": sparkline,
+}
+
+var (
+	htmlTemplate = htmltemplate.Must(htmltemplate.New("report").Funcs(funcs).Funcs(map[string]any{
+		"chart": chart,
+	}).Parse(htmlTemplateText))
+	markdownTemplate = template.Must(template.New("report").Funcs(funcs).Parse(markdownTemplateText))
+)
+
+// WriteHTML writes self-contained HTML report with SVG charts.
+func WriteHTML(w io.Writer, r *Report) error {
+	return htmlTemplate.Execute(w, r)
+}
+
+// WriteMarkdown writes Markdown report. Timelines are drawn by sparklines.
+func WriteMarkdown(w io.Writer, r *Report) error {
+	return markdownTemplate.Execute(w, r)
+}
+
+// FormatDuration formats duration in µs, ms or s with three significant digits.
+func FormatDuration(d time.Duration) string {
+	switch {
+	case d < time.Millisecond:
+		return strconv.FormatInt(d.Microseconds(), 10) + "µs"
+	case d < time.Second:
+		return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 2, 64) + "ms"
+	default:
+		return strconv.FormatFloat(d.Seconds(), 'f', 3, 64) + "s"
+	}
+}
+
+func FormatPercent(f float64) string {
+	return strconv.FormatFloat(f*100, 'f', 2, 64) + "%"
+}
+
+// QuantileName returns p50 for 0.5 and p99.9 for 0.999.
+func QuantileName(q float64) string {
+	return "p" + strconv.FormatFloat(math.Round(q*100*1e4)/1e4, 'f', -1, 64)
+}
+
+func tagName(tag string) string {
+	if tag == "" {
+		return "(untagged)"
+	}
+	return tag
+}
+
+// TimelineSeries returns timeline values by metric: rps, errors, avg, p50, p99 or max.
+// Latencies are in milliseconds.
+func (r *Report) TimelineSeries(metric string) []float64 {
+	values := make([]float64, len(r.Timeline))
+	for i, p := range r.Timeline {
+		var v float64
+		switch metric {
+		case "rps":
+			v = float64(p.Count)
+		case "errors":
+			v = float64(p.Errors)
+		case "avg":
+			v = ms(p.Avg)
+		case "p50":
+			v = ms(p.P50)
+		case "p99":
+			v = ms(p.P99)
+		case "max":
+			v = ms(p.Max)
+		default:
+			panic("unknown timeline metric " + metric)
+		}
+		values[i] = v
+	}
+	return values
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+const sparkTicks = "▁▂▃▄▅▆▇█"
+
+// sparklineWidth is max number of sparkline characters. Longer series are downsampled by max.
+const sparklineWidth = 80
+
+func sparkline(values []float64) string {
+	values = downsample(values, sparklineWidth)
+	max := 0.0
+	for _, v := range values {
+		max = math.Max(max, v)
+	}
+	ticks := []rune(sparkTicks)
+	var sb strings.Builder
+	for _, v := range values {
+		i := 0
+		if max > 0 {
+			i = int(v / max * float64(len(ticks)-1))
+		}
+		sb.WriteRune(ticks[i])
+	}
+	return sb.String()
+}
+
+// downsample returns at most n values, taking max of adjacent values.
+func downsample(values []float64, n int) []float64 {
+	if len(values) <= n {
+		return values
+	}
+	result := make([]float64, n)
+	for i := range result {
+		from, to := i*len(values)/n, (i+1)*len(values)/n
+		for _, v := range values[from:to] {
+			result[i] = math.Max(result[i], v)
+		}
+	}
+	return result
+}
+
+type chartSeries struct {
+	Name   string
+	Color  string
+	Values []float64
+}
+
+var chartColors = []string{"#1f77b4", "#d62728", "#2ca02c", "#ff7f0e"}
+
+const (
+	chartWidth   = 900
+	chartHeight  = 200
+	chartPadding = 40
+)
+
+// chart returns SVG line chart of timeline metrics.
+func chart(r *Report, unit string, metrics ...string) htmltemplate.HTML {
+	series := make([]chartSeries, len(metrics))
+	max := 0.0
+	for i, m := range metrics {
+		series[i] = chartSeries{Name: m, Color: chartColors[i%len(chartColors)], Values: r.TimelineSeries(m)}
+		for _, v := range series[i].Values {
+			max = math.Max(max, v)
+		}
+	}
+	if max == 0 {
+		max = 1
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg viewBox="0 0 %d %d" width="100%%" xmlns="http://www.w3.org/2000/svg">`,
+		chartWidth, chartHeight+chartPadding)
+	left, right, bottom := float64(chartPadding), float64(chartWidth-10), float64(chartHeight)
+	fmt.Fprintf(&sb, `<line x1="%.0f" y1="10" x2="%.0f" y2="%.0f" stroke="#999"/>`, left, left, bottom)
+	fmt.Fprintf(&sb, `<line x1="%.0f" y1="%.0f" x2="%.0f" y2="%.0f" stroke="#999"/>`, left, bottom, right, bottom)
+	fmt.Fprintf(&sb, `<text x="2" y="18" font-size="11">%s</text>`, htmltemplate.HTMLEscapeString(formatChartValue(max)))
+	fmt.Fprintf(&sb, `<text x="2" y="%.0f" font-size="11">0 %s</text>`, bottom, htmltemplate.HTMLEscapeString(unit))
+	for i, s := range series {
+		if len(s.Values) == 0 {
+			continue
+		}
+		step := 0.0
+		if len(s.Values) > 1 {
+			step = (right - left) / float64(len(s.Values)-1)
+		}
+		sb.WriteString(`<polyline fill="none" stroke-width="1.5" stroke="` + s.Color + `" points="`)
+		for j, v := range s.Values {
+			fmt.Fprintf(&sb, "%.1f,%.1f ", left+float64(j)*step, bottom-v/max*(bottom-10))
+		}
+		sb.WriteString(`"/>`)
+		fmt.Fprintf(&sb, `<text x="%.0f" y="%d" font-size="12" fill="%s">%s</text>`,
+			left+float64(i)*90, chartHeight+25, s.Color, htmltemplate.HTMLEscapeString(s.Name))
+	}
+	sb.WriteString(`</svg>`)
+	return htmltemplate.HTML(sb.String())
+}
+
+func formatChartValue(v float64) string {
+	if v >= 100 {
+		return strconv.FormatFloat(v, 'f', 0, 64)
+	}
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
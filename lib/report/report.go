@@ -0,0 +1,221 @@
+// Package report builds offline report of test results: RPS, latency and error timelines,
+// per tag quantiles, code distribution and comparison with baseline results.
+package report
+
+import (
+	"io"
+	"sort"
+	"time"
+
+	"github.com/yandex/pandora/core/aggregator/netsample"
+)
+
+// Quantiles of latency in report tables.
+var Quantiles = []float64{0.5, 0.9, 0.95, 0.99}
+
+// Report is statistics of one results file.
+type Report struct {
+	Title    string
+	Start    time.Time
+	End      time.Time
+	Total    Stats
+	Tags     []Stats
+	Timeline []Second
+	// ProtoCodes and NetCodes are sorted by count in descending order.
+	ProtoCodes []CodeCount
+	NetCodes   []CodeCount
+	// Comparison is set by Compare.
+	Comparison *Comparison
+}
+
+func (r *Report) Duration() time.Duration {
+	return r.End.Sub(r.Start)
+}
+
+// Stats of samples with the same tag. Total stats have empty tag.
+type Stats struct {
+	Tag    string
+	Count  int
+	Errors int
+	RPS    float64
+	Min    time.Duration
+	Avg    time.Duration
+	Max    time.Duration
+	// Quantiles are in order of report Quantiles.
+	Quantiles []time.Duration
+}
+
+func (s Stats) ErrorRate() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return float64(s.Errors) / float64(s.Count)
+}
+
+// Second is timeline point of samples started during one second.
+type Second struct {
+	Time   time.Time
+	Count  int
+	Errors int
+	Avg    time.Duration
+	P50    time.Duration
+	P99    time.Duration
+	Max    time.Duration
+}
+
+type CodeCount struct {
+	Code  int
+	Count int
+	Share float64
+}
+
+// IsError returns true for samples with net error or proto code of HTTP client or server error.
+func IsError(s *netsample.Sample) bool {
+	return s.NetCode() != 0 || s.ProtoCode() >= 400
+}
+
+// Builder collects samples into Report.
+type Builder struct {
+	start, end time.Time
+	total      *tagStats
+	tags       map[string]*tagStats
+	seconds    map[int64]*tagStats
+	protoCodes map[int]int
+	netCodes   map[int]int
+}
+
+type tagStats struct {
+	hist   histogram
+	errors int
+}
+
+func NewBuilder() *Builder {
+	return &Builder{
+		total:      &tagStats{},
+		tags:       map[string]*tagStats{},
+		seconds:    map[int64]*tagStats{},
+		protoCodes: map[int]int{},
+		netCodes:   map[int]int{},
+	}
+}
+
+func (b *Builder) Add(s *netsample.Sample) {
+	ts := s.Timestamp()
+	if b.start.IsZero() || ts.Before(b.start) {
+		b.start = ts
+	}
+	if end := ts.Add(s.RTT()); end.After(b.end) {
+		b.end = end
+	}
+	isErr := IsError(s)
+	add := func(st *tagStats) {
+		st.hist.add(s.RTT())
+		if isErr {
+			st.errors++
+		}
+	}
+	add(b.total)
+	tag := b.tags[s.Tags()]
+	if tag == nil {
+		tag = &tagStats{}
+		b.tags[s.Tags()] = tag
+	}
+	add(tag)
+	second := b.seconds[ts.Unix()]
+	if second == nil {
+		second = &tagStats{}
+		b.seconds[ts.Unix()] = second
+	}
+	add(second)
+	b.protoCodes[s.ProtoCode()]++
+	b.netCodes[s.NetCode()]++
+}
+
+// Report returns report of added samples.
+func (b *Builder) Report(title string) *Report {
+	r := &Report{
+		Title: title,
+		Start: b.start,
+		End:   b.end,
+	}
+	duration := r.Duration()
+	r.Total = b.total.stats("", duration)
+	for tag, st := range b.tags {
+		r.Tags = append(r.Tags, st.stats(tag, duration))
+	}
+	sort.Slice(r.Tags, func(i, j int) bool { return r.Tags[i].Tag < r.Tags[j].Tag })
+
+	if len(b.seconds) > 0 {
+		first, last := b.start.Unix(), b.start.Unix()
+		for sec := range b.seconds {
+			if sec > last {
+				last = sec
+			}
+		}
+		// Seconds without samples are in timeline too, to show gaps.
+		for sec := first; sec <= last; sec++ {
+			p := Second{Time: time.Unix(sec, 0)}
+			if st := b.seconds[sec]; st != nil {
+				p.Count = int(st.hist.total)
+				p.Errors = st.errors
+				p.Avg = st.hist.mean()
+				p.P50 = st.hist.quantile(0.5)
+				p.P99 = st.hist.quantile(0.99)
+				p.Max = time.Duration(st.hist.max) * time.Microsecond
+			}
+			r.Timeline = append(r.Timeline, p)
+		}
+	}
+	r.ProtoCodes = codeCounts(b.protoCodes, r.Total.Count)
+	r.NetCodes = codeCounts(b.netCodes, r.Total.Count)
+	return r
+}
+
+func (st *tagStats) stats(tag string, duration time.Duration) Stats {
+	s := Stats{
+		Tag:       tag,
+		Count:     int(st.hist.total),
+		Errors:    st.errors,
+		Min:       time.Duration(st.hist.min) * time.Microsecond,
+		Avg:       st.hist.mean(),
+		Max:       time.Duration(st.hist.max) * time.Microsecond,
+		Quantiles: make([]time.Duration, len(Quantiles)),
+	}
+	if duration > 0 {
+		s.RPS = float64(s.Count) / duration.Seconds()
+	}
+	for i, q := range Quantiles {
+		s.Quantiles[i] = st.hist.quantile(q)
+	}
+	return s
+}
+
+func codeCounts(counts map[int]int, total int) []CodeCount {
+	result := make([]CodeCount, 0, len(counts))
+	for code, count := range counts {
+		result = append(result, CodeCount{Code: code, Count: count, Share: float64(count) / float64(total)})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Code < result[j].Code
+	})
+	return result
+}
+
+// Read returns report of results in phout, jsonlines or binary format.
+func Read(r io.Reader, title string) (*Report, error) {
+	decoder := netsample.NewResultDecoder(r)
+	b := NewBuilder()
+	for {
+		s, err := decoder.Decode()
+		if err == io.EOF {
+			return b.Report(title), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		b.Add(s)
+	}
+}
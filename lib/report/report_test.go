@@ -0,0 +1,167 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistogram_Precision(t *testing.T) {
+	for _, v := range []uint64{0, 1, 127, 255, 256, 257, 1000, 12345, 999999, 1 << 40} {
+		i := bucketIndex(v)
+		value := bucketValue(i)
+		assert.InDelta(t, float64(v), float64(value), float64(v)/100+1, "value %v", v)
+		assert.Equal(t, i, bucketIndex(value), "value %v", v)
+	}
+	for i := 1; i < 4000; i++ {
+		assert.True(t, bucketValue(i-1) < bucketValue(i), "bucket %v", i)
+	}
+}
+
+func TestHistogram_Quantile(t *testing.T) {
+	var h histogram
+	assert.Zero(t, h.quantile(0.5))
+	for i := 1; i <= 1000; i++ {
+		h.add(time.Duration(i) * time.Millisecond)
+	}
+	assert.InEpsilon(t, float64(500*time.Millisecond), float64(h.quantile(0.5)), 0.01)
+	assert.InEpsilon(t, float64(990*time.Millisecond), float64(h.quantile(0.99)), 0.01)
+	assert.Equal(t, time.Second, h.quantile(1))
+	assert.Equal(t, time.Millisecond, h.quantile(0))
+	assert.Equal(t, 500500*time.Microsecond, h.mean())
+}
+
+// phout returns phout results of rps requests per second for duration seconds.
+func phout(duration, rps int, tags []string, rtt time.Duration, errorEvery int) string {
+	var sb strings.Builder
+	start := int64(1700000000000)
+	n := 0
+	for sec := 0; sec < duration; sec++ {
+		for i := 0; i < rps; i++ {
+			ts := start + int64(sec*1000+i*1000/rps)
+			code := 200
+			if errorEvery > 0 && n%errorEvery == 0 {
+				code = 503
+			}
+			fmt.Fprintf(&sb, "%d.%03d\t%s\t%d\t0\t0\t0\t0\t0\t10\t20\t0\t%d\n",
+				ts/1000, ts%1000, tags[n%len(tags)], rtt.Microseconds(), code)
+			n++
+		}
+	}
+	return sb.String()
+}
+
+func TestRead(t *testing.T) {
+	r, err := Read(strings.NewReader(phout(3, 10, []string{"a", "b"}, 10*time.Millisecond, 5)), "test")
+	require.NoError(t, err)
+
+	assert.Equal(t, "test", r.Title)
+	assert.Equal(t, 30, r.Total.Count)
+	assert.Equal(t, 6, r.Total.Errors)
+	assert.Equal(t, 0.2, r.Total.ErrorRate())
+	assert.InDelta(t, 10, r.Total.RPS, 0.5)
+	assert.Equal(t, 10*time.Millisecond, r.Total.Min)
+	assert.Equal(t, 10*time.Millisecond, r.Total.Max)
+	assert.Equal(t, []time.Duration{10 * time.Millisecond, 10 * time.Millisecond, 10 * time.Millisecond, 10 * time.Millisecond}, r.Total.Quantiles)
+
+	require.Len(t, r.Tags, 2)
+	assert.Equal(t, "a", r.Tags[0].Tag)
+	assert.Equal(t, 15, r.Tags[0].Count)
+	assert.Equal(t, 3, r.Tags[0].Errors)
+	assert.Equal(t, 3, r.Tags[1].Errors)
+
+	require.Len(t, r.Timeline, 3)
+	for _, p := range r.Timeline {
+		assert.Equal(t, 10, p.Count)
+		assert.Equal(t, 10*time.Millisecond, p.P99)
+	}
+	assert.Equal(t, []CodeCount{{200, 24, 0.8}, {503, 6, 0.2}}, r.ProtoCodes)
+	assert.Equal(t, []CodeCount{{0, 30, 1}}, r.NetCodes)
+}
+
+func TestRead_TimelineGaps(t *testing.T) {
+	input := "1700000000.000\ta\t1000\t0\t0\t0\t0\t0\t0\t0\t0\t200\n" +
+		"1700000003.000\ta\t1000\t0\t0\t0\t0\t0\t0\t0\t110\t0\n"
+	r, err := Read(strings.NewReader(input), "test")
+	require.NoError(t, err)
+	assert.Equal(t, []float64{1, 0, 0, 1}, r.TimelineSeries("rps"))
+	assert.Equal(t, []float64{0, 0, 0, 1}, r.TimelineSeries("errors"))
+}
+
+func TestCompare(t *testing.T) {
+	tags := []string{"a", "b"}
+	base, err := Read(strings.NewReader(phout(2, 10, tags, 10*time.Millisecond, 0)), "base")
+	require.NoError(t, err)
+
+	t.Run("no regressions", func(t *testing.T) {
+		current, err := Read(strings.NewReader(phout(2, 10, tags, 10500*time.Microsecond, 0)), "current")
+		require.NoError(t, err)
+		Compare(current, base, DefaultCompareConfig())
+		require.NotNil(t, current.Comparison)
+		assert.Equal(t, "base", current.Comparison.Baseline)
+		assert.Zero(t, current.Comparison.Regressions)
+		// rps, avg, quantiles and errors for total and two tags.
+		assert.Len(t, current.Comparison.Rows, 3*(3+len(Quantiles)))
+	})
+
+	t.Run("regressions", func(t *testing.T) {
+		current, err := Read(strings.NewReader(phout(2, 10, tags, 20*time.Millisecond, 2)), "current")
+		require.NoError(t, err)
+		Compare(current, base, DefaultCompareConfig())
+		c := current.Comparison
+		// Latency of total and both tags, and errors of total and tag a.
+		assert.Equal(t, 3*(1+len(Quantiles))+2, c.Regressions)
+		row := c.Rows[1]
+		assert.Equal(t, ComparisonRow{
+			Stats: "total", Metric: "avg", Baseline: "10.00ms", Current: "20.00ms", Change: 1, Regression: true,
+		}, row)
+		assert.Equal(t, "+100.0%", row.FormatChange())
+		errorsRow := c.Rows[2+len(Quantiles)]
+		assert.Equal(t, "errors", errorsRow.Metric)
+		assert.Equal(t, "+50.00 pp", errorsRow.FormatChange())
+		// Tag b has no errors.
+		assert.False(t, c.Rows[len(c.Rows)-1].Regression)
+	})
+}
+
+func TestRender(t *testing.T) {
+	base, err := Read(strings.NewReader(phout(2, 10, []string{"a", "<b>"}, 10*time.Millisecond, 0)), "base")
+	require.NoError(t, err)
+	current, err := Read(strings.NewReader(phout(2, 10, []string{"a", "<b>"}, 20*time.Millisecond, 0)), "current")
+	require.NoError(t, err)
+	Compare(current, base, DefaultCompareConfig())
+
+	html := &bytes.Buffer{}
+	require.NoError(t, WriteHTML(html, current))
+	assert.Contains(t, html.String(), "<title>current</title>")
+	assert.Contains(t, html.String(), "<polyline")
+	assert.Contains(t, html.String(), "&lt;b&gt;")
+	assert.NotContains(t, html.String(), "<b>")
+	assert.Contains(t, html.String(), `class="regression"`)
+
+	md := &bytes.Buffer{}
+	require.NoError(t, WriteMarkdown(md, current))
+	assert.Contains(t, md.String(), "# current\n")
+	assert.Contains(t, md.String(), "| p50 | `██` |")
+	assert.Contains(t, md.String(), "| a | 10 | 5.2 | 0.00% | 20.00ms | 20.00ms | 20.00ms | 20.00ms | 20.00ms | 20.00ms | 20.00ms |")
+	assert.Contains(t, md.String(), "| total | p99 | 10.00ms | 20.00ms | **+100.0%** :x: |")
+	assert.Contains(t, md.String(), "## Comparison with base")
+
+	empty := &bytes.Buffer{}
+	require.NoError(t, WriteMarkdown(empty, NewBuilder().Report("empty")))
+	assert.Equal(t, "# empty\n\nNo results.\n", empty.String())
+}
+
+func TestFormat(t *testing.T) {
+	assert.Equal(t, "850µs", FormatDuration(850*time.Microsecond))
+	assert.Equal(t, "12.35ms", FormatDuration(12345*time.Microsecond))
+	assert.Equal(t, "1.500s", FormatDuration(1500*time.Millisecond))
+	assert.Equal(t, "p99.9", QuantileName(0.999))
+	assert.Equal(t, "▁▄█", sparkline([]float64{0, 1, 2}))
+	assert.Equal(t, []float64{2, 4}, downsample([]float64{1, 2, 3, 4}, 2))
+}
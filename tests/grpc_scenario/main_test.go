@@ -18,6 +18,7 @@ import (
 	"github.com/yandex/pandora/components/providers/scenario/grpc/postprocessor"
 	_import "github.com/yandex/pandora/components/providers/scenario/import"
 	"github.com/yandex/pandora/core"
+	"github.com/yandex/pandora/core/aggregator/netsample"
 	"github.com/yandex/pandora/core/plugin/pluginconfig"
 	"github.com/yandex/pandora/core/warmup"
 	"github.com/yandex/pandora/examples/grpc/server"
@@ -133,7 +134,7 @@ func (s *GunSuite) Test_Scenario() {
 	}
 
 	g.Shoot(am)
-	s.Len(aggr.samples, 3)
+	s.Len(aggr.samples, 4) // 3 calls and transaction
 }
 
 func (s *GunSuite) Test_FullScenario() {
@@ -167,7 +168,7 @@ func (s *GunSuite) Test_FullScenario() {
 		s.True(ok)
 		g.Shoot(am)
 	}
-	s.Len(aggr.samples, 15)
+	s.Len(aggr.samples, 18) // 3 * (5 calls and transaction)
 	stats, err := s.srv.Stats(context.Background(), nil)
 	require.NoError(s.T(), err)
 
@@ -243,7 +244,40 @@ func (s *GunSuite) Test_ErrorScenario() {
 	}
 
 	g.Shoot(am)
-	s.Len(aggr.samples, 1)
+	s.Len(aggr.samples, 2) // failed call and transaction
+}
+
+func (s *GunSuite) Test_StatusErrorRetry() {
+	ctx := context.Background()
+	log := zap.NewNop()
+	g := grpcscenario.NewGun(grpcscenario.GunConfig{Target: s.grpcAddress})
+
+	sharedDeps, err := g.WarmUp(&warmup.Options{Log: log, Ctx: ctx})
+	s.NoError(err)
+
+	gunDeps := core.GunDeps{Ctx: ctx, Log: log, PoolID: "test", InstanceID: 1, Shared: sharedDeps}
+	aggr := &Aggregator{}
+	err = g.Bind(aggr, gunDeps)
+	s.NoError(err)
+
+	am := &grpcscenario.Scenario{
+		Name: "retry",
+		Calls: []grpcscenario.Call{
+			{
+				Name:     "auth",
+				Tag:      "auth",
+				Call:     "target.TargetService.Auth",
+				Metadata: map[string]string{"metadata": "server.proto"},
+				Payload:  []byte(`{"login": "1", "pass": "2"}`), // invalid pass
+				Retry:    scenario.Retry{Attempts: 1},
+			},
+		},
+	}
+
+	g.Shoot(am)
+	s.Require().Len(aggr.samples, 3) // call, its retry and transaction
+	s.Equal(400, aggr.samples[1].(*netsample.Sample).ProtoCode())
+	s.ErrorContains(aggr.samples[2].(*netsample.Sample).Err(), "invalid credentials")
 }
 
 type Aggregator struct {